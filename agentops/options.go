@@ -299,6 +299,8 @@ type TaskUpdateConfig struct {
 	AddRetries   int
 	AddTokens    TokenUsage
 	AddCost      float64
+	TraceID      string
+	SpanID       string
 	Metadata     map[string]any
 }
 
@@ -353,6 +355,20 @@ func WithTaskAddCost(cost float64) TaskUpdateOption {
 	}
 }
 
+// WithTaskUpdateTraceID sets the trace ID, linking the task to its trace.
+func WithTaskUpdateTraceID(traceID string) TaskUpdateOption {
+	return func(c *TaskUpdateConfig) {
+		c.TraceID = traceID
+	}
+}
+
+// WithTaskUpdateSpanID sets the span ID.
+func WithTaskUpdateSpanID(spanID string) TaskUpdateOption {
+	return func(c *TaskUpdateConfig) {
+		c.SpanID = spanID
+	}
+}
+
 // TaskCompleteOption configures task completion.
 type TaskCompleteOption func(*TaskCompleteConfig)
 
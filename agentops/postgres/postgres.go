@@ -370,6 +370,12 @@ func (s *Store) UpdateTask(ctx context.Context, id string, opts ...agentops.Task
 	if cfg.AddCost > 0 {
 		update.AddCostUsd(cfg.AddCost)
 	}
+	if cfg.TraceID != "" {
+		update.SetTraceID(cfg.TraceID)
+	}
+	if cfg.SpanID != "" {
+		update.SetSpanID(cfg.SpanID)
+	}
 	if cfg.Metadata != nil {
 		update.SetMetadata(cfg.Metadata)
 	}
@@ -0,0 +1,395 @@
+package trajectory
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/agentplexus/omniobserve/agentops"
+	"github.com/agentplexus/omniobserve/llmops"
+	"github.com/agentplexus/omniobserve/llmops/metrics"
+)
+
+// Compile-time interface checks.
+var (
+	_ llmops.Metric = (*ToolSequenceMetric)(nil)
+	_ llmops.Metric = (*ArgumentMatchMetric)(nil)
+	_ llmops.Metric = (*HandoffMetric)(nil)
+	_ llmops.Metric = (*EfficiencyMetric)(nil)
+)
+
+// MatchMode selects how an actual sequence is compared with the expected one.
+type MatchMode string
+
+const (
+	// MatchExact requires the sequences to be identical. Scores 1.0 or 0.0.
+	MatchExact MatchMode = "exact"
+
+	// MatchInOrder requires the expected items to appear in order, allowing
+	// extra items in between. Scores the fraction of the expected items in
+	// the longest in-order match.
+	MatchInOrder MatchMode = "in_order"
+
+	// MatchAnyOrder requires the expected items to appear in any order.
+	// Scores the fraction of the expected items present.
+	MatchAnyOrder MatchMode = "any_order"
+)
+
+// ToolSequenceMetric compares the tool names of a trajectory with the
+// expected tools.
+type ToolSequenceMetric struct {
+	Mode MatchMode
+}
+
+// NewToolSequenceMetric creates a new tool sequence metric.
+func NewToolSequenceMetric(mode MatchMode) *ToolSequenceMetric {
+	return &ToolSequenceMetric{Mode: mode}
+}
+
+// Name returns the metric name, such as "trajectory_in_order_match".
+func (m *ToolSequenceMetric) Name() string {
+	return "trajectory_" + string(m.Mode) + "_match"
+}
+
+// Evaluate compares the tools called with the expected tools.
+func (m *ToolSequenceMetric) Evaluate(input llmops.EvalInput) (llmops.MetricScore, error) {
+	t, expected, err := trajectoryInputs(input)
+	if err != nil {
+		return failedScore(m.Name(), err)
+	}
+
+	actual := t.Tools()
+	want := expected.Tools()
+	score, matched, err := matchSequence(m.Mode, want, actual)
+	if err != nil {
+		return failedScore(m.Name(), err)
+	}
+
+	return llmops.MetricScore{
+		Name:   m.Name(),
+		Score:  score,
+		Reason: fmt.Sprintf("Matched %d of %d expected tool calls (%d calls made)", matched, len(want), len(actual)),
+		Metadata: map[string]any{
+			"mode":           string(m.Mode),
+			"expected_tools": want,
+			"actual_tools":   actual,
+			"matched":        matched,
+		},
+	}, nil
+}
+
+// ArgumentMatchMetric compares the arguments of each expected tool call with
+// the arguments the agent passed. Each expected step is paired with the
+// first unpaired call of the same tool, and its arguments are compared as
+// JSON using metrics.JSONMatchMetric. The score is the mean over the
+// expected steps; steps without a matching call score 0.0.
+type ArgumentMatchMetric struct {
+	matcher *metrics.JSONMatchMetric
+}
+
+// NewArgumentMatchMetric creates a new argument match metric. The options
+// configure the JSON comparison, such as numeric tolerance.
+func NewArgumentMatchMetric(opts ...metrics.JSONMatchOption) *ArgumentMatchMetric {
+	return &ArgumentMatchMetric{matcher: metrics.NewJSONMatchMetric(opts...)}
+}
+
+// Name returns the metric name.
+func (m *ArgumentMatchMetric) Name() string {
+	return "trajectory_argument_match"
+}
+
+// Evaluate compares the tool call arguments with the expected arguments.
+func (m *ArgumentMatchMetric) Evaluate(input llmops.EvalInput) (llmops.MetricScore, error) {
+	t, expected, err := trajectoryInputs(input)
+	if err != nil {
+		return failedScore(m.Name(), err)
+	}
+	if len(expected.Steps) == 0 {
+		return llmops.MetricScore{
+			Name:   m.Name(),
+			Score:  1.0,
+			Reason: "No expected tool calls",
+		}, nil
+	}
+
+	used := make([]bool, len(t.Steps))
+	stepScores := make([]float64, len(expected.Steps))
+	var mismatches []string
+	total := 0.0
+	for i, want := range expected.Steps {
+		idx := -1
+		for j, s := range t.Steps {
+			if !used[j] && s.Tool == want.Tool {
+				idx = j
+				break
+			}
+		}
+		if idx < 0 {
+			mismatches = append(mismatches, fmt.Sprintf("%s: not called", want.Tool))
+			continue
+		}
+		used[idx] = true
+
+		score := 1.0
+		if len(want.Args) > 0 {
+			ms, err := m.matcher.Evaluate(llmops.EvalInput{
+				Output:   t.Steps[idx].Input,
+				Expected: want.Args,
+			})
+			if err != nil {
+				return failedScore(m.Name(), fmt.Errorf("failed to compare %s arguments: %w", want.Tool, err))
+			}
+			score = ms.Score
+			if score < 1.0 {
+				mismatches = append(mismatches, fmt.Sprintf("%s: %s", want.Tool, ms.Reason))
+			}
+		}
+		stepScores[i] = score
+		total += score
+	}
+
+	score := total / float64(len(expected.Steps))
+	reason := "All expected tool arguments matched"
+	if len(mismatches) > 0 {
+		reason = "Argument mismatches: " + strings.Join(mismatches, "; ")
+	}
+
+	return llmops.MetricScore{
+		Name:   m.Name(),
+		Score:  score,
+		Reason: reason,
+		Metadata: map[string]any{
+			"step_scores": stepScores,
+		},
+	}, nil
+}
+
+// HandoffMetric compares the handoffs of a trajectory with the expected
+// handoff path.
+type HandoffMetric struct {
+	Mode MatchMode
+}
+
+// NewHandoffMetric creates a new handoff path metric.
+func NewHandoffMetric(mode MatchMode) *HandoffMetric {
+	return &HandoffMetric{Mode: mode}
+}
+
+// Name returns the metric name, such as "handoff_exact_match".
+func (m *HandoffMetric) Name() string {
+	return "handoff_" + string(m.Mode) + "_match"
+}
+
+// Evaluate compares the handoffs with the expected handoffs.
+func (m *HandoffMetric) Evaluate(input llmops.EvalInput) (llmops.MetricScore, error) {
+	t, expected, err := trajectoryInputs(input)
+	if err != nil {
+		return failedScore(m.Name(), err)
+	}
+
+	actual := hopStrings(t.Handoffs)
+	want := hopStrings(expected.Handoffs)
+	score, matched, err := matchSequence(m.Mode, want, actual)
+	if err != nil {
+		return failedScore(m.Name(), err)
+	}
+
+	return llmops.MetricScore{
+		Name:   m.Name(),
+		Score:  score,
+		Reason: fmt.Sprintf("Matched %d of %d expected handoffs (%d handoffs made)", matched, len(want), len(actual)),
+		Metadata: map[string]any{
+			"mode":              string(m.Mode),
+			"expected_handoffs": want,
+			"actual_handoffs":   actual,
+			"matched":           matched,
+		},
+	}, nil
+}
+
+// EfficiencyMetric scores how many steps the agent took relative to a
+// budget. A call repeating an earlier successful call with the same tool and
+// input is redundant; one repeating a failed call is a retry, as are the
+// retries recorded on each invocation.
+//
+// The budget is Expected.MaxSteps, or else the number of expected steps, or
+// else the number of calls that are neither redundant nor retries. The
+// score is the budget divided by the calls made plus recorded retries,
+// capped at 1.0.
+type EfficiencyMetric struct{}
+
+// NewEfficiencyMetric creates a new efficiency metric.
+func NewEfficiencyMetric() *EfficiencyMetric {
+	return &EfficiencyMetric{}
+}
+
+// Name returns the metric name.
+func (m *EfficiencyMetric) Name() string {
+	return "trajectory_efficiency"
+}
+
+// Evaluate scores the number of steps against the budget.
+func (m *EfficiencyMetric) Evaluate(input llmops.EvalInput) (llmops.MetricScore, error) {
+	t, expected, err := trajectoryInputs(input)
+	if err != nil {
+		return failedScore(m.Name(), err)
+	}
+
+	redundant, repeatedFailures, recordedRetries := 0, 0, 0
+	succeeded := make(map[string]bool)
+	failed := make(map[string]bool)
+	for _, s := range t.Steps {
+		recordedRetries += s.RetryCount
+
+		key := s.Tool + "\x00" + canonicalInput(s.Input)
+		switch {
+		case succeeded[key]:
+			redundant++
+		case failed[key]:
+			repeatedFailures++
+		}
+		if s.Status == agentops.StatusFailed {
+			failed[key] = true
+		} else {
+			succeeded[key] = true
+		}
+	}
+
+	cost := len(t.Steps) + recordedRetries
+	budget := expected.MaxSteps
+	if budget <= 0 {
+		budget = len(expected.Steps)
+	}
+	if budget <= 0 {
+		budget = len(t.Steps) - redundant - repeatedFailures
+	}
+
+	score := 1.0
+	if cost > 0 {
+		score = min(1.0, float64(budget)/float64(cost))
+	}
+
+	return llmops.MetricScore{
+		Name:  m.Name(),
+		Score: score,
+		Reason: fmt.Sprintf("%d calls with %d redundant and %d retries against a budget of %d",
+			len(t.Steps), redundant, repeatedFailures+recordedRetries, budget),
+		Metadata: map[string]any{
+			"steps":           len(t.Steps),
+			"redundant_calls": redundant,
+			"retries":         repeatedFailures + recordedRetries,
+			"task_retries":    t.TaskRetries,
+			"budget":          budget,
+		},
+	}, nil
+}
+
+// =============================================================================
+// Helpers
+// =============================================================================
+
+// trajectoryInputs extracts the trajectory from EvalInput.Output and the
+// expected trajectory from EvalInput.Expected. Expected may also be a list
+// of tool names.
+func trajectoryInputs(input llmops.EvalInput) (*Trajectory, *Expected, error) {
+	var t *Trajectory
+	switch v := input.Output.(type) {
+	case *Trajectory:
+		t = v
+	case Trajectory:
+		t = &v
+	}
+	if t == nil {
+		return nil, nil, fmt.Errorf("output must be a trajectory, got %T", input.Output)
+	}
+
+	var expected *Expected
+	switch v := input.Expected.(type) {
+	case *Expected:
+		expected = v
+	case Expected:
+		expected = &v
+	case []string:
+		e := ExpectTools(v...)
+		expected = &e
+	}
+	if expected == nil {
+		return nil, nil, fmt.Errorf("expected must be an expected trajectory, got %T", input.Expected)
+	}
+	return t, expected, nil
+}
+
+// matchSequence scores actual against expected in the given mode, returning
+// the score and the number of expected items matched.
+func matchSequence(mode MatchMode, expected, actual []string) (float64, int, error) {
+	switch mode {
+	case MatchExact:
+		matched := 0
+		for i := 0; i < len(expected) && i < len(actual) && expected[i] == actual[i]; i++ {
+			matched++
+		}
+		if matched == len(expected) && len(expected) == len(actual) {
+			return 1.0, matched, nil
+		}
+		return 0.0, matched, nil
+
+	case MatchInOrder:
+		matched := metrics.LCSLength(expected, actual)
+		return fraction(matched, len(expected)), matched, nil
+
+	case MatchAnyOrder:
+		counts := make(map[string]int, len(actual))
+		for _, a := range actual {
+			counts[a]++
+		}
+		matched := 0
+		for _, e := range expected {
+			if counts[e] > 0 {
+				counts[e]--
+				matched++
+			}
+		}
+		return fraction(matched, len(expected)), matched, nil
+
+	default:
+		return 0, 0, fmt.Errorf("unknown match mode %q", mode)
+	}
+}
+
+// fraction returns matched/total, or 1.0 if nothing was expected.
+func fraction(matched, total int) float64 {
+	if total == 0 {
+		return 1.0
+	}
+	return float64(matched) / float64(total)
+}
+
+// hopStrings returns the hops as "from->to" strings.
+func hopStrings(hops []Hop) []string {
+	out := make([]string, len(hops))
+	for i, h := range hops {
+		out[i] = h.String()
+	}
+	return out
+}
+
+// canonicalInput returns a stable encoding of a tool input for detecting
+// repeated calls. Map keys are sorted by encoding/json.
+func canonicalInput(input map[string]any) string {
+	if len(input) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Sprint(input)
+	}
+	return string(data)
+}
+
+// failedScore returns a score recording err, along with err.
+func failedScore(name string, err error) (llmops.MetricScore, error) {
+	return llmops.MetricScore{
+		Name:  name,
+		Error: err.Error(),
+	}, err
+}
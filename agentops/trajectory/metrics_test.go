@@ -0,0 +1,158 @@
+package trajectory
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/agentplexus/omniobserve/agentops"
+	"github.com/agentplexus/omniobserve/llmops"
+)
+
+func TestMatchSequence(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     MatchMode
+		expected []string
+		actual   []string
+		score    float64
+		matched  int
+	}{
+		{"exact identical", MatchExact, []string{"a", "b"}, []string{"a", "b"}, 1, 2},
+		{"exact extra call", MatchExact, []string{"a", "b"}, []string{"a", "b", "c"}, 0, 2},
+		{"exact prefix mismatch", MatchExact, []string{"a", "b"}, []string{"b", "a"}, 0, 0},
+		{"exact empty", MatchExact, nil, nil, 1, 0},
+		{"exact empty expected", MatchExact, nil, []string{"a"}, 0, 0},
+		{"in order with extras", MatchInOrder, []string{"a", "b", "c"}, []string{"a", "x", "b", "c"}, 1, 3},
+		{"in order swapped", MatchInOrder, []string{"a", "b"}, []string{"b", "a"}, 0.5, 1},
+		{"in order empty expected", MatchInOrder, nil, []string{"a"}, 1, 0},
+		{"any order swapped", MatchAnyOrder, []string{"a", "b"}, []string{"b", "a"}, 1, 2},
+		{"any order duplicates", MatchAnyOrder, []string{"a", "a", "b"}, []string{"a", "b"}, 2.0 / 3, 2},
+		{"any order empty expected", MatchAnyOrder, nil, nil, 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, matched, err := matchSequence(tt.mode, tt.expected, tt.actual)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(score-tt.score) > 1e-9 || matched != tt.matched {
+				t.Errorf("expected score %v with %d matched, got %v with %d", tt.score, tt.matched, score, matched)
+			}
+		})
+	}
+
+	if _, _, err := matchSequence("fuzzy", nil, nil); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
+
+func TestArgumentMatchMetric(t *testing.T) {
+	traj := &Trajectory{Steps: []Step{
+		{Tool: "search", Input: map[string]any{"query": "go", "limit": 10}},
+		{Tool: "search", Input: map[string]any{"query": "rust"}},
+		{Tool: "fetch", Input: map[string]any{"url": "https://example.com"}},
+	}}
+
+	tests := []struct {
+		name     string
+		expected Expected
+		score    float64
+		steps    []float64
+	}{
+		{"no expected steps", Expected{}, 1, nil},
+		{"subset of arguments", Expected{Steps: []ExpectedStep{
+			{Tool: "search", Args: map[string]any{"query": "go"}},
+			{Tool: "fetch"},
+		}}, 1, []float64{1, 1}},
+		{"pairs repeated tools in order", Expected{Steps: []ExpectedStep{
+			{Tool: "search", Args: map[string]any{"query": "go"}},
+			{Tool: "search", Args: map[string]any{"query": "python"}},
+		}}, 0.5, []float64{1, 0}},
+		{"missing call", Expected{Steps: []ExpectedStep{
+			{Tool: "fetch"},
+			{Tool: "summarize"},
+		}}, 0.5, []float64{1, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, err := NewArgumentMatchMetric().Evaluate(llmops.EvalInput{Output: traj, Expected: tt.expected})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(score.Score-tt.score) > 1e-9 {
+				t.Errorf("expected score %v, got %v (%s)", tt.score, score.Score, score.Reason)
+			}
+			if tt.steps != nil {
+				got := score.Metadata.(map[string]any)["step_scores"]
+				if !reflect.DeepEqual(got, tt.steps) {
+					t.Errorf("expected step scores %v, got %v", tt.steps, got)
+				}
+			}
+		})
+	}
+}
+
+func TestEfficiencyMetric(t *testing.T) {
+	query := map[string]any{"q": "go"}
+	url := map[string]any{"url": "https://example.com"}
+	steps := []Step{
+		{Tool: "search", Input: query, Status: agentops.StatusCompleted},
+		{Tool: "search", Input: query, Status: agentops.StatusCompleted}, // redundant
+		{Tool: "fetch", Input: url, Status: agentops.StatusFailed},
+		{Tool: "fetch", Input: url, Status: agentops.StatusFailed},                   // retry
+		{Tool: "fetch", Input: url, Status: agentops.StatusCompleted, RetryCount: 1}, // retry, plus one recorded
+		{Tool: "search", Input: map[string]any{"q": "rust"}, Status: agentops.StatusCompleted},
+	}
+
+	tests := []struct {
+		name      string
+		steps     []Step
+		expected  Expected
+		score     float64
+		redundant int
+		retries   int
+		budget    int
+	}{
+		{"no steps", nil, Expected{}, 1, 0, 0, 0},
+		{"derived budget", steps, Expected{}, 3.0 / 7, 1, 3, 3},
+		{"expected steps budget", steps, ExpectTools("search", "fetch"), 2.0 / 7, 1, 3, 2},
+		{"max steps budget", steps, Expected{MaxSteps: 10, Steps: []ExpectedStep{{Tool: "search"}}}, 1, 1, 3, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, err := NewEfficiencyMetric().Evaluate(llmops.EvalInput{
+				Output:   &Trajectory{Steps: tt.steps},
+				Expected: tt.expected,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(score.Score-tt.score) > 1e-9 {
+				t.Errorf("expected score %v, got %v (%s)", tt.score, score.Score, score.Reason)
+			}
+			md := score.Metadata.(map[string]any)
+			if md["redundant_calls"] != tt.redundant || md["retries"] != tt.retries || md["budget"] != tt.budget {
+				t.Errorf("expected %d redundant, %d retries and budget %d, got %v", tt.redundant, tt.retries, tt.budget, md)
+			}
+		})
+	}
+}
+
+func TestTrajectoryInputs(t *testing.T) {
+	traj, expected, err := trajectoryInputs(llmops.EvalInput{
+		Output:   Trajectory{},
+		Expected: []string{"search", "fetch"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if traj == nil || !reflect.DeepEqual(expected.Tools(), []string{"search", "fetch"}) {
+		t.Errorf("expected tool names to become an expected trajectory, got %+v", expected)
+	}
+
+	score, err := NewToolSequenceMetric(MatchExact).Evaluate(llmops.EvalInput{Output: "text"})
+	if err == nil || score.Error == "" {
+		t.Error("expected an error for a non-trajectory output")
+	}
+}
@@ -0,0 +1,328 @@
+// Package trajectory evaluates agent behavior recorded by agentops.
+//
+// A Trajectory is the ordered sequence of tool invocations and handoffs of a
+// task or workflow, loaded from an agentops.Store. Trajectory metrics compare
+// it with an Expected trajectory and return llmops.MetricScore values, so
+// agent behavior can be regression-tested like any other LLM output:
+//
+//   - Tool sequence match: exact, in-order subset, or any-order
+//   - Argument match: expected tool arguments, compared as JSON
+//   - Handoff match: the agent-to-agent handoff path
+//   - Efficiency: redundant calls and retries against a step budget
+//
+// The metrics implement llmops.Metric with the Trajectory as
+// EvalInput.Output and the Expected trajectory as EvalInput.Expected, so they
+// can also be run by any llmops.Evaluator.
+//
+// # Usage
+//
+//	evaluator := trajectory.NewEvaluator(store)
+//
+//	result, err := evaluator.EvaluateWorkflow(ctx, workflowID, trajectory.Expected{
+//	    Steps: []trajectory.ExpectedStep{
+//	        {Tool: "search", Args: map[string]any{"query": "GDP 2024"}},
+//	        {Tool: "extract"},
+//	    },
+//	    Handoffs: []trajectory.Hop{{From: "orchestrator", To: "synthesis-agent"}},
+//	    MaxSteps: 3,
+//	})
+//
+//	// Write the scores back to the workflow's trace
+//	err = result.RecordFeedback(ctx, provider)
+package trajectory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/agentplexus/omniobserve/agentops"
+	"github.com/agentplexus/omniobserve/llmops"
+)
+
+// Step is a single tool invocation in a trajectory.
+type Step struct {
+	ID         string         `json:"id,omitempty"`
+	TaskID     string         `json:"task_id,omitempty"`
+	AgentID    string         `json:"agent_id,omitempty"`
+	Tool       string         `json:"tool"`
+	Input      map[string]any `json:"input,omitempty"`
+	Status     string         `json:"status,omitempty"`
+	RetryCount int            `json:"retry_count,omitempty"`
+	StartedAt  time.Time      `json:"started_at"`
+}
+
+// Hop is a handoff from one agent to another.
+type Hop struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// String returns the hop as "from->to".
+func (h Hop) String() string {
+	return h.From + "->" + h.To
+}
+
+// Trajectory is the ordered tool invocations and handoffs of a task or
+// workflow.
+type Trajectory struct {
+	WorkflowID string `json:"workflow_id,omitempty"`
+	TaskID     string `json:"task_id,omitempty"`
+	TraceID    string `json:"trace_id,omitempty"`
+	Steps      []Step `json:"steps"`
+	Handoffs   []Hop  `json:"handoffs,omitempty"`
+
+	// TaskRetries is the total retry count of the trajectory's tasks.
+	TaskRetries int `json:"task_retries,omitempty"`
+}
+
+// Tools returns the tool names of the steps, in order.
+func (t *Trajectory) Tools() []string {
+	tools := make([]string, len(t.Steps))
+	for i, s := range t.Steps {
+		tools[i] = s.Tool
+	}
+	return tools
+}
+
+// ExpectedStep is a tool call the agent is expected to make. Args, if set,
+// are the expected arguments; arguments not listed are ignored.
+type ExpectedStep struct {
+	Tool string         `json:"tool"`
+	Args map[string]any `json:"args,omitempty"`
+}
+
+// Expected is the expected trajectory of a task or workflow.
+type Expected struct {
+	Steps    []ExpectedStep `json:"steps,omitempty"`
+	Handoffs []Hop          `json:"handoffs,omitempty"`
+
+	// MaxSteps is the step budget for the efficiency metric. If zero, the
+	// number of expected steps is used.
+	MaxSteps int `json:"max_steps,omitempty"`
+}
+
+// ExpectTools returns an Expected trajectory calling the given tools in
+// order, with any arguments.
+func ExpectTools(tools ...string) Expected {
+	steps := make([]ExpectedStep, len(tools))
+	for i, tool := range tools {
+		steps[i] = ExpectedStep{Tool: tool}
+	}
+	return Expected{Steps: steps}
+}
+
+// Tools returns the expected tool names, in order.
+func (e *Expected) Tools() []string {
+	tools := make([]string, len(e.Steps))
+	for i, s := range e.Steps {
+		tools[i] = s.Tool
+	}
+	return tools
+}
+
+// =============================================================================
+// Loading
+// =============================================================================
+
+// LoadTask loads the trajectory of a single task.
+func LoadTask(ctx context.Context, store agentops.Store, taskID string) (*Trajectory, error) {
+	task, err := store.GetTask(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+
+	t := &Trajectory{
+		WorkflowID:  task.WorkflowID,
+		TaskID:      task.ID,
+		TraceID:     task.TraceID,
+		TaskRetries: task.RetryCount,
+	}
+	if err := t.loadSteps(ctx, store, task.ID); err != nil {
+		return nil, err
+	}
+	t.sortSteps()
+	return t, nil
+}
+
+// LoadWorkflow loads the trajectory of a workflow, combining the tool
+// invocations of all its tasks with its handoffs.
+func LoadWorkflow(ctx context.Context, store agentops.Store, workflowID string) (*Trajectory, error) {
+	workflow, err := store.GetWorkflow(ctx, workflowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workflow: %w", err)
+	}
+
+	t := &Trajectory{
+		WorkflowID: workflow.ID,
+		TraceID:    workflow.TraceID,
+	}
+
+	tasks, err := store.ListTasks(ctx, agentops.WithFilterWorkflow(workflowID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+	for _, task := range tasks {
+		t.TaskRetries += task.RetryCount
+		if err := t.loadSteps(ctx, store, task.ID); err != nil {
+			return nil, err
+		}
+	}
+	t.sortSteps()
+
+	handoffs, err := store.ListHandoffs(ctx, agentops.WithFilterWorkflow(workflowID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list handoffs: %w", err)
+	}
+	sort.SliceStable(handoffs, func(i, j int) bool {
+		return handoffs[i].InitiatedAt.Before(handoffs[j].InitiatedAt)
+	})
+	for _, h := range handoffs {
+		t.Handoffs = append(t.Handoffs, Hop{From: h.FromAgentID, To: h.ToAgentID})
+	}
+
+	return t, nil
+}
+
+// loadSteps appends the tool invocations of a task.
+func (t *Trajectory) loadSteps(ctx context.Context, store agentops.Store, taskID string) error {
+	invocations, err := store.ListToolInvocations(ctx, agentops.WithFilterTask(taskID))
+	if err != nil {
+		return fmt.Errorf("failed to list tool invocations: %w", err)
+	}
+	for _, inv := range invocations {
+		t.Steps = append(t.Steps, Step{
+			ID:         inv.ID,
+			TaskID:     inv.TaskID,
+			AgentID:    inv.AgentID,
+			Tool:       inv.ToolName,
+			Input:      inv.Input,
+			Status:     inv.Status,
+			RetryCount: inv.RetryCount,
+			StartedAt:  inv.StartedAt,
+		})
+	}
+	return nil
+}
+
+// sortSteps orders the steps by start time, as stores may list them newest
+// first.
+func (t *Trajectory) sortSteps() {
+	sort.SliceStable(t.Steps, func(i, j int) bool {
+		return t.Steps[i].StartedAt.Before(t.Steps[j].StartedAt)
+	})
+}
+
+// =============================================================================
+// Evaluator
+// =============================================================================
+
+// Evaluator loads trajectories from a store and scores them.
+type Evaluator struct {
+	store   agentops.Store
+	metrics []llmops.Metric
+}
+
+// NewEvaluator creates an evaluator reading from the given store. If no
+// metrics are given, DefaultMetrics is used.
+func NewEvaluator(store agentops.Store, metrics ...llmops.Metric) *Evaluator {
+	if len(metrics) == 0 {
+		metrics = DefaultMetrics()
+	}
+	return &Evaluator{
+		store:   store,
+		metrics: metrics,
+	}
+}
+
+// DefaultMetrics returns the in-order tool match, argument match, handoff
+// match, and efficiency metrics.
+func DefaultMetrics() []llmops.Metric {
+	return []llmops.Metric{
+		NewToolSequenceMetric(MatchInOrder),
+		NewArgumentMatchMetric(),
+		NewHandoffMetric(MatchExact),
+		NewEfficiencyMetric(),
+	}
+}
+
+// EvaluateTask loads and scores the trajectory of a task.
+func (e *Evaluator) EvaluateTask(ctx context.Context, taskID string, expected Expected) (*Result, error) {
+	t, err := LoadTask(ctx, e.store, taskID)
+	if err != nil {
+		return nil, err
+	}
+	return Evaluate(t, expected, e.metrics...), nil
+}
+
+// EvaluateWorkflow loads and scores the trajectory of a workflow.
+func (e *Evaluator) EvaluateWorkflow(ctx context.Context, workflowID string, expected Expected) (*Result, error) {
+	t, err := LoadWorkflow(ctx, e.store, workflowID)
+	if err != nil {
+		return nil, err
+	}
+	return Evaluate(t, expected, e.metrics...), nil
+}
+
+// Result is the evaluation of a trajectory.
+type Result struct {
+	Trajectory *Trajectory
+	llmops.EvalResult
+}
+
+// Evaluate scores a trajectory with the given metrics. Metric errors are
+// recorded in the scores rather than returned.
+func Evaluate(t *Trajectory, expected Expected, metrics ...llmops.Metric) *Result {
+	start := time.Now()
+	input := llmops.EvalInput{
+		Output:   t,
+		Expected: expected,
+		TraceID:  t.TraceID,
+	}
+
+	result := &Result{Trajectory: t}
+	for _, m := range metrics {
+		score, _ := m.Evaluate(input)
+		result.Scores = append(result.Scores, score)
+	}
+	result.Metadata = map[string]any{
+		"workflow_id": t.WorkflowID,
+		"task_id":     t.TaskID,
+		"steps":       len(t.Steps),
+	}
+	result.Duration = time.Since(start)
+	return result
+}
+
+// FeedbackScores returns the scores as feedback for the trajectory's trace.
+// Scores with errors are skipped.
+func (r *Result) FeedbackScores() []llmops.FeedbackScoreOpts {
+	var opts []llmops.FeedbackScoreOpts
+	for _, s := range r.Scores {
+		if s.Error != "" {
+			continue
+		}
+		opts = append(opts, llmops.FeedbackScoreOpts{
+			TraceID: r.Trajectory.TraceID,
+			Name:    s.Name,
+			Score:   s.Score,
+			Reason:  s.Reason,
+			Source:  "heuristic",
+		})
+	}
+	return opts
+}
+
+// RecordFeedback adds the scores as feedback to the trajectory's trace.
+func (r *Result) RecordFeedback(ctx context.Context, e llmops.Evaluator) error {
+	if r.Trajectory.TraceID == "" {
+		return fmt.Errorf("trajectory has no trace ID to record feedback on")
+	}
+	for _, opts := range r.FeedbackScores() {
+		if err := e.AddFeedbackScore(ctx, opts); err != nil {
+			return fmt.Errorf("failed to record %s: %w", opts.Name, err)
+		}
+	}
+	return nil
+}
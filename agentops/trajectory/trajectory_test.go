@@ -0,0 +1,108 @@
+package trajectory
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/agentplexus/omniobserve/agentops"
+)
+
+// fakeStore serves a fixed workflow, returning records newest first as
+// stores may. Methods not overridden panic through the nil embedded
+// interface.
+type fakeStore struct {
+	agentops.Store
+	workflow    *agentops.Workflow
+	tasks       []*agentops.Task
+	invocations []*agentops.ToolInvocation
+	handoffs    []*agentops.Handoff
+}
+
+func (s *fakeStore) GetWorkflow(_ context.Context, id string) (*agentops.Workflow, error) {
+	if s.workflow.ID != id {
+		return nil, agentops.ErrNotFound
+	}
+	return s.workflow, nil
+}
+
+func (s *fakeStore) ListTasks(_ context.Context, opts ...agentops.ListOption) ([]*agentops.Task, error) {
+	cfg := agentops.ApplyListOptions(opts...)
+	var out []*agentops.Task
+	for _, task := range s.tasks {
+		if task.WorkflowID == cfg.WorkflowID {
+			out = append(out, task)
+		}
+	}
+	return out, nil
+}
+
+func (s *fakeStore) ListToolInvocations(_ context.Context, opts ...agentops.ListOption) ([]*agentops.ToolInvocation, error) {
+	cfg := agentops.ApplyListOptions(opts...)
+	var out []*agentops.ToolInvocation
+	for _, inv := range s.invocations {
+		if inv.TaskID == cfg.TaskID {
+			out = append(out, inv)
+		}
+	}
+	return out, nil
+}
+
+func (s *fakeStore) ListHandoffs(_ context.Context, opts ...agentops.ListOption) ([]*agentops.Handoff, error) {
+	cfg := agentops.ApplyListOptions(opts...)
+	var out []*agentops.Handoff
+	for _, h := range s.handoffs {
+		if h.WorkflowID == cfg.WorkflowID {
+			out = append(out, h)
+		}
+	}
+	return out, nil
+}
+
+func TestLoadWorkflow_OrdersStepsAndHandoffs(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(s int) time.Time { return base.Add(time.Duration(s) * time.Second) }
+	store := &fakeStore{
+		workflow: &agentops.Workflow{ID: "wf-1", TraceID: "trace-1"},
+		tasks: []*agentops.Task{
+			{ID: "task-2", WorkflowID: "wf-1", RetryCount: 1},
+			{ID: "task-1", WorkflowID: "wf-1", RetryCount: 2},
+			{ID: "other", WorkflowID: "wf-2"},
+		},
+		invocations: []*agentops.ToolInvocation{
+			{ID: "inv-4", TaskID: "task-2", ToolName: "summarize", StartedAt: at(4)},
+			{ID: "inv-2", TaskID: "task-2", ToolName: "fetch", StartedAt: at(2)},
+			{ID: "inv-3", TaskID: "task-1", ToolName: "extract", StartedAt: at(3)},
+			{ID: "inv-1", TaskID: "task-1", ToolName: "search", StartedAt: at(1)},
+			{ID: "inv-x", TaskID: "other", ToolName: "ignored", StartedAt: at(0)},
+		},
+		handoffs: []*agentops.Handoff{
+			{WorkflowID: "wf-1", FromAgentID: "research", ToAgentID: "writer", InitiatedAt: at(3)},
+			{WorkflowID: "wf-1", FromAgentID: "orchestrator", ToAgentID: "research", InitiatedAt: at(0)},
+		},
+	}
+
+	traj, err := LoadWorkflow(context.Background(), store, "wf-1")
+	if err != nil {
+		t.Fatalf("LoadWorkflow: %v", err)
+	}
+
+	if want := []string{"search", "fetch", "extract", "summarize"}; !reflect.DeepEqual(traj.Tools(), want) {
+		t.Errorf("expected tools %v, got %v", want, traj.Tools())
+	}
+	wantHops := []Hop{{From: "orchestrator", To: "research"}, {From: "research", To: "writer"}}
+	if !reflect.DeepEqual(traj.Handoffs, wantHops) {
+		t.Errorf("expected handoffs %v, got %v", wantHops, traj.Handoffs)
+	}
+	if traj.TraceID != "trace-1" || traj.TaskRetries != 3 {
+		t.Errorf("expected trace-1 with 3 task retries, got %q with %d", traj.TraceID, traj.TaskRetries)
+	}
+}
+
+func TestLoadWorkflow_NotFound(t *testing.T) {
+	store := &fakeStore{workflow: &agentops.Workflow{ID: "wf-1"}}
+	if _, err := LoadWorkflow(context.Background(), store, "missing"); err == nil {
+		t.Error("expected an error for a missing workflow")
+	}
+}
@@ -1,13 +1,18 @@
 // Example: evaluation
 //
 // Demonstrates using evaluation metrics to assess LLM outputs.
-// Shows both code-based metrics (no LLM required) and LLM-based metrics.
+// Shows both code-based metrics (no LLM required) and LLM-based metrics,
+// and a dataset evaluation report.
 //
 // Usage:
 //
 //	# For code-based metrics only
 //	go run main.go
 //
+//	# Also write the report as HTML and JUnit XML
+//	export REPORT_DIR=./eval-report
+//	go run main.go
+//
 //	# For LLM-based metrics
 //	export OPENAI_API_KEY=your-key
 //	go run main.go
@@ -15,12 +20,15 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"path/filepath"
 
 	"github.com/agentplexus/omnillm"
 	"github.com/agentplexus/omniobserve/llmops"
 	"github.com/agentplexus/omniobserve/llmops/metrics"
+	"github.com/agentplexus/omniobserve/llmops/report"
 )
 
 func main() {
@@ -86,6 +94,12 @@ func main() {
 	})
 	fmt.Printf("Contains 'error' (no match): %.1f - %s\n", score.Score, score.Reason)
 
+	fmt.Println()
+	fmt.Println("=== Evaluation Report ===")
+	fmt.Println()
+
+	runReport()
+
 	// LLM-based metrics (optional)
 	apiKey := os.Getenv("OPENAI_API_KEY")
 	if apiKey == "" {
@@ -180,3 +194,79 @@ func main() {
 
 	fmt.Println("\nEvaluation examples complete!")
 }
+
+// runReport evaluates a small question-answering dataset with gated
+// metrics and writes the report. The Markdown report is printed; if
+// REPORT_DIR is set, HTML and JUnit XML reports are written there.
+func runReport() {
+	dataset := []llmops.DatasetItem{
+		{ID: "capital-france", Input: "What is the capital of France?", Expected: "Paris"},
+		{ID: "capital-japan", Input: "What is the capital of Japan?", Expected: "Tokyo"},
+		{ID: "largest-planet", Input: "What is the largest planet?", Expected: "Jupiter"},
+		{ID: "speed-of-light", Input: "How fast is light in km/s?", Expected: "about 299,792 km/s"},
+	}
+
+	// Canned outputs standing in for the application under test
+	outputs := map[string]string{
+		"capital-france": "Paris",
+		"capital-japan":  "tokyo",
+		"largest-planet": "Saturn",
+		"speed-of-light": "roughly 300,000 km/s",
+	}
+
+	evalMetrics := []llmops.Metric{
+		metrics.NewThresholdMetric(metrics.NewTokenF1Metric(), 0.5),
+		metrics.NewThresholdMetric(metrics.NewExactMatchMetricWithOptions(metrics.WithCaseSensitive(false)), 1.0),
+		metrics.NewROUGEMetric(metrics.ROUGEL),
+	}
+
+	items := make([]llmops.ExperimentItem, 0, len(dataset))
+	for _, item := range dataset {
+		input := llmops.EvalInput{
+			Input:    item.Input,
+			Output:   outputs[item.ID],
+			Expected: item.Expected,
+		}
+		var scores []llmops.MetricScore
+		for _, m := range evalMetrics {
+			score, _ := m.Evaluate(input)
+			scores = append(scores, score)
+		}
+		items = append(items, llmops.ExperimentItem{
+			DatasetItemID: item.ID,
+			Input:         item.Input,
+			Output:        input.Output,
+			Expected:      item.Expected,
+			Scores:        scores,
+		})
+	}
+
+	r := report.FromExperimentItems("QA regression", items, report.WithWorstExamples(2))
+	if err := r.WriteMarkdown(os.Stdout); err != nil {
+		log.Fatalf("Failed to write Markdown report: %v", err)
+	}
+
+	dir := os.Getenv("REPORT_DIR")
+	if dir == "" {
+		return
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		log.Fatalf("Failed to create report directory: %v", err)
+	}
+	writeReport(filepath.Join(dir, "report.html"), r.WriteHTML)
+	writeReport(filepath.Join(dir, "junit.xml"), r.WriteJUnit)
+	fmt.Printf("\nReports written to %s (passed: %v)\n", dir, r.Passed)
+}
+
+// writeReport writes a report to a file.
+func writeReport(path string, write func(w io.Writer) error) {
+	f, err := os.Create(path)
+	if err != nil {
+		log.Fatalf("Failed to create %s: %v", path, err)
+	}
+	defer f.Close()
+
+	if err := write(f); err != nil {
+		log.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
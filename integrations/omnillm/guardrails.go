@@ -0,0 +1,248 @@
+package omnillm
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/agentplexus/omnillm"
+	"github.com/agentplexus/omnillm/provider"
+
+	"github.com/agentplexus/omniobserve/llmops/guardrails"
+)
+
+// GuardedProvider wraps a provider.Provider with a guardrail pipeline.
+// Input checks run on the last user message before the call and output
+// checks run on each choice after it. Rewrites replace the message or
+// choice content, and blocked calls fail with a *guardrails.BlockedError.
+//
+// Since an observability hook cannot stop a call, guardrails are applied
+// by wrapping the provider. The client calls its hook before the provider,
+// so the hook must also be wrapped with Hook; otherwise it records the
+// input before it is redacted:
+//
+//	base, _ := omnillm.NewClient(config)
+//	guarded := omnillmhook.NewGuardedProvider(base.Provider(), pipeline)
+//	client, _ := omnillm.NewClient(omnillm.ClientConfig{
+//	    CustomProvider:    guarded,
+//	    ObservabilityHook: guarded.Hook(hook),
+//	})
+//
+// Output checks run inside the client, so the hook records rewritten
+// output without wrapping.
+//
+// For streams, output checks run when the stream ends. Chunks have already
+// been delivered by then, so a blocking output check replaces io.EOF with
+// the *guardrails.BlockedError and rewrites are not applied.
+type GuardedProvider struct {
+	provider provider.Provider
+	pipeline *guardrails.Pipeline
+}
+
+// Ensure GuardedProvider implements the interface at compile time
+var _ provider.Provider = (*GuardedProvider)(nil)
+
+// NewGuardedProvider wraps p with the guardrail pipeline.
+func NewGuardedProvider(p provider.Provider, pipeline *guardrails.Pipeline) *GuardedProvider {
+	return &GuardedProvider{provider: p, pipeline: pipeline}
+}
+
+// CreateChatCompletion checks the input, calls the provider, and checks
+// the output.
+func (g *GuardedProvider) CreateChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
+	req, input, err := g.checkInput(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := g.provider.CreateChatCompletion(ctx, req)
+	if err != nil || resp == nil || !g.pipeline.HasOutputChecks() {
+		return resp, err
+	}
+
+	for i := range resp.Choices {
+		msg := &resp.Choices[i].Message
+		if msg.Content == "" {
+			continue
+		}
+		result, err := g.pipeline.CheckOutput(ctx, input, msg.Content)
+		if err != nil {
+			return nil, err
+		}
+		msg.Content = result.Text
+	}
+	return resp, nil
+}
+
+// CreateChatCompletionStream checks the input and returns a stream whose
+// output is checked when it ends.
+func (g *GuardedProvider) CreateChatCompletionStream(ctx context.Context, req *provider.ChatCompletionRequest) (provider.ChatCompletionStream, error) {
+	req, input, err := g.checkInput(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := g.provider.CreateChatCompletionStream(ctx, req)
+	if err != nil || !g.pipeline.HasOutputChecks() {
+		return stream, err
+	}
+	return &guardedStream{ctx: ctx, stream: stream, pipeline: g.pipeline, input: input}, nil
+}
+
+// Close closes the underlying provider.
+func (g *GuardedProvider) Close() error {
+	return g.provider.Close()
+}
+
+// Name returns the underlying provider name.
+func (g *GuardedProvider) Name() string {
+	return g.provider.Name()
+}
+
+// withheldInput replaces input that a hook must not record because its
+// checks blocked or failed.
+const withheldInput = "[withheld by guardrails]"
+
+// inputCheck is the outcome of the input checks for a request.
+type inputCheck struct {
+	original *provider.ChatCompletionRequest
+	req      *provider.ChatCompletionRequest // request to send, nil if err is set
+	recorded *provider.ChatCompletionRequest // request a hook may record
+	input    string
+	err      error
+}
+
+// inputCheckKey is a private type used for storing the input check made
+// by the hook returned by GuardedProvider.Hook.
+type inputCheckKey struct{}
+
+// checkInput returns the request to send and the checked input, for output
+// checks. It reuses the result of the hook returned by Hook when that hook
+// has already checked req.
+func (g *GuardedProvider) checkInput(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionRequest, string, error) {
+	c, _ := ctx.Value(inputCheckKey{}).(*inputCheck)
+	if c == nil || c.original != req {
+		c = g.runInputChecks(ctx, req)
+	}
+	return c.req, c.input, c.err
+}
+
+// runInputChecks runs the input checks on the last user message. If a
+// check rewrites it, the request to send is a copy with the rewritten
+// message.
+func (g *GuardedProvider) runInputChecks(ctx context.Context, req *provider.ChatCompletionRequest) *inputCheck {
+	c := &inputCheck{original: req, req: req, recorded: req}
+	idx := -1
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		if req.Messages[i].Role == provider.RoleUser {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return c
+	}
+	c.input = req.Messages[idx].Content
+	if !g.pipeline.HasInputChecks() {
+		return c
+	}
+
+	result, err := g.pipeline.CheckInput(ctx, c.input)
+	if err != nil {
+		c.req, c.input, c.err = nil, "", err
+		c.recorded = withMessage(req, idx, withheldInput)
+		return c
+	}
+	if result.Text != c.input {
+		c.req = withMessage(req, idx, result.Text)
+		c.recorded = c.req
+		c.input = result.Text
+	}
+	return c
+}
+
+// withMessage returns a copy of req with the content of message idx
+// replaced, leaving the caller's request unchanged.
+func withMessage(req *provider.ChatCompletionRequest, idx int, content string) *provider.ChatCompletionRequest {
+	rewritten := *req
+	rewritten.Messages = append([]provider.Message(nil), req.Messages...)
+	rewritten.Messages[idx].Content = content
+	return &rewritten
+}
+
+// Hook wraps an observability hook so that it records the request after
+// the input checks. The checks run before the wrapped hook is called, and
+// the provider reuses their result rather than checking again. Input that
+// is blocked, or whose checks fail, is recorded as withheld.
+func (g *GuardedProvider) Hook(hook omnillm.ObservabilityHook) omnillm.ObservabilityHook {
+	return &guardedHook{provider: g, hook: hook}
+}
+
+// guardedHook runs the input checks before calling the wrapped hook.
+type guardedHook struct {
+	provider *GuardedProvider
+	hook     omnillm.ObservabilityHook
+}
+
+// Ensure guardedHook implements the interface at compile time
+var _ omnillm.ObservabilityHook = (*guardedHook)(nil)
+
+// BeforeRequest runs the input checks and calls the wrapped hook with the
+// request as it will be sent.
+func (h *guardedHook) BeforeRequest(ctx context.Context, info omnillm.LLMCallInfo, req *provider.ChatCompletionRequest) context.Context {
+	c := h.provider.runInputChecks(ctx, req)
+	ctx = context.WithValue(ctx, inputCheckKey{}, c)
+	return h.hook.BeforeRequest(ctx, info, c.recorded)
+}
+
+// AfterResponse calls the wrapped hook with the checked request.
+func (h *guardedHook) AfterResponse(ctx context.Context, info omnillm.LLMCallInfo, req *provider.ChatCompletionRequest, resp *provider.ChatCompletionResponse, err error) {
+	h.hook.AfterResponse(ctx, info, recordedRequest(ctx, req), resp, err)
+}
+
+// WrapStream calls the wrapped hook with the checked request.
+func (h *guardedHook) WrapStream(ctx context.Context, info omnillm.LLMCallInfo, req *provider.ChatCompletionRequest, stream provider.ChatCompletionStream) provider.ChatCompletionStream {
+	return h.hook.WrapStream(ctx, info, recordedRequest(ctx, req), stream)
+}
+
+// recordedRequest returns the request a hook may record for req.
+func recordedRequest(ctx context.Context, req *provider.ChatCompletionRequest) *provider.ChatCompletionRequest {
+	if c, _ := ctx.Value(inputCheckKey{}).(*inputCheck); c != nil && c.original == req {
+		return c.recorded
+	}
+	return req
+}
+
+// guardedStream runs output checks on the streamed content when the
+// stream ends.
+type guardedStream struct {
+	ctx      context.Context
+	stream   provider.ChatCompletionStream
+	pipeline *guardrails.Pipeline
+	input    string
+	content  strings.Builder
+	checked  bool
+}
+
+// Recv receives the next chunk. At the end of the stream it runs the
+// output checks, returning a *guardrails.BlockedError instead of io.EOF if
+// the output is blocked.
+func (s *guardedStream) Recv() (*provider.ChatCompletionChunk, error) {
+	chunk, err := s.stream.Recv()
+	if err == io.EOF && !s.checked {
+		s.checked = true
+		if _, checkErr := s.pipeline.CheckOutput(s.ctx, s.input, s.content.String()); checkErr != nil {
+			return chunk, checkErr
+		}
+		return chunk, err
+	}
+	if err == nil && chunk != nil && len(chunk.Choices) > 0 && chunk.Choices[0].Delta != nil {
+		s.content.WriteString(chunk.Choices[0].Delta.Content)
+	}
+	return chunk, err
+}
+
+// Close closes the underlying stream.
+func (s *guardedStream) Close() error {
+	return s.stream.Close()
+}
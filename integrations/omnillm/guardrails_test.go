@@ -0,0 +1,220 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/agentplexus/omnillm"
+	"github.com/agentplexus/omnillm/provider"
+
+	"github.com/agentplexus/omniobserve/llmops/guardrails"
+)
+
+// fakeProvider returns a fixed reply and records requests. Methods not
+// overridden panic through the nil embedded interface.
+type fakeProvider struct {
+	provider.Provider
+	reply    string
+	requests []*provider.ChatCompletionRequest
+}
+
+func (p *fakeProvider) Name() string { return "fake" }
+
+func (p *fakeProvider) CreateChatCompletion(_ context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
+	p.requests = append(p.requests, req)
+	return &provider.ChatCompletionResponse{
+		Choices: []provider.ChatCompletionChoice{{Message: provider.Message{Role: provider.RoleAssistant, Content: p.reply}}},
+	}, nil
+}
+
+func (p *fakeProvider) CreateChatCompletionStream(_ context.Context, req *provider.ChatCompletionRequest) (provider.ChatCompletionStream, error) {
+	p.requests = append(p.requests, req)
+	return &fakeStream{chunks: []string{p.reply[:len(p.reply)/2], p.reply[len(p.reply)/2:]}}, nil
+}
+
+// fakeStream streams content chunks, then io.EOF.
+type fakeStream struct {
+	chunks []string
+}
+
+func (s *fakeStream) Recv() (*provider.ChatCompletionChunk, error) {
+	if len(s.chunks) == 0 {
+		return nil, io.EOF
+	}
+	content := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return &provider.ChatCompletionChunk{
+		Choices: []provider.ChatCompletionChoice{{Delta: &provider.Message{Content: content}}},
+	}, nil
+}
+
+func (s *fakeStream) Close() error { return nil }
+
+func chatRequest(messages ...string) *provider.ChatCompletionRequest {
+	req := &provider.ChatCompletionRequest{}
+	for i, content := range messages {
+		role := provider.RoleUser
+		if i%2 == 1 {
+			role = provider.RoleAssistant
+		}
+		req.Messages = append(req.Messages, provider.Message{Role: role, Content: content})
+	}
+	return req
+}
+
+func TestGuardedProvider_RewritesInputAndOutput(t *testing.T) {
+	base := &fakeProvider{reply: "write to jo@example.com"}
+	g := NewGuardedProvider(base, guardrails.NewPipeline(
+		guardrails.WithInputChecks(guardrails.NewPII()),
+		guardrails.WithOutputChecks(guardrails.NewPII()),
+	))
+	req := chatRequest("I am ann@example.com", "hi", "my SSN is 123-45-6789")
+
+	resp, err := g.CreateChatCompletion(context.Background(), req)
+	if err != nil {
+		t.Fatalf("CreateChatCompletion: %v", err)
+	}
+
+	sent := base.requests[0]
+	if got := sent.Messages[2].Content; got != "my SSN is [SSN]" {
+		t.Errorf("expected the last user message to be redacted, got %q", got)
+	}
+	if got := sent.Messages[0].Content; got != "I am ann@example.com" {
+		t.Errorf("expected earlier messages to be unchecked, got %q", got)
+	}
+	if got := req.Messages[2].Content; got != "my SSN is 123-45-6789" {
+		t.Errorf("expected the caller's request to be unchanged, got %q", got)
+	}
+	if got := resp.Choices[0].Message.Content; got != "write to [EMAIL]" {
+		t.Errorf("expected the output to be redacted, got %q", got)
+	}
+}
+
+func TestGuardedProvider_BlockedInputSkipsCall(t *testing.T) {
+	base := &fakeProvider{reply: "ok"}
+	g := NewGuardedProvider(base, guardrails.NewPipeline(
+		guardrails.WithInputChecks(guardrails.MustDenylist("secrets", `password`)),
+	))
+
+	_, err := g.CreateChatCompletion(context.Background(), chatRequest("my password is hunter2"))
+	if !errors.Is(err, guardrails.ErrBlocked) {
+		t.Fatalf("expected ErrBlocked, got %v", err)
+	}
+	if len(base.requests) != 0 {
+		t.Errorf("expected the provider not to be called, got %d requests", len(base.requests))
+	}
+}
+
+func TestGuardedProvider_StreamBlockedAtEnd(t *testing.T) {
+	base := &fakeProvider{reply: "the password is hunter2"}
+	g := NewGuardedProvider(base, guardrails.NewPipeline(
+		guardrails.WithOutputChecks(guardrails.MustDenylist("secrets", `password`)),
+	))
+
+	stream, err := g.CreateChatCompletionStream(context.Background(), chatRequest("tell me"))
+	if err != nil {
+		t.Fatalf("CreateChatCompletionStream: %v", err)
+	}
+	chunks := 0
+	for {
+		_, err = stream.Recv()
+		if err != nil {
+			break
+		}
+		chunks++
+	}
+	if chunks != 2 {
+		t.Errorf("expected both chunks to be delivered, got %d", chunks)
+	}
+	var blocked *guardrails.BlockedError
+	if !errors.As(err, &blocked) || blocked.Stage != guardrails.StageOutput {
+		t.Errorf("expected an output *BlockedError instead of io.EOF, got %v", err)
+	}
+}
+
+// recordingHook records the last user message of each request it is given.
+type recordingHook struct {
+	before, after []string
+}
+
+func lastMessage(req *provider.ChatCompletionRequest) string {
+	return req.Messages[len(req.Messages)-1].Content
+}
+
+func (h *recordingHook) BeforeRequest(ctx context.Context, _ omnillm.LLMCallInfo, req *provider.ChatCompletionRequest) context.Context {
+	h.before = append(h.before, lastMessage(req))
+	return ctx
+}
+
+func (h *recordingHook) AfterResponse(_ context.Context, _ omnillm.LLMCallInfo, req *provider.ChatCompletionRequest, _ *provider.ChatCompletionResponse, _ error) {
+	h.after = append(h.after, lastMessage(req))
+}
+
+func (h *recordingHook) WrapStream(_ context.Context, _ omnillm.LLMCallInfo, req *provider.ChatCompletionRequest, stream provider.ChatCompletionStream) provider.ChatCompletionStream {
+	h.after = append(h.after, lastMessage(req))
+	return stream
+}
+
+func TestGuardedProvider_HookRecordsRedactedInput(t *testing.T) {
+	checks := 0
+	counting := guardrails.NewCheckFunc("counting", func(context.Context, guardrails.Content) (guardrails.Verdict, error) {
+		checks++
+		return guardrails.Verdict{}, nil
+	})
+	base := &fakeProvider{reply: "ok"}
+	g := NewGuardedProvider(base, guardrails.NewPipeline(
+		guardrails.WithInputChecks(counting, guardrails.NewPII()),
+	))
+	hook := &recordingHook{}
+	client, err := omnillm.NewClient(omnillm.ClientConfig{CustomProvider: g, ObservabilityHook: g.Hook(hook)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.CreateChatCompletion(context.Background(), chatRequest("my SSN is 123-45-6789")); err != nil {
+		t.Fatalf("CreateChatCompletion: %v", err)
+	}
+	stream, err := client.CreateChatCompletionStream(context.Background(), chatRequest("mail jo@example.com"))
+	if err != nil {
+		t.Fatalf("CreateChatCompletionStream: %v", err)
+	}
+	_ = stream.Close()
+
+	want := []string{"my SSN is [SSN]", "mail [EMAIL]"}
+	if !reflect.DeepEqual(hook.before, want) || !reflect.DeepEqual(hook.after, want) {
+		t.Errorf("expected the hook to see only redacted input %v, got %v and %v", want, hook.before, hook.after)
+	}
+	if got := lastMessage(base.requests[1]); got != "mail [EMAIL]" {
+		t.Errorf("expected the provider to be sent the redacted input, got %q", got)
+	}
+	if checks != 2 {
+		t.Errorf("expected the input to be checked once per call, got %d checks", checks)
+	}
+}
+
+func TestGuardedProvider_HookWithholdsBlockedInput(t *testing.T) {
+	base := &fakeProvider{reply: "ok"}
+	g := NewGuardedProvider(base, guardrails.NewPipeline(
+		guardrails.WithInputChecks(guardrails.MustDenylist("secrets", `password`)),
+	))
+	hook := &recordingHook{}
+	client, err := omnillm.NewClient(omnillm.ClientConfig{CustomProvider: g, ObservabilityHook: g.Hook(hook)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.CreateChatCompletion(context.Background(), chatRequest("my password is hunter2"))
+	if !errors.Is(err, guardrails.ErrBlocked) {
+		t.Fatalf("expected ErrBlocked, got %v", err)
+	}
+	if len(base.requests) != 0 {
+		t.Errorf("expected the provider not to be called, got %d requests", len(base.requests))
+	}
+	want := []string{withheldInput}
+	if !reflect.DeepEqual(hook.before, want) || !reflect.DeepEqual(hook.after, want) {
+		t.Errorf("expected the hook to see withheld input, got %v and %v", hook.before, hook.after)
+	}
+}
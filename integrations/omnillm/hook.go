@@ -14,14 +14,35 @@ import (
 // Hook implements omnillm.ObservabilityHook using an llmops.Provider.
 // It automatically creates spans for each LLM call with model, provider,
 // input/output, and token usage information.
+//
+// When the model requests tool calls, the hook records them as structured
+// output and creates a child tool span per call. The tool span ends when its
+// result is sent back to the model as a tool message, or when
+// RecordToolResult is called with the tool call ID. Tool spans that get no
+// result are ended with ErrToolCallAbandoned (see WithToolCallTTL).
 type Hook struct {
 	provider llmops.Provider
+	config   hookConfig
+	tools    toolSpans
+	tasks    taskRollup
 }
 
 // NewHook creates a new OmniLLM observability hook.
 // The provider should be initialized before passing to this function.
-func NewHook(provider llmops.Provider) *Hook {
-	return &Hook{provider: provider}
+func NewHook(provider llmops.Provider, opts ...HookOption) *Hook {
+	h := &Hook{
+		provider: provider,
+		config: hookConfig{
+			toolCallTTL:  DefaultToolCallTTL,
+			maxToolCalls: DefaultMaxPendingToolCalls,
+		},
+	}
+	for _, opt := range opts {
+		opt(&h.config)
+	}
+	h.tools.ttl = h.config.toolCallTTL
+	h.tools.max = h.config.maxToolCalls
+	return h
 }
 
 // Ensure Hook implements the interface at compile time
@@ -30,6 +51,9 @@ var _ omnillm.ObservabilityHook = (*Hook)(nil)
 // BeforeRequest is called before each LLM call.
 // It starts a new trace and span, returning a context with both attached.
 func (h *Hook) BeforeRequest(ctx context.Context, info omnillm.LLMCallInfo, req *provider.ChatCompletionRequest) context.Context {
+	// Link results of earlier tool calls
+	h.linkToolResults(req.Messages)
+
 	// Check if there's already a trace in context
 	_, hasTrace := h.provider.TraceFromContext(ctx)
 
@@ -56,6 +80,7 @@ func (h *Hook) BeforeRequest(ctx context.Context, info omnillm.LLMCallInfo, req
 		llmops.WithModel(req.Model),
 		llmops.WithProvider(info.ProviderName),
 		llmops.WithSpanInput(req.Messages),
+		llmops.WithSpanMetadata(requestMetadata(info, req)),
 	)
 	if err != nil {
 		// If span creation failed but we created a trace, end it
@@ -79,24 +104,39 @@ func (h *Hook) AfterResponse(ctx context.Context, info omnillm.LLMCallInfo, req
 	span := spanFromContext(ctx)
 	trace := traceFromContext(ctx)
 
+	var usage *llmops.TokenUsage
+	if err == nil && resp != nil {
+		usage = &llmops.TokenUsage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+			TotalTokens:      resp.Usage.TotalTokens,
+		}
+		model := resp.Model
+		if model == "" {
+			model = req.Model
+		}
+		h.priceUsage(model, usage)
+	}
+	h.rollupTask(ctx, span, usage)
+
 	// End span first
 	if span != nil {
 		if err != nil {
 			_ = span.End(llmops.WithEndError(err))
 		} else {
 			if resp != nil {
-				// Set output
+				_ = span.SetMetadata(responseMetadata(resp))
+
+				// Set output and record tool calls
 				if len(resp.Choices) > 0 {
-					output := resp.Choices[0].Message.Content
-					_ = span.SetOutput(output)
+					msg := resp.Choices[0].Message
+					calls := toolCallsFromMessage(msg)
+					_ = span.SetOutput(responseOutput(msg.Content, calls))
+					h.tools.start(ctx, span, calls)
 				}
 
 				// Set token usage
-				_ = span.SetUsage(llmops.TokenUsage{
-					PromptTokens:     resp.Usage.PromptTokens,
-					CompletionTokens: resp.Usage.CompletionTokens,
-					TotalTokens:      resp.Usage.TotalTokens,
-				})
+				_ = span.SetUsage(*usage)
 			}
 			_ = span.End()
 		}
@@ -105,25 +145,22 @@ func (h *Hook) AfterResponse(ctx context.Context, info omnillm.LLMCallInfo, req
 	// End trace if we created one
 	if trace != nil {
 		if resp != nil && len(resp.Choices) > 0 {
-			output := resp.Choices[0].Message.Content
-			_ = trace.SetOutput(output)
+			msg := resp.Choices[0].Message
+			_ = trace.SetOutput(responseOutput(msg.Content, toolCallsFromMessage(msg)))
 		}
 		_ = trace.End()
 	}
 }
 
 // WrapStream wraps a stream for observability.
-// The wrapped stream will buffer content and record it when the stream ends.
+// The wrapped stream will buffer content and tool call deltas, measure time
+// to first token and inter-token latency, and record them when the stream
+// ends.
 func (h *Hook) WrapStream(ctx context.Context, info omnillm.LLMCallInfo, req *provider.ChatCompletionRequest, stream provider.ChatCompletionStream) provider.ChatCompletionStream {
 	span := spanFromContext(ctx)
-	if span == nil {
+	if span == nil && !h.config.taskRollup {
 		return stream
 	}
 	trace := traceFromContext(ctx)
-	return &observedStream{
-		stream: stream,
-		span:   span,
-		trace:  trace,
-		info:   info,
-	}
+	return newObservedStream(ctx, h, info, req, stream, span, trace)
 }
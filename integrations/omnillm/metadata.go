@@ -0,0 +1,159 @@
+package omnillm
+
+import (
+	"github.com/agentplexus/omnillm"
+	"github.com/agentplexus/omnillm/provider"
+)
+
+// Span metadata keys recorded by the hook. Keys follow the OpenTelemetry
+// GenAI semantic conventions where one exists.
+const (
+	MetadataCallID        = "omnillm.call_id"
+	MetadataOperationName = "gen_ai.operation.name"
+	MetadataSystem        = "gen_ai.system"
+
+	// Request parameters
+	MetadataRequestModel            = "gen_ai.request.model"
+	MetadataRequestTemperature      = "gen_ai.request.temperature"
+	MetadataRequestTopP             = "gen_ai.request.top_p"
+	MetadataRequestMaxTokens        = "gen_ai.request.max_tokens"
+	MetadataRequestStopSequences    = "gen_ai.request.stop_sequences"
+	MetadataRequestPresencePenalty  = "gen_ai.request.presence_penalty"
+	MetadataRequestFrequencyPenalty = "gen_ai.request.frequency_penalty"
+	MetadataRequestLogitBias        = "gen_ai.request.logit_bias"
+	MetadataRequestStream           = "gen_ai.request.stream"
+	MetadataRequestTools            = "gen_ai.request.tools"
+	MetadataRequestToolChoice       = "gen_ai.request.tool_choice"
+
+	// Response metadata
+	MetadataResponseID                = "gen_ai.response.id"
+	MetadataResponseModel             = "gen_ai.response.model"
+	MetadataResponseFinishReasons     = "gen_ai.response.finish_reasons"
+	MetadataResponseSystemFingerprint = "gen_ai.response.system_fingerprint"
+	MetadataResponseChoices           = "gen_ai.response.choices"
+
+	// Streaming metrics; durations are in milliseconds
+	MetadataStreamTimeToFirstToken     = "gen_ai.stream.time_to_first_token_ms"
+	MetadataStreamInterTokenLatencyP50 = "gen_ai.stream.inter_token_latency_p50_ms"
+	MetadataStreamInterTokenLatencyP90 = "gen_ai.stream.inter_token_latency_p90_ms"
+	MetadataStreamInterTokenLatencyP99 = "gen_ai.stream.inter_token_latency_p99_ms"
+	MetadataStreamDuration             = "gen_ai.stream.duration_ms"
+	MetadataStreamChunkCount           = "gen_ai.stream.chunk_count"
+	MetadataStreamAbandoned            = "gen_ai.stream.abandoned"
+
+	// Tool spans
+	MetadataToolCallID = "gen_ai.tool.call.id"
+)
+
+// OperationChat is the operation name recorded for chat completions.
+const OperationChat = "chat"
+
+// Choice is a response choice, recorded when the response has more than one.
+type Choice struct {
+	Index        int        `json:"index"`
+	Content      string     `json:"content,omitempty"`
+	FinishReason string     `json:"finish_reason,omitempty"`
+	ToolCalls    []ToolCall `json:"tool_calls,omitempty"`
+}
+
+// requestMetadata returns the span metadata for a request. Parameters that
+// are not set are omitted, so that provider defaults are distinguishable
+// from explicit values.
+func requestMetadata(info omnillm.LLMCallInfo, req *provider.ChatCompletionRequest) map[string]any {
+	md := map[string]any{
+		MetadataOperationName: OperationChat,
+	}
+	if info.CallID != "" {
+		md[MetadataCallID] = info.CallID
+	}
+	if info.ProviderName != "" {
+		md[MetadataSystem] = info.ProviderName
+	}
+	if req.Model != "" {
+		md[MetadataRequestModel] = req.Model
+	}
+	if req.Temperature != nil {
+		md[MetadataRequestTemperature] = *req.Temperature
+	}
+	if req.TopP != nil {
+		md[MetadataRequestTopP] = *req.TopP
+	}
+	if req.MaxTokens != nil {
+		md[MetadataRequestMaxTokens] = *req.MaxTokens
+	}
+	if len(req.Stop) > 0 {
+		md[MetadataRequestStopSequences] = req.Stop
+	}
+	if req.PresencePenalty != nil {
+		md[MetadataRequestPresencePenalty] = *req.PresencePenalty
+	}
+	if req.FrequencyPenalty != nil {
+		md[MetadataRequestFrequencyPenalty] = *req.FrequencyPenalty
+	}
+	if len(req.LogitBias) > 0 {
+		md[MetadataRequestLogitBias] = req.LogitBias
+	}
+	if req.Stream != nil {
+		md[MetadataRequestStream] = *req.Stream
+	}
+	if len(req.Tools) > 0 {
+		md[MetadataRequestTools] = req.Tools
+	}
+	if req.ToolChoice != nil {
+		md[MetadataRequestToolChoice] = req.ToolChoice
+	}
+	return md
+}
+
+// responseMetadata returns the span metadata for a response.
+func responseMetadata(resp *provider.ChatCompletionResponse) map[string]any {
+	md := map[string]any{}
+	if resp.ID != "" {
+		md[MetadataResponseID] = resp.ID
+	}
+	if resp.Model != "" {
+		md[MetadataResponseModel] = resp.Model
+	}
+	if resp.SystemFingerprint != nil {
+		md[MetadataResponseSystemFingerprint] = *resp.SystemFingerprint
+	}
+
+	reasons := make([]string, 0, len(resp.Choices))
+	for _, c := range resp.Choices {
+		if c.FinishReason != nil {
+			reasons = append(reasons, *c.FinishReason)
+		}
+	}
+	if len(reasons) > 0 {
+		md[MetadataResponseFinishReasons] = reasons
+	}
+
+	if len(resp.Choices) > 1 {
+		choices := make([]Choice, len(resp.Choices))
+		for i, c := range resp.Choices {
+			choices[i] = Choice{
+				Index:     c.Index,
+				Content:   c.Message.Content,
+				ToolCalls: toolCallsFromMessage(c.Message),
+			}
+			if c.FinishReason != nil {
+				choices[i].FinishReason = *c.FinishReason
+			}
+		}
+		md[MetadataResponseChoices] = choices
+	}
+	return md
+}
+
+// chunkMetadata records the response metadata carried by a stream chunk.
+func chunkMetadata(md map[string]any, chunk *provider.ChatCompletionChunk) {
+	if chunk.ID != "" {
+		md[MetadataResponseID] = chunk.ID
+	}
+	if chunk.Model != "" {
+		md[MetadataResponseModel] = chunk.Model
+	}
+	if chunk.SystemFingerprint != nil {
+		md[MetadataResponseSystemFingerprint] = *chunk.SystemFingerprint
+	}
+}
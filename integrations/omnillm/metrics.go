@@ -0,0 +1,261 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/agentplexus/omnillm"
+	"github.com/agentplexus/omnillm/provider"
+
+	"github.com/agentplexus/omniobserve/observops"
+)
+
+// GenAI client metric names, following the OpenTelemetry GenAI semantic
+// conventions. MetricTimeToFirstToken and MetricOperationErrors have no
+// standard equivalent.
+const (
+	MetricTokenUsage        = "gen_ai.client.token.usage"
+	MetricOperationDuration = "gen_ai.client.operation.duration"
+	MetricTimeToFirstToken  = "gen_ai.client.time_to_first_token"
+	MetricOperationErrors   = "gen_ai.client.operation.errors"
+)
+
+// Metric attribute keys.
+const (
+	AttrOperationName = "gen_ai.operation.name"
+	AttrSystem        = "gen_ai.system"
+	AttrRequestModel  = "gen_ai.request.model"
+	AttrResponseModel = "gen_ai.response.model"
+	AttrTokenType     = "gen_ai.token.type"
+	AttrErrorType     = "error.type"
+)
+
+// Token types recorded in AttrTokenType.
+const (
+	TokenTypeInput  = "input"
+	TokenTypeOutput = "output"
+)
+
+// ErrorTypeOther is the error type recorded for unclassified errors.
+const ErrorTypeOther = "_OTHER"
+
+// MetricsHook implements omnillm.ObservabilityHook by recording GenAI client
+// metrics through an observops.Meter: token usage, operation duration,
+// time to first token for streams, and error counts by error type. All
+// metrics are dimensioned by operation, provider, and model.
+//
+// Use MultiHook to combine it with the tracing Hook.
+type MetricsHook struct {
+	tokenUsage       observops.Histogram
+	duration         observops.Histogram
+	timeToFirstToken observops.Histogram
+	errors           observops.Counter
+}
+
+// Ensure MetricsHook implements the interface at compile time
+var _ omnillm.ObservabilityHook = (*MetricsHook)(nil)
+
+// NewMetricsHook creates a metrics hook that records through meter.
+func NewMetricsHook(meter observops.Meter) (*MetricsHook, error) {
+	h := &MetricsHook{}
+	histograms := []struct {
+		dst  *observops.Histogram
+		name string
+		desc string
+		unit string
+	}{
+		{&h.tokenUsage, MetricTokenUsage, "Number of input and output tokens used", "{token}"},
+		{&h.duration, MetricOperationDuration, "GenAI operation duration", "s"},
+		{&h.timeToFirstToken, MetricTimeToFirstToken, "Time to receive the first token of a streamed response", "s"},
+	}
+	for _, m := range histograms {
+		histogram, err := meter.Histogram(m.name,
+			observops.WithDescription(m.desc),
+			observops.WithUnit(m.unit),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("create %s histogram: %w", m.name, err)
+		}
+		*m.dst = histogram
+	}
+
+	counter, err := meter.Counter(MetricOperationErrors,
+		observops.WithDescription("Number of failed GenAI operations"),
+		observops.WithUnit("{error}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create %s counter: %w", MetricOperationErrors, err)
+	}
+	h.errors = counter
+	return h, nil
+}
+
+// BeforeRequest is called before each LLM call. Metrics need no per-call
+// state, so the context is returned unchanged.
+func (h *MetricsHook) BeforeRequest(ctx context.Context, info omnillm.LLMCallInfo, req *provider.ChatCompletionRequest) context.Context {
+	return ctx
+}
+
+// AfterResponse records the duration, token usage, and errors of an LLM call.
+func (h *MetricsHook) AfterResponse(ctx context.Context, info omnillm.LLMCallInfo, req *provider.ChatCompletionRequest, resp *provider.ChatCompletionResponse, err error) {
+	attrs := metricAttributes(info, req)
+	if resp != nil && resp.Model != "" {
+		attrs = withAttribute(attrs, AttrResponseModel, resp.Model)
+	}
+
+	var usage *provider.Usage
+	if err == nil && resp != nil {
+		usage = &resp.Usage
+	}
+	h.record(ctx, attrs, time.Since(info.StartTime), usage, err)
+}
+
+// WrapStream wraps a stream to record time to first token, and duration,
+// token usage, and errors when the stream ends.
+func (h *MetricsHook) WrapStream(ctx context.Context, info omnillm.LLMCallInfo, req *provider.ChatCompletionRequest, stream provider.ChatCompletionStream) provider.ChatCompletionStream {
+	return &meteredStream{
+		ctx:    ctx,
+		hook:   h,
+		stream: stream,
+		info:   info,
+		attrs:  metricAttributes(info, req),
+	}
+}
+
+// record records the metrics of a completed operation.
+func (h *MetricsHook) record(ctx context.Context, attrs []observops.KeyValue, duration time.Duration, usage *provider.Usage, err error) {
+	if err != nil {
+		errAttrs := withAttribute(attrs, AttrErrorType, errorType(err))
+		h.duration.Record(ctx, duration.Seconds(), observops.WithAttributes(errAttrs...))
+		h.errors.Add(ctx, 1, observops.WithAttributes(errAttrs...))
+		return
+	}
+
+	h.duration.Record(ctx, duration.Seconds(), observops.WithAttributes(attrs...))
+	if usage == nil {
+		return
+	}
+	if usage.PromptTokens > 0 {
+		h.tokenUsage.Record(ctx, float64(usage.PromptTokens),
+			observops.WithAttributes(withAttribute(attrs, AttrTokenType, TokenTypeInput)...))
+	}
+	if usage.CompletionTokens > 0 {
+		h.tokenUsage.Record(ctx, float64(usage.CompletionTokens),
+			observops.WithAttributes(withAttribute(attrs, AttrTokenType, TokenTypeOutput)...))
+	}
+}
+
+// metricAttributes returns the attributes common to all metrics of a call.
+func metricAttributes(info omnillm.LLMCallInfo, req *provider.ChatCompletionRequest) []observops.KeyValue {
+	return []observops.KeyValue{
+		observops.Attribute(AttrOperationName, OperationChat),
+		observops.Attribute(AttrSystem, info.ProviderName),
+		observops.Attribute(AttrRequestModel, req.Model),
+	}
+}
+
+// withAttribute returns a copy of attrs with an attribute added.
+func withAttribute(attrs []observops.KeyValue, key string, value any) []observops.KeyValue {
+	out := make([]observops.KeyValue, len(attrs), len(attrs)+1)
+	copy(out, attrs)
+	return append(out, observops.Attribute(key, value))
+}
+
+// errorType returns a low-cardinality classification of an error: the
+// HTTP status code for API errors, or a name for well-known errors.
+func errorType(err error) string {
+	var apiErr *omnillm.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode != 0 {
+		return strconv.Itoa(apiErr.StatusCode)
+	}
+
+	known := []struct {
+		err  error
+		name string
+	}{
+		{context.DeadlineExceeded, "timeout"},
+		{context.Canceled, "canceled"},
+		{omnillm.ErrRateLimitExceeded, "rate_limit_exceeded"},
+		{omnillm.ErrQuotaExceeded, "quota_exceeded"},
+		{omnillm.ErrInvalidRequest, "invalid_request"},
+		{omnillm.ErrModelNotFound, "model_not_found"},
+		{omnillm.ErrServerError, "server_error"},
+		{omnillm.ErrNetworkError, "network_error"},
+		{omnillm.ErrInvalidResponse, "invalid_response"},
+		{omnillm.ErrStreamClosed, "stream_closed"},
+	}
+	for _, k := range known {
+		if errors.Is(err, k.err) {
+			return k.name
+		}
+	}
+	return ErrorTypeOther
+}
+
+// meteredStream wraps a provider.ChatCompletionStream to record GenAI
+// client metrics.
+type meteredStream struct {
+	ctx           context.Context
+	hook          *MetricsHook
+	stream        provider.ChatCompletionStream
+	info          omnillm.LLMCallInfo
+	attrs         []observops.KeyValue
+	responseModel string
+	usage         *provider.Usage
+	firstToken    bool
+	ended         bool
+}
+
+// Recv receives the next chunk from the stream.
+func (s *meteredStream) Recv() (*provider.ChatCompletionChunk, error) {
+	chunk, err := s.stream.Recv()
+
+	if err == io.EOF {
+		s.finalize(nil)
+		return chunk, err
+	}
+	if err != nil {
+		s.finalize(err)
+		return chunk, err
+	}
+
+	if chunk != nil {
+		if chunk.Model != "" {
+			s.responseModel = chunk.Model
+		}
+		if chunk.Usage != nil {
+			s.usage = chunk.Usage
+		}
+		if !s.firstToken && len(chunk.Choices) > 0 && chunk.Choices[0].Delta != nil &&
+			(chunk.Choices[0].Delta.Content != "" || len(chunk.Choices[0].Delta.ToolCalls) > 0) {
+			s.firstToken = true
+			s.hook.timeToFirstToken.Record(s.ctx, time.Since(s.info.StartTime).Seconds(),
+				observops.WithAttributes(s.attrs...))
+		}
+	}
+	return chunk, nil
+}
+
+// Close closes the underlying stream.
+func (s *meteredStream) Close() error {
+	s.finalize(nil)
+	return s.stream.Close()
+}
+
+// finalize records the duration, token usage, and error of the stream.
+func (s *meteredStream) finalize(err error) {
+	if s.ended {
+		return
+	}
+	s.ended = true
+
+	attrs := s.attrs
+	if s.responseModel != "" {
+		attrs = withAttribute(attrs, AttrResponseModel, s.responseModel)
+	}
+	s.hook.record(s.ctx, attrs, time.Since(s.info.StartTime), s.usage, err)
+}
@@ -0,0 +1,235 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/agentplexus/omnillm"
+	"github.com/agentplexus/omnillm/provider"
+
+	"github.com/agentplexus/omniobserve/observops"
+)
+
+// measurement is a value recorded by a memoryMeter instrument.
+type measurement struct {
+	name  string
+	value float64
+	attrs map[string]any
+}
+
+// memoryMeter is an in-memory observops.Meter that keeps every recorded
+// value. Methods not overridden panic through the nil embedded interface.
+type memoryMeter struct {
+	observops.Meter
+
+	mu           sync.Mutex
+	measurements []measurement
+}
+
+func (m *memoryMeter) Counter(name string, _ ...observops.MetricOption) (observops.Counter, error) {
+	return memoryInstrument{meter: m, name: name}, nil
+}
+
+func (m *memoryMeter) Histogram(name string, _ ...observops.MetricOption) (observops.Histogram, error) {
+	return memoryInstrument{meter: m, name: name}, nil
+}
+
+// recorded returns the measurements of the named instrument.
+func (m *memoryMeter) recorded(name string) []measurement {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []measurement
+	for _, r := range m.measurements {
+		if r.name == name {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// memoryInstrument records into a memoryMeter as a counter or histogram.
+type memoryInstrument struct {
+	meter *memoryMeter
+	name  string
+}
+
+func (i memoryInstrument) Add(_ context.Context, value float64, opts ...observops.RecordOption) {
+	i.Record(context.Background(), value, opts...)
+}
+
+func (i memoryInstrument) Record(_ context.Context, value float64, opts ...observops.RecordOption) {
+	attrs := make(map[string]any)
+	for _, kv := range observops.GetAttributes(opts...) {
+		attrs[kv.Key] = kv.Value
+	}
+	i.meter.mu.Lock()
+	defer i.meter.mu.Unlock()
+	i.meter.measurements = append(i.meter.measurements, measurement{name: i.name, value: value, attrs: attrs})
+}
+
+// scriptedStream returns its chunks in order, each after delay, then err
+// (io.EOF if nil).
+type scriptedStream struct {
+	chunks []*provider.ChatCompletionChunk
+	delay  time.Duration
+	err    error
+	closed bool
+}
+
+func (s *scriptedStream) Recv() (*provider.ChatCompletionChunk, error) {
+	time.Sleep(s.delay)
+	if len(s.chunks) == 0 {
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, io.EOF
+	}
+	chunk := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return chunk, nil
+}
+
+func (s *scriptedStream) Close() error {
+	s.closed = true
+	return nil
+}
+
+func contentChunk(content string) *provider.ChatCompletionChunk {
+	return &provider.ChatCompletionChunk{
+		Model:   "gpt-4o-2024-08-06",
+		Choices: []provider.ChatCompletionChoice{{Delta: &provider.Message{Content: content}}},
+	}
+}
+
+func newTestMetricsHook(t *testing.T) (*MetricsHook, *memoryMeter) {
+	t.Helper()
+	meter := &memoryMeter{}
+	h, err := NewMetricsHook(meter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h, meter
+}
+
+// drain receives from stream until it returns an error.
+func drain(stream provider.ChatCompletionStream) error {
+	for {
+		if _, err := stream.Recv(); err != nil {
+			return err
+		}
+	}
+}
+
+// tokenCounts returns the recorded token usage by token type.
+func tokenCounts(meter *memoryMeter) map[any]float64 {
+	counts := make(map[any]float64)
+	for _, m := range meter.recorded(MetricTokenUsage) {
+		counts[m.attrs[AttrTokenType]] += m.value
+	}
+	return counts
+}
+
+func TestMetricsHook_AfterResponse(t *testing.T) {
+	h, meter := newTestMetricsHook(t)
+	info := omnillm.LLMCallInfo{ProviderName: "openai", StartTime: time.Now().Add(-2 * time.Second)}
+	req := &provider.ChatCompletionRequest{Model: "gpt-4o"}
+
+	h.AfterResponse(context.Background(), info, req, &provider.ChatCompletionResponse{
+		Model: "gpt-4o-2024-08-06",
+		Usage: provider.Usage{PromptTokens: 12, CompletionTokens: 5},
+	}, nil)
+
+	durations := meter.recorded(MetricOperationDuration)
+	if len(durations) != 1 || durations[0].value < 2 {
+		t.Fatalf("expected one duration of at least 2s, got %+v", durations)
+	}
+	attrs := durations[0].attrs
+	if attrs[AttrSystem] != "openai" || attrs[AttrRequestModel] != "gpt-4o" ||
+		attrs[AttrResponseModel] != "gpt-4o-2024-08-06" || attrs[AttrOperationName] != OperationChat {
+		t.Errorf("unexpected duration attributes: %v", attrs)
+	}
+	if got := tokenCounts(meter); got[TokenTypeInput] != 12 || got[TokenTypeOutput] != 5 || len(got) != 2 {
+		t.Errorf("expected 12 input and 5 output tokens, got %v", got)
+	}
+	if got := meter.recorded(MetricOperationErrors); len(got) != 0 {
+		t.Errorf("expected no errors, got %+v", got)
+	}
+}
+
+func TestMetricsHook_AfterResponseError(t *testing.T) {
+	h, meter := newTestMetricsHook(t)
+	info := omnillm.LLMCallInfo{ProviderName: "openai", StartTime: time.Now()}
+	req := &provider.ChatCompletionRequest{Model: "gpt-4o"}
+
+	h.AfterResponse(context.Background(), info, req, nil, &omnillm.APIError{StatusCode: 429})
+
+	durations := meter.recorded(MetricOperationDuration)
+	if len(durations) != 1 || durations[0].attrs[AttrErrorType] != "429" {
+		t.Errorf("expected one duration with error type 429, got %+v", durations)
+	}
+	errs := meter.recorded(MetricOperationErrors)
+	if len(errs) != 1 || errs[0].value != 1 || errs[0].attrs[AttrErrorType] != "429" {
+		t.Errorf("expected one error with type 429, got %+v", errs)
+	}
+	if got := meter.recorded(MetricTokenUsage); len(got) != 0 {
+		t.Errorf("expected no token usage for a failed call, got %+v", got)
+	}
+}
+
+func TestMetricsHook_Stream(t *testing.T) {
+	h, meter := newTestMetricsHook(t)
+	info := omnillm.LLMCallInfo{ProviderName: "openai", StartTime: time.Now()}
+	req := &provider.ChatCompletionRequest{Model: "gpt-4o"}
+	usage := &provider.ChatCompletionChunk{Usage: &provider.Usage{PromptTokens: 7, CompletionTokens: 3}}
+	inner := &scriptedStream{chunks: []*provider.ChatCompletionChunk{contentChunk("Hel"), contentChunk("lo"), usage}}
+
+	stream := h.WrapStream(context.Background(), info, req, inner)
+	if err := drain(stream); err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+	_ = stream.Close()
+
+	if got := meter.recorded(MetricTimeToFirstToken); len(got) != 1 {
+		t.Errorf("expected one time to first token, got %+v", got)
+	}
+	durations := meter.recorded(MetricOperationDuration)
+	if len(durations) != 1 || durations[0].attrs[AttrResponseModel] != "gpt-4o-2024-08-06" {
+		t.Errorf("expected one duration with the streamed response model, got %+v", durations)
+	}
+	if got := tokenCounts(meter); got[TokenTypeInput] != 7 || got[TokenTypeOutput] != 3 || len(got) != 2 {
+		t.Errorf("expected 7 input and 3 output tokens recorded once, got %v", got)
+	}
+	if !inner.closed {
+		t.Error("expected Close to close the underlying stream")
+	}
+}
+
+func TestMetricsHook_StreamErrorRecordedOnce(t *testing.T) {
+	h, meter := newTestMetricsHook(t)
+	info := omnillm.LLMCallInfo{ProviderName: "openai", StartTime: time.Now()}
+	req := &provider.ChatCompletionRequest{Model: "gpt-4o"}
+	inner := &scriptedStream{chunks: []*provider.ChatCompletionChunk{contentChunk("Hel")}, err: omnillm.ErrServerError}
+
+	stream := h.WrapStream(context.Background(), info, req, inner)
+	if err := drain(stream); !errors.Is(err, omnillm.ErrServerError) {
+		t.Fatalf("expected the stream error, got %v", err)
+	}
+	// Receiving again and closing after the error must not record it again
+	_, _ = stream.Recv()
+	_ = stream.Close()
+
+	durations := meter.recorded(MetricOperationDuration)
+	if len(durations) != 1 || durations[0].attrs[AttrErrorType] != "server_error" {
+		t.Errorf("expected one duration with error type server_error, got %+v", durations)
+	}
+	if got := meter.recorded(MetricOperationErrors); len(got) != 1 {
+		t.Errorf("expected the error to be counted once, got %+v", got)
+	}
+	if got := meter.recorded(MetricTokenUsage); len(got) != 0 {
+		t.Errorf("expected no token usage for a failed stream, got %+v", got)
+	}
+}
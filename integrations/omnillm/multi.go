@@ -0,0 +1,49 @@
+package omnillm
+
+import (
+	"context"
+
+	"github.com/agentplexus/omnillm"
+	"github.com/agentplexus/omnillm/provider"
+)
+
+// multiHook calls several hooks for each LLM call.
+type multiHook []omnillm.ObservabilityHook
+
+// MultiHook combines hooks into one, since an OmniLLM client accepts a
+// single observability hook. BeforeRequest is called in order, each hook
+// receiving the context returned by the previous one; AfterResponse is
+// called in reverse order. Nil hooks are ignored.
+func MultiHook(hooks ...omnillm.ObservabilityHook) omnillm.ObservabilityHook {
+	m := make(multiHook, 0, len(hooks))
+	for _, h := range hooks {
+		if h != nil {
+			m = append(m, h)
+		}
+	}
+	return m
+}
+
+// BeforeRequest calls BeforeRequest on each hook.
+func (m multiHook) BeforeRequest(ctx context.Context, info omnillm.LLMCallInfo, req *provider.ChatCompletionRequest) context.Context {
+	for _, h := range m {
+		ctx = h.BeforeRequest(ctx, info, req)
+	}
+	return ctx
+}
+
+// AfterResponse calls AfterResponse on each hook, in reverse order.
+func (m multiHook) AfterResponse(ctx context.Context, info omnillm.LLMCallInfo, req *provider.ChatCompletionRequest, resp *provider.ChatCompletionResponse, err error) {
+	for i := len(m) - 1; i >= 0; i-- {
+		m[i].AfterResponse(ctx, info, req, resp, err)
+	}
+}
+
+// WrapStream wraps the stream with each hook, so that the first hook's
+// wrapper is innermost.
+func (m multiHook) WrapStream(ctx context.Context, info omnillm.LLMCallInfo, req *provider.ChatCompletionRequest, stream provider.ChatCompletionStream) provider.ChatCompletionStream {
+	for _, h := range m {
+		stream = h.WrapStream(ctx, info, req, stream)
+	}
+	return stream
+}
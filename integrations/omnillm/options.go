@@ -0,0 +1,71 @@
+package omnillm
+
+import (
+	"time"
+
+	"github.com/agentplexus/omniobserve/agentops"
+	"github.com/agentplexus/omniobserve/llmops"
+)
+
+// HookOption configures a Hook.
+type HookOption func(*hookConfig)
+
+// hookConfig holds hook configuration.
+type hookConfig struct {
+	taskRollup   bool
+	taskStore    agentops.Store
+	costFunc     CostFunc
+	toolCallTTL  time.Duration
+	maxToolCalls int
+}
+
+// CostFunc returns the cost in USD of an LLM call from its model and token
+// usage. It returns 0 if the cost is unknown.
+type CostFunc func(model string, usage llmops.TokenUsage) float64
+
+// WithTaskRollup rolls each LLM call up into the agentops task in the
+// context (see middleware.TaskFromContext). The task's LLM call count,
+// token counts, and cost are incremented, and the trace and span IDs of
+// the first traced call are stamped onto the task if it has none.
+//
+// The task is updated through the store in the context, unless one is set
+// with WithTaskStore.
+func WithTaskRollup() HookOption {
+	return func(c *hookConfig) {
+		c.taskRollup = true
+	}
+}
+
+// WithTaskStore sets the store used to update tasks, and enables task rollup.
+func WithTaskStore(store agentops.Store) HookOption {
+	return func(c *hookConfig) {
+		c.taskRollup = true
+		c.taskStore = store
+	}
+}
+
+// WithCostFunc sets the function used to price LLM calls. The cost is
+// recorded on the span usage and added to the task cost.
+func WithCostFunc(fn CostFunc) HookOption {
+	return func(c *hookConfig) {
+		c.costFunc = fn
+	}
+}
+
+// WithToolCallTTL sets how long a tool span waits for its result before it
+// is ended with ErrToolCallAbandoned. Zero disables expiry. Default is
+// DefaultToolCallTTL.
+func WithToolCallTTL(ttl time.Duration) HookOption {
+	return func(c *hookConfig) {
+		c.toolCallTTL = ttl
+	}
+}
+
+// WithMaxPendingToolCalls sets how many tool spans may await results. When
+// more are pending, the oldest is ended with ErrToolCallAbandoned. Zero
+// removes the limit. Default is DefaultMaxPendingToolCalls.
+func WithMaxPendingToolCalls(n int) HookOption {
+	return func(c *hookConfig) {
+		c.maxToolCalls = n
+	}
+}
@@ -1,8 +1,9 @@
 package omnillm
 
 import (
+	"context"
 	"io"
-	"strings"
+	"time"
 
 	"github.com/agentplexus/omnillm"
 	"github.com/agentplexus/omnillm/provider"
@@ -11,14 +12,40 @@ import (
 )
 
 // observedStream wraps a provider.ChatCompletionStream to capture
-// streaming content and record it when the stream ends.
+// streaming content, tool calls, and latency and record them when the
+// stream ends.
 type observedStream struct {
-	stream        provider.ChatCompletionStream
-	span          llmops.Span
-	trace         llmops.Trace // trace we created (may be nil)
-	info          omnillm.LLMCallInfo
-	contentBuffer strings.Builder
-	ended         bool
+	ctx         context.Context
+	hook        *Hook
+	stream      provider.ChatCompletionStream
+	span        llmops.Span  // may be nil if only rolling up into a task
+	trace       llmops.Trace // trace we created (may be nil)
+	info        omnillm.LLMCallInfo
+	model       string
+	accumulator *llmops.StreamAccumulator
+	toolCalls   toolCallAccumulator
+	metadata    map[string]any
+	ended       bool
+}
+
+// newObservedStream creates an observed stream. Latency is measured from
+// the start of the LLM call.
+func newObservedStream(ctx context.Context, h *Hook, info omnillm.LLMCallInfo, req *provider.ChatCompletionRequest, stream provider.ChatCompletionStream, span llmops.Span, trace llmops.Trace) *observedStream {
+	acc := llmops.NewStreamAccumulator()
+	if !info.StartTime.IsZero() {
+		acc.StartTime = info.StartTime
+	}
+	return &observedStream{
+		ctx:         ctx,
+		hook:        h,
+		stream:      stream,
+		span:        span,
+		trace:       trace,
+		info:        info,
+		model:       req.Model,
+		accumulator: acc,
+		metadata:    make(map[string]any),
+	}
 }
 
 // Recv receives the next chunk from the stream.
@@ -37,21 +64,85 @@ func (s *observedStream) Recv() (*provider.ChatCompletionChunk, error) {
 		return chunk, err
 	}
 
-	// Buffer content from chunk
-	if chunk != nil && len(chunk.Choices) > 0 && chunk.Choices[0].Delta != nil {
-		s.contentBuffer.WriteString(chunk.Choices[0].Delta.Content)
+	if chunk != nil {
+		s.addChunk(chunk)
 	}
 
 	return chunk, nil
 }
 
+// addChunk records the content, tool call deltas, finish reason, and usage
+// of a chunk. Chunks without content, such as role-only or usage-only
+// chunks, do not count towards time to first token or chunk latencies.
+func (s *observedStream) addChunk(chunk *provider.ChatCompletionChunk) {
+	chunkMetadata(s.metadata, chunk)
+
+	if chunk.Usage != nil {
+		s.accumulator.SetUsage(llmops.TokenUsage{
+			PromptTokens:     chunk.Usage.PromptTokens,
+			CompletionTokens: chunk.Usage.CompletionTokens,
+			TotalTokens:      chunk.Usage.TotalTokens,
+		})
+	}
+
+	if len(chunk.Choices) == 0 {
+		return
+	}
+	choice := chunk.Choices[0]
+
+	var sc llmops.StreamChunk
+	if choice.Delta != nil {
+		sc.Content = choice.Delta.Content
+		s.toolCalls.add(choice.Delta.ToolCalls)
+	}
+	if choice.FinishReason != nil {
+		sc.FinishReason = *choice.FinishReason
+	}
+	if sc.Content == "" && sc.FinishReason == "" && (choice.Delta == nil || len(choice.Delta.ToolCalls) == 0) {
+		return
+	}
+	sc.Index = s.accumulator.ChunkCount
+	s.accumulator.AddChunk(sc)
+}
+
 // Close closes the underlying stream.
 func (s *observedStream) Close() error {
 	// Ensure span is ended if Close is called before EOF
+	if !s.ended {
+		s.metadata[MetadataStreamAbandoned] = true
+	}
 	s.finalizeSpan(nil)
 	return s.stream.Close()
 }
 
+// streamMetadata records the latency and chunk metrics of the stream.
+func (s *observedStream) streamMetadata() {
+	acc := s.accumulator
+	acc.End()
+
+	s.metadata[MetadataStreamChunkCount] = acc.ChunkCount
+	s.metadata[MetadataStreamDuration] = milliseconds(acc.TotalDuration())
+	if acc.FirstChunkAt != nil {
+		s.metadata[MetadataStreamTimeToFirstToken] = milliseconds(acc.TimeToFirstChunk())
+	}
+	if acc.ChunkCount > 1 {
+		s.metadata[MetadataStreamInterTokenLatencyP50] = milliseconds(acc.InterChunkLatency(50))
+		s.metadata[MetadataStreamInterTokenLatencyP90] = milliseconds(acc.InterChunkLatency(90))
+		s.metadata[MetadataStreamInterTokenLatencyP99] = milliseconds(acc.InterChunkLatency(99))
+	}
+	if acc.FinishReason != "" {
+		s.metadata[MetadataResponseFinishReasons] = []string{acc.FinishReason}
+	}
+	if _, ok := s.metadata[MetadataStreamAbandoned]; !ok {
+		s.metadata[MetadataStreamAbandoned] = false
+	}
+}
+
+// milliseconds converts a duration to fractional milliseconds.
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
 // finalizeSpan ends the span and trace with the buffered content.
 func (s *observedStream) finalizeSpan(err error) {
 	if s.ended {
@@ -59,22 +150,49 @@ func (s *observedStream) finalizeSpan(err error) {
 	}
 	s.ended = true
 
-	output := s.contentBuffer.String()
+	s.streamMetadata()
+
+	content := s.accumulator.TotalContent
+	calls := s.toolCalls.calls
+	hasOutput := len(content) > 0 || len(calls) > 0
+	output := responseOutput(content, calls)
+
+	var usage *llmops.TokenUsage
+	if err == nil && s.accumulator.Usage != nil {
+		usage = s.accumulator.Usage
+		model := s.model
+		if m, ok := s.metadata[MetadataResponseModel].(string); ok {
+			model = m
+		}
+		s.hook.priceUsage(model, usage)
+	}
+	s.hook.rollupTask(s.ctx, s.span, usage)
+
+	if s.span == nil {
+		return
+	}
 
 	// End span first
 	if err != nil {
+		_ = s.span.SetMetadata(s.metadata)
 		_ = s.span.End(llmops.WithEndError(err))
 	} else {
-		// Set the buffered output
-		if len(output) > 0 {
+		// Set the buffered output, stream metrics, and usage, and record
+		// tool calls
+		_ = s.span.SetMetadata(s.metadata)
+		if usage != nil {
+			_ = s.span.SetUsage(*usage)
+		}
+		if hasOutput {
 			_ = s.span.SetOutput(output)
 		}
+		s.hook.tools.start(s.ctx, s.span, calls)
 		_ = s.span.End()
 	}
 
 	// End trace if we created one
 	if s.trace != nil {
-		if len(output) > 0 {
+		if hasOutput {
 			_ = s.trace.SetOutput(output)
 		}
 		_ = s.trace.End()
@@ -0,0 +1,76 @@
+package omnillm
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/agentplexus/omnillm"
+	"github.com/agentplexus/omnillm/provider"
+)
+
+// wrapTestStream wraps inner with a hook whose context carries span.
+func wrapTestStream(span *fakeSpan, inner provider.ChatCompletionStream) provider.ChatCompletionStream {
+	ctx := contextWithSpan(context.Background(), span)
+	info := omnillm.LLMCallInfo{ProviderName: "openai", StartTime: time.Now()}
+	return NewHook(nil).WrapStream(ctx, info, &provider.ChatCompletionRequest{Model: "gpt-4o"}, inner)
+}
+
+func TestObservedStream_InterChunkLatency(t *testing.T) {
+	span := &fakeSpan{name: "llm"}
+	inner := &scriptedStream{
+		chunks: []*provider.ChatCompletionChunk{contentChunk("a"), contentChunk("b"), contentChunk("c")},
+		delay:  20 * time.Millisecond,
+	}
+
+	stream := wrapTestStream(span, inner)
+	if err := drain(stream); err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+	_ = stream.Close()
+
+	md := span.metadata
+	if md[MetadataStreamChunkCount] != 3 || md[MetadataStreamAbandoned] != false {
+		t.Errorf("expected 3 chunks from a completed stream, got %v", md)
+	}
+	if ttft, ok := md[MetadataStreamTimeToFirstToken].(float64); !ok || ttft < 20 {
+		t.Errorf("expected time to first token of at least 20ms, got %v", md[MetadataStreamTimeToFirstToken])
+	}
+	for _, key := range []string{MetadataStreamInterTokenLatencyP50, MetadataStreamInterTokenLatencyP90, MetadataStreamInterTokenLatencyP99} {
+		if latency, ok := md[key].(float64); !ok || latency < 15 {
+			t.Errorf("expected %s of about 20ms, got %v", key, md[key])
+		}
+	}
+	if md[MetadataStreamDuration].(float64) < md[MetadataStreamTimeToFirstToken].(float64) {
+		t.Errorf("expected the duration to include time to first token, got %v", md)
+	}
+	if ended, err := span.endState(); !ended || err != nil || span.output != "abc" {
+		t.Errorf("expected the span to end with the streamed output, got ended=%v err=%v output=%v", ended, err, span.output)
+	}
+}
+
+func TestObservedStream_AbandonedBeforeEOF(t *testing.T) {
+	span := &fakeSpan{name: "llm"}
+	inner := &scriptedStream{chunks: []*provider.ChatCompletionChunk{contentChunk("a"), contentChunk("b"), contentChunk("c")}}
+
+	stream := wrapTestStream(span, inner)
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	_ = stream.Close()
+
+	md := span.metadata
+	if md[MetadataStreamAbandoned] != true || md[MetadataStreamChunkCount] != 1 {
+		t.Errorf("expected an abandoned stream with 1 chunk, got %v", md)
+	}
+	if _, ok := md[MetadataStreamInterTokenLatencyP50]; ok {
+		t.Errorf("expected no inter-chunk latency from a single chunk, got %v", md)
+	}
+	if ended, err := span.endState(); !ended || err != nil || span.output != "a" {
+		t.Errorf("expected the span to end with the partial output, got ended=%v err=%v output=%v", ended, err, span.output)
+	}
+	if !inner.closed {
+		t.Error("expected Close to close the underlying stream")
+	}
+}
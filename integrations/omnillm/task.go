@@ -0,0 +1,76 @@
+package omnillm
+
+import (
+	"context"
+	"sync"
+
+	"github.com/agentplexus/omniobserve/agentops"
+	"github.com/agentplexus/omniobserve/agentops/middleware"
+	"github.com/agentplexus/omniobserve/llmops"
+)
+
+// taskRollup links tasks to the trace of their first LLM call.
+type taskRollup struct {
+	mu sync.Mutex
+}
+
+// link reports whether the task still needs trace and span IDs. If so, it
+// sets them on the task, so later calls in the same task see them and do
+// not link it again.
+func (r *taskRollup) link(task *agentops.Task, span llmops.Span) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if task.TraceID != "" {
+		return false
+	}
+	task.TraceID = span.TraceID()
+	task.SpanID = span.ID()
+	return true
+}
+
+// priceUsage sets the cost of the usage using the configured CostFunc.
+func (h *Hook) priceUsage(model string, usage *llmops.TokenUsage) {
+	if h.config.costFunc == nil {
+		return
+	}
+	if cost := h.config.costFunc(model, *usage); cost > 0 {
+		usage.TotalCost = cost
+		usage.Currency = "USD"
+	}
+}
+
+// rollupTask adds an LLM call to the agentops task in the context. usage
+// is nil if the call failed or the provider reported no usage.
+func (h *Hook) rollupTask(ctx context.Context, span llmops.Span, usage *llmops.TokenUsage) {
+	if !h.config.taskRollup {
+		return
+	}
+	task := middleware.TaskFromContext(ctx)
+	if task == nil {
+		return
+	}
+	store := h.config.taskStore
+	if store == nil {
+		store = middleware.StoreFromContext(ctx)
+	}
+	if store == nil {
+		return
+	}
+
+	opts := []agentops.TaskUpdateOption{agentops.WithTaskAddLLMCall()}
+	if usage != nil {
+		opts = append(opts, agentops.WithTaskAddTokens(usage.PromptTokens, usage.CompletionTokens))
+		if usage.TotalCost > 0 {
+			opts = append(opts, agentops.WithTaskAddCost(usage.TotalCost))
+		}
+	}
+	if span != nil && span.TraceID() != "" && h.tasks.link(task, span) {
+		opts = append(opts,
+			agentops.WithTaskUpdateTraceID(span.TraceID()),
+			agentops.WithTaskUpdateSpanID(span.ID()),
+		)
+	}
+
+	// Don't fail the request if the task update fails
+	_ = store.UpdateTask(ctx, task.ID, opts...)
+}
@@ -0,0 +1,59 @@
+package omnillm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/agentplexus/omniobserve/agentops"
+	"github.com/agentplexus/omniobserve/agentops/middleware"
+)
+
+// fakeTaskStore records task updates. Methods not overridden panic through
+// the nil embedded interface.
+type fakeTaskStore struct {
+	agentops.Store
+	updates []*agentops.TaskUpdateConfig
+}
+
+func (s *fakeTaskStore) UpdateTask(_ context.Context, _ string, opts ...agentops.TaskUpdateOption) error {
+	s.updates = append(s.updates, agentops.ApplyTaskUpdateOptions(opts...))
+	return nil
+}
+
+type tracedSpan struct {
+	fakeSpan
+	traceID string
+}
+
+func (s *tracedSpan) TraceID() string { return s.traceID }
+
+func TestRollupTask_LinksFirstTracedCall(t *testing.T) {
+	store := &fakeTaskStore{}
+	h := NewHook(nil, WithTaskStore(store))
+	ctx := middleware.WithTask(context.Background(), &agentops.Task{ID: "task-1"})
+
+	h.rollupTask(ctx, &tracedSpan{fakeSpan: fakeSpan{name: "span-1"}, traceID: "trace-1"}, nil)
+	h.rollupTask(ctx, &tracedSpan{fakeSpan: fakeSpan{name: "span-2"}, traceID: "trace-2"}, nil)
+
+	if len(store.updates) != 2 {
+		t.Fatalf("expected 2 updates, got %d", len(store.updates))
+	}
+	if got := store.updates[0]; got.TraceID != "trace-1" || got.SpanID != "span-1" || got.AddLLMCalls != 1 {
+		t.Errorf("expected first call to link trace-1/span-1, got %+v", got)
+	}
+	if got := store.updates[1]; got.TraceID != "" || got.AddLLMCalls != 1 {
+		t.Errorf("expected second call not to relink, got %+v", got)
+	}
+}
+
+func TestRollupTask_KeepsExistingTrace(t *testing.T) {
+	store := &fakeTaskStore{}
+	h := NewHook(nil, WithTaskStore(store))
+	ctx := middleware.WithTask(context.Background(), &agentops.Task{ID: "task-1", TraceID: "upstream"})
+
+	h.rollupTask(ctx, &tracedSpan{fakeSpan: fakeSpan{name: "span-1"}, traceID: "trace-1"}, nil)
+
+	if len(store.updates) != 1 || store.updates[0].TraceID != "" {
+		t.Errorf("expected the task's trace not to be replaced, got %+v", store.updates)
+	}
+}
@@ -0,0 +1,269 @@
+package omnillm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/agentplexus/omnillm/provider"
+
+	"github.com/agentplexus/omniobserve/llmops"
+)
+
+// ToolCall is a tool call requested by the model.
+type ToolCall struct {
+	ID        string `json:"id"`
+	Type      string `json:"type,omitempty"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// Output is the structured output recorded for responses that contain
+// tool calls. Responses without tool calls are recorded as plain content.
+type Output struct {
+	Content   string     `json:"content,omitempty"`
+	ToolCalls []ToolCall `json:"tool_calls"`
+}
+
+// toolCallsFromMessage converts the tool calls of a response message.
+func toolCallsFromMessage(msg provider.Message) []ToolCall {
+	if len(msg.ToolCalls) == 0 {
+		return nil
+	}
+	calls := make([]ToolCall, len(msg.ToolCalls))
+	for i, tc := range msg.ToolCalls {
+		calls[i] = ToolCall{
+			ID:        tc.ID,
+			Type:      tc.Type,
+			Name:      tc.Function.Name,
+			Arguments: tc.Function.Arguments,
+		}
+	}
+	return calls
+}
+
+// responseOutput returns the output to record for a response: the content,
+// or an Output if the model requested tool calls.
+func responseOutput(content string, calls []ToolCall) any {
+	if len(calls) == 0 {
+		return content
+	}
+	return Output{Content: content, ToolCalls: calls}
+}
+
+// toolCallAccumulator assembles tool calls from streamed deltas. A delta
+// carrying an ID starts a new call (or continues the call with that ID);
+// deltas without an ID continue the most recent call.
+type toolCallAccumulator struct {
+	calls []ToolCall
+}
+
+// add merges the tool call deltas of a chunk.
+func (a *toolCallAccumulator) add(deltas []provider.ToolCall) {
+	for _, d := range deltas {
+		call := a.current(d.ID)
+		if d.Type != "" {
+			call.Type = d.Type
+		}
+		if d.Function.Name != "" {
+			call.Name = d.Function.Name
+		}
+		call.Arguments += d.Function.Arguments
+	}
+}
+
+// current returns the call a delta with the given ID belongs to.
+func (a *toolCallAccumulator) current(id string) *ToolCall {
+	if id != "" {
+		for i := range a.calls {
+			if a.calls[i].ID == id {
+				return &a.calls[i]
+			}
+		}
+	}
+	if id != "" || len(a.calls) == 0 {
+		a.calls = append(a.calls, ToolCall{ID: id})
+	}
+	return &a.calls[len(a.calls)-1]
+}
+
+// Defaults for pending tool spans.
+const (
+	DefaultToolCallTTL         = 10 * time.Minute
+	DefaultMaxPendingToolCalls = 1000
+)
+
+// ErrToolCallAbandoned is recorded on tool spans that are ended without a
+// result, because the tool call expired or too many calls were pending.
+var ErrToolCallAbandoned = errors.New("omnillm: tool call abandoned without a result")
+
+// toolSpans tracks tool spans awaiting their results, keyed by tool call ID.
+// Spans waiting longer than ttl, or the oldest beyond max, are ended with
+// ErrToolCallAbandoned so that abandoned agent turns do not leak spans.
+type toolSpans struct {
+	ttl time.Duration
+	max int
+	now func() time.Time
+
+	mu    sync.Mutex
+	spans map[string]pendingTool
+}
+
+// pendingTool is a tool span awaiting its result.
+type pendingTool struct {
+	span    llmops.Span
+	started time.Time
+}
+
+// start creates a child tool span under parent for each tool call. Calls
+// without an ID cannot be linked to a result and are ended immediately.
+func (t *toolSpans) start(ctx context.Context, parent llmops.Span, calls []ToolCall) {
+	for _, call := range calls {
+		name := call.Name
+		if name == "" {
+			name = "tool"
+		}
+		_, span, err := parent.StartSpan(ctx, name,
+			llmops.WithSpanType(llmops.SpanTypeTool),
+			llmops.WithSpanInput(toolArguments(call.Arguments)),
+			llmops.WithSpanMetadata(map[string]any{MetadataToolCallID: call.ID}),
+		)
+		if err != nil {
+			continue
+		}
+		if call.ID == "" {
+			_ = span.End()
+			continue
+		}
+
+		t.mu.Lock()
+		if t.spans == nil {
+			t.spans = make(map[string]pendingTool)
+		}
+		t.spans[call.ID] = pendingTool{span: span, started: t.clock()}
+		abandoned := t.expire()
+		t.mu.Unlock()
+
+		for _, span := range abandoned {
+			_ = span.End(llmops.WithEndError(ErrToolCallAbandoned))
+		}
+	}
+}
+
+// expire removes the spans that have waited longer than the TTL, and the
+// oldest spans beyond the maximum, and returns them. t.mu must be held.
+func (t *toolSpans) expire() []llmops.Span {
+	var abandoned []llmops.Span
+	if t.ttl > 0 {
+		deadline := t.clock().Add(-t.ttl)
+		for id, p := range t.spans {
+			if p.started.Before(deadline) {
+				abandoned = append(abandoned, p.span)
+				delete(t.spans, id)
+			}
+		}
+	}
+	for t.max > 0 && len(t.spans) > t.max {
+		oldestID := ""
+		for id, p := range t.spans {
+			if oldestID == "" || p.started.Before(t.spans[oldestID].started) {
+				oldestID = id
+			}
+		}
+		abandoned = append(abandoned, t.spans[oldestID].span)
+		delete(t.spans, oldestID)
+	}
+	return abandoned
+}
+
+func (t *toolSpans) clock() time.Time {
+	if t.now != nil {
+		return t.now()
+	}
+	return time.Now()
+}
+
+// take removes and returns the pending span for a tool call.
+func (t *toolSpans) take(id string) (llmops.Span, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	p, ok := t.spans[id]
+	if ok {
+		delete(t.spans, id)
+	}
+	return p.span, ok
+}
+
+// takeAll removes and returns all pending spans.
+func (t *toolSpans) takeAll() []llmops.Span {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	spans := make([]llmops.Span, 0, len(t.spans))
+	for id, p := range t.spans {
+		spans = append(spans, p.span)
+		delete(t.spans, id)
+	}
+	return spans
+}
+
+// toolArguments decodes JSON tool arguments, returning the raw string if
+// they are not valid JSON.
+func toolArguments(args string) any {
+	if args == "" {
+		return nil
+	}
+	var v any
+	if err := json.Unmarshal([]byte(args), &v); err != nil {
+		return args
+	}
+	return v
+}
+
+// RecordToolResult links the result of an executed tool back to the tool
+// span created for the model's tool call, and ends the span. A non-nil err
+// marks the tool call as failed.
+//
+// Tool results sent back to the model as tool messages are linked
+// automatically by BeforeRequest, so RecordToolResult is only needed for
+// results that are not.
+func (h *Hook) RecordToolResult(toolCallID string, output any, err error) error {
+	span, ok := h.tools.take(toolCallID)
+	if !ok {
+		return fmt.Errorf("%w: tool call %s", llmops.ErrSpanNotFound, toolCallID)
+	}
+	if err != nil {
+		return span.End(llmops.WithEndOutput(output), llmops.WithEndError(err))
+	}
+	return span.End(llmops.WithEndOutput(output))
+}
+
+// PendingToolCalls returns the number of tool spans awaiting a result.
+func (h *Hook) PendingToolCalls() int {
+	h.tools.mu.Lock()
+	defer h.tools.mu.Unlock()
+	return len(h.tools.spans)
+}
+
+// EndPendingToolCalls ends all tool spans that are still awaiting a result,
+// for example when an agent run finishes without executing every tool.
+func (h *Hook) EndPendingToolCalls() {
+	for _, span := range h.tools.takeAll() {
+		_ = span.End()
+	}
+}
+
+// linkToolResults ends the pending tool spans answered by tool messages in
+// the request.
+func (h *Hook) linkToolResults(messages []provider.Message) {
+	for _, msg := range messages {
+		if msg.Role != provider.RoleTool || msg.ToolCallID == nil {
+			continue
+		}
+		if span, ok := h.tools.take(*msg.ToolCallID); ok {
+			_ = span.End(llmops.WithEndOutput(msg.Content))
+		}
+	}
+}
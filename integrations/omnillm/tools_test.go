@@ -0,0 +1,152 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/agentplexus/omniobserve/llmops"
+)
+
+// fakeSpan records child spans, metadata, output, and how it was ended.
+// Methods not overridden panic through the nil embedded interface.
+type fakeSpan struct {
+	llmops.Span
+	name string
+
+	mu       sync.Mutex
+	children []*fakeSpan
+	metadata map[string]any
+	usage    *llmops.TokenUsage
+	ended    bool
+	endErr   error
+	output   any
+}
+
+func (s *fakeSpan) ID() string { return s.name }
+
+func (s *fakeSpan) StartSpan(ctx context.Context, name string, _ ...llmops.SpanOption) (context.Context, llmops.Span, error) {
+	child := &fakeSpan{name: name}
+	s.mu.Lock()
+	s.children = append(s.children, child)
+	s.mu.Unlock()
+	return ctx, child, nil
+}
+
+func (s *fakeSpan) SetMetadata(metadata map[string]any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metadata = metadata
+	return nil
+}
+
+func (s *fakeSpan) SetOutput(output any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.output = output
+	return nil
+}
+
+func (s *fakeSpan) SetUsage(usage llmops.TokenUsage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.usage = &usage
+	return nil
+}
+
+func (s *fakeSpan) End(opts ...llmops.EndOption) error {
+	o := &llmops.EndOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ended = true
+	s.endErr = o.Error
+	if o.Output != nil {
+		s.output = o.Output
+	}
+	return nil
+}
+
+func (s *fakeSpan) endState() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ended, s.endErr
+}
+
+func toolCalls(ids ...string) []ToolCall {
+	calls := make([]ToolCall, len(ids))
+	for i, id := range ids {
+		calls[i] = ToolCall{ID: id, Name: "tool-" + id, Arguments: `{"q":1}`}
+	}
+	return calls
+}
+
+func TestToolSpans_ExpireAfterTTL(t *testing.T) {
+	now := time.Unix(1000, 0)
+	h := NewHook(nil, WithToolCallTTL(time.Minute))
+	h.tools.now = func() time.Time { return now }
+	parent := &fakeSpan{name: "llm"}
+
+	h.tools.start(context.Background(), parent, toolCalls("a"))
+	now = now.Add(2 * time.Minute)
+	h.tools.start(context.Background(), parent, toolCalls("b"))
+
+	if got := h.PendingToolCalls(); got != 1 {
+		t.Errorf("expected 1 pending tool call, got %d", got)
+	}
+	ended, err := parent.children[0].endState()
+	if !ended || !errors.Is(err, ErrToolCallAbandoned) {
+		t.Errorf("expected expired span to end with ErrToolCallAbandoned, got ended=%v err=%v", ended, err)
+	}
+	if ended, _ := parent.children[1].endState(); ended {
+		t.Error("expected the new span to stay open")
+	}
+}
+
+func TestToolSpans_MaxPending(t *testing.T) {
+	now := time.Unix(1000, 0)
+	h := NewHook(nil, WithMaxPendingToolCalls(3))
+	h.tools.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	parent := &fakeSpan{name: "llm"}
+
+	for i := range 5 {
+		h.tools.start(context.Background(), parent, toolCalls(fmt.Sprint(i)))
+	}
+
+	if got := h.PendingToolCalls(); got != 3 {
+		t.Errorf("expected 3 pending tool calls, got %d", got)
+	}
+	for i, child := range parent.children {
+		ended, err := child.endState()
+		if abandoned := i < 2; ended != abandoned || (abandoned && !errors.Is(err, ErrToolCallAbandoned)) {
+			t.Errorf("span %d: expected abandoned=%v, got ended=%v err=%v", i, abandoned, ended, err)
+		}
+	}
+}
+
+func TestToolSpans_RecordToolResult(t *testing.T) {
+	h := NewHook(nil)
+	parent := &fakeSpan{name: "llm"}
+	h.tools.start(context.Background(), parent, toolCalls("a", ""))
+
+	if got := h.PendingToolCalls(); got != 1 {
+		t.Fatalf("expected the call without an ID not to be pending, got %d pending", got)
+	}
+	if err := h.RecordToolResult("a", "result", nil); err != nil {
+		t.Fatalf("RecordToolResult: %v", err)
+	}
+	if ended, err := parent.children[0].endState(); !ended || err != nil {
+		t.Errorf("expected span to end without error, got ended=%v err=%v", ended, err)
+	}
+	if err := h.RecordToolResult("a", "again", nil); !errors.Is(err, llmops.ErrSpanNotFound) {
+		t.Errorf("expected ErrSpanNotFound for a finished call, got %v", err)
+	}
+}
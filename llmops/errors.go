@@ -31,6 +31,7 @@ var (
 	ErrInvalidInput    = errors.New("llmops: invalid input")
 	ErrInvalidSpanType = errors.New("llmops: invalid span type")
 	ErrInvalidMetric   = errors.New("llmops: invalid metric")
+	ErrInvalidScore    = errors.New("llmops: invalid score")
 
 	// Provider errors
 	ErrProviderNotFound       = errors.New("llmops: provider not found")
@@ -0,0 +1,473 @@
+package guardrails
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+
+	"github.com/agentplexus/omniobserve/llmops"
+)
+
+// CheckOption configures a built-in check.
+type CheckOption func(*checkConfig)
+
+// checkConfig holds built-in check configuration.
+type checkConfig struct {
+	name        string
+	action      Action
+	replacement string
+	piiTypes    []PIIType
+	minScore    *float64
+	maxScore    *float64
+}
+
+func applyCheckOptions(name string, action Action, opts []CheckOption) *checkConfig {
+	cfg := &checkConfig{name: name, action: action}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithName sets the check name.
+func WithName(name string) CheckOption {
+	return func(c *checkConfig) {
+		c.name = name
+	}
+}
+
+// WithAction sets the action taken when the check finds a violation.
+func WithAction(action Action) CheckOption {
+	return func(c *checkConfig) {
+		c.action = action
+	}
+}
+
+// WithReplacement sets the text that replaces denied matches when the
+// action is ActionRewrite.
+func WithReplacement(replacement string) CheckOption {
+	return func(c *checkConfig) {
+		c.replacement = replacement
+	}
+}
+
+// =============================================================================
+// Denylist
+// =============================================================================
+
+// Denylist finds text matching any of a set of regular expressions. By
+// default it blocks; with ActionRewrite, matches are replaced with
+// "[REDACTED]" or the WithReplacement text.
+type Denylist struct {
+	cfg      *checkConfig
+	patterns []*regexp.Regexp
+}
+
+// NewDenylist creates a denylist check from regular expressions.
+func NewDenylist(name string, patterns []string, opts ...CheckOption) (*Denylist, error) {
+	cfg := applyCheckOptions(name, ActionBlock, opts)
+	if cfg.replacement == "" {
+		cfg.replacement = "[REDACTED]"
+	}
+	d := &Denylist{cfg: cfg}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("compile denylist pattern %q: %w", p, err)
+		}
+		d.patterns = append(d.patterns, re)
+	}
+	return d, nil
+}
+
+// MustDenylist creates a denylist check, panicking on an invalid pattern.
+func MustDenylist(name string, patterns ...string) *Denylist {
+	d, err := NewDenylist(name, patterns)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// Name returns the check name.
+func (d *Denylist) Name() string {
+	return d.cfg.name
+}
+
+// Check looks for denied patterns in the text.
+func (d *Denylist) Check(ctx context.Context, content Content) (Verdict, error) {
+	var matched []string
+	text := content.Text
+	for _, re := range d.patterns {
+		if re.MatchString(text) {
+			matched = append(matched, re.String())
+			text = re.ReplaceAllLiteralString(text, d.cfg.replacement)
+		}
+	}
+	if len(matched) == 0 {
+		return pass(d.cfg.name), nil
+	}
+	return violation(d.cfg.name, d.cfg.action,
+		"matched denied pattern "+joinLimited(matched, 3), text,
+		map[string]any{"patterns": matched}), nil
+}
+
+// =============================================================================
+// PII
+// =============================================================================
+
+// PIIType is a kind of personally identifiable information.
+type PIIType string
+
+const (
+	PIIEmail      PIIType = "email"
+	PIIPhone      PIIType = "phone"
+	PIICreditCard PIIType = "credit_card"
+	PIISSN        PIIType = "ssn"
+	PIIIPAddress  PIIType = "ip_address"
+)
+
+// piiPatterns are the detectors for each PII type.
+var piiPatterns = map[PIIType]*regexp.Regexp{
+	PIIEmail:      regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+	PIIPhone:      regexp.MustCompile(`(?:\+\d{1,3}[\s.\-]?)?(?:\(\d{3}\)|\d{3})[\s.\-]?\d{3}[\s.\-]?\d{4}\b`),
+	PIICreditCard: regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`),
+	PIISSN:        regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`),
+	PIIIPAddress:  regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1?\d?\d)\.){3}(?:25[0-5]|2[0-4]\d|1?\d?\d)\b`),
+}
+
+// piiOrder is the order in which detectors run. Card numbers run before
+// phone numbers so that long digit runs are not partially redacted.
+var piiOrder = []PIIType{PIIEmail, PIISSN, PIICreditCard, PIIPhone, PIIIPAddress}
+
+// WithPIITypes limits the PII check to the given types.
+func WithPIITypes(types ...PIIType) CheckOption {
+	return func(c *checkConfig) {
+		c.piiTypes = types
+	}
+}
+
+// PII finds personally identifiable information. By default it redacts
+// each match with a placeholder such as "[EMAIL]".
+type PII struct {
+	cfg   *checkConfig
+	types []PIIType
+}
+
+// NewPII creates a PII check for all supported types, unless limited with
+// WithPIITypes.
+func NewPII(opts ...CheckOption) *PII {
+	cfg := applyCheckOptions("pii", ActionRewrite, opts)
+	p := &PII{cfg: cfg}
+	for _, t := range piiOrder {
+		if len(cfg.piiTypes) == 0 || containsPIIType(cfg.piiTypes, t) {
+			p.types = append(p.types, t)
+		}
+	}
+	return p
+}
+
+// Name returns the check name.
+func (p *PII) Name() string {
+	return p.cfg.name
+}
+
+// Check looks for PII in the text.
+func (p *PII) Check(ctx context.Context, content Content) (Verdict, error) {
+	text := content.Text
+	counts := map[string]any{}
+	var found []string
+	for _, t := range p.types {
+		n := 0
+		text = piiPatterns[t].ReplaceAllStringFunc(text, func(m string) string {
+			if t == PIICreditCard && !luhnValid(m) {
+				return m
+			}
+			n++
+			return "[" + strings.ToUpper(string(t)) + "]"
+		})
+		if n > 0 {
+			counts[string(t)] = n
+			found = append(found, string(t))
+		}
+	}
+	if len(found) == 0 {
+		return pass(p.cfg.name), nil
+	}
+	return violation(p.cfg.name, p.cfg.action,
+		"found PII: "+strings.Join(found, ", "), text, counts), nil
+}
+
+func containsPIIType(types []PIIType, t PIIType) bool {
+	for _, x := range types {
+		if x == t {
+			return true
+		}
+	}
+	return false
+}
+
+// luhnValid reports whether the digits in s pass the Luhn checksum.
+func luhnValid(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
+
+// =============================================================================
+// JSON Schema
+// =============================================================================
+
+// JSONSchema checks that text is JSON conforming to a JSON Schema. Markdown
+// code fences around the JSON are ignored. By default it blocks.
+type JSONSchema struct {
+	cfg    *checkConfig
+	schema *jsonschema.Schema
+}
+
+// NewJSONSchema creates a JSON Schema check. The schema may be a JSON
+// string, a []byte, or a value that marshals to JSON, such as a map.
+func NewJSONSchema(schema any, opts ...CheckOption) (*JSONSchema, error) {
+	compiled, err := compileSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONSchema{
+		cfg:    applyCheckOptions("json_schema", ActionBlock, opts),
+		schema: compiled,
+	}, nil
+}
+
+// Name returns the check name.
+func (j *JSONSchema) Name() string {
+	return j.cfg.name
+}
+
+// Check validates the text against the schema.
+func (j *JSONSchema) Check(ctx context.Context, content Content) (Verdict, error) {
+	doc, err := jsonschema.UnmarshalJSON(strings.NewReader(stripCodeFence(content.Text)))
+	if err != nil {
+		return violation(j.cfg.name, j.cfg.action, "output is not valid JSON: "+err.Error(), "", nil), nil
+	}
+	if err := j.schema.Validate(doc); err != nil {
+		var verr *jsonschema.ValidationError
+		metadata := map[string]any{}
+		if errors.As(err, &verr) {
+			metadata["paths"] = validationPaths(verr)
+		}
+		return violation(j.cfg.name, j.cfg.action, "output does not match schema: "+err.Error(), "", metadata), nil
+	}
+	return pass(j.cfg.name), nil
+}
+
+// schemaURL identifies schemas added to the compiler.
+const schemaURL = "urn:omniobserve:guardrails:schema"
+
+// compileSchema compiles a JSON Schema given as a string, bytes, or value.
+func compileSchema(schema any) (*jsonschema.Schema, error) {
+	var raw string
+	switch s := schema.(type) {
+	case string:
+		raw = s
+	case []byte:
+		raw = string(s)
+	default:
+		b, err := json.Marshal(s)
+		if err != nil {
+			return nil, fmt.Errorf("marshal schema: %w", err)
+		}
+		raw = string(b)
+	}
+
+	doc, err := jsonschema.UnmarshalJSON(strings.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("parse schema: %w", err)
+	}
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource(schemaURL, doc); err != nil {
+		return nil, fmt.Errorf("add schema: %w", err)
+	}
+	compiled, err := c.Compile(schemaURL)
+	if err != nil {
+		return nil, fmt.Errorf("compile schema: %w", err)
+	}
+	return compiled, nil
+}
+
+// validationPaths returns the JSON pointers of the values that failed
+// validation.
+func validationPaths(err *jsonschema.ValidationError) []string {
+	seen := map[string]bool{}
+	var walk func(e *jsonschema.ValidationError)
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) == 0 {
+			seen["/"+strings.Join(e.InstanceLocation, "/")] = true
+			return
+		}
+		for _, c := range e.Causes {
+			walk(c)
+		}
+	}
+	walk(err)
+
+	paths := make([]string, 0, len(seen))
+	for p := range seen {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// stripCodeFence removes a surrounding Markdown code fence, if any.
+func stripCodeFence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	s = strings.TrimPrefix(s, "```")
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[i+1:]
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "```"))
+}
+
+// =============================================================================
+// Max Length
+// =============================================================================
+
+// MaxLength limits text to a number of characters. By default it blocks;
+// with ActionRewrite, the text is truncated.
+type MaxLength struct {
+	cfg *checkConfig
+	max int
+}
+
+// NewMaxLength creates a check limiting text to max characters. A negative
+// max is treated as 0.
+func NewMaxLength(max int, opts ...CheckOption) *MaxLength {
+	if max < 0 {
+		max = 0
+	}
+	return &MaxLength{
+		cfg: applyCheckOptions("max_length", ActionBlock, opts),
+		max: max,
+	}
+}
+
+// Name returns the check name.
+func (m *MaxLength) Name() string {
+	return m.cfg.name
+}
+
+// Check compares the text length with the limit.
+func (m *MaxLength) Check(ctx context.Context, content Content) (Verdict, error) {
+	n := utf8.RuneCountInString(content.Text)
+	if n <= m.max {
+		return pass(m.cfg.name), nil
+	}
+	return violation(m.cfg.name, m.cfg.action,
+		fmt.Sprintf("length %d exceeds maximum %d", n, m.max),
+		string([]rune(content.Text)[:m.max]),
+		map[string]any{"length": n, "max_length": m.max}), nil
+}
+
+// =============================================================================
+// Metric
+// =============================================================================
+
+// WithMinScore sets the lowest metric score that passes.
+func WithMinScore(score float64) CheckOption {
+	return func(c *checkConfig) {
+		c.minScore = &score
+	}
+}
+
+// WithMaxScore sets the highest metric score that passes, for metrics where
+// higher is worse, such as hallucination.
+func WithMaxScore(score float64) CheckOption {
+	return func(c *checkConfig) {
+		c.maxScore = &score
+	}
+}
+
+// MetricCheck applies an llmops.Metric, such as an LLM judge, as a check.
+// Text whose score is below WithMinScore (default 0.5) or above
+// WithMaxScore is a violation. By default it blocks.
+//
+// For input checks, the metric receives the text as Input. For output
+// checks, it receives the call input as Input and the text as Output.
+type MetricCheck struct {
+	cfg    *checkConfig
+	metric llmops.Metric
+}
+
+// NewMetricCheck creates a check backed by a metric.
+func NewMetricCheck(metric llmops.Metric, opts ...CheckOption) *MetricCheck {
+	cfg := applyCheckOptions(metric.Name(), ActionBlock, opts)
+	if cfg.minScore == nil && cfg.maxScore == nil {
+		minScore := 0.5
+		cfg.minScore = &minScore
+	}
+	return &MetricCheck{cfg: cfg, metric: metric}
+}
+
+// Name returns the check name.
+func (m *MetricCheck) Name() string {
+	return m.cfg.name
+}
+
+// Check evaluates the metric and compares the score with the thresholds.
+func (m *MetricCheck) Check(ctx context.Context, content Content) (Verdict, error) {
+	input := llmops.EvalInput{Input: content.Text}
+	if content.Stage == StageOutput {
+		input = llmops.EvalInput{Input: content.Input, Output: content.Text}
+	}
+
+	score, err := m.metric.Evaluate(input)
+	if err == nil && score.Error != "" {
+		err = errors.New(score.Error)
+	}
+	if err != nil {
+		return Verdict{Check: m.cfg.name}, fmt.Errorf("evaluate %s: %w", m.metric.Name(), err)
+	}
+
+	v := pass(m.cfg.name)
+	switch {
+	case m.cfg.minScore != nil && score.Score < *m.cfg.minScore:
+		v = violation(m.cfg.name, m.cfg.action,
+			fmt.Sprintf("score %.2f below minimum %.2f", score.Score, *m.cfg.minScore), "", nil)
+	case m.cfg.maxScore != nil && score.Score > *m.cfg.maxScore:
+		v = violation(m.cfg.name, m.cfg.action,
+			fmt.Sprintf("score %.2f above maximum %.2f", score.Score, *m.cfg.maxScore), "", nil)
+	}
+	if score.Reason != "" {
+		if v.Reason != "" {
+			v.Reason += ": " + score.Reason
+		} else {
+			v.Reason = score.Reason
+		}
+	}
+	v.Score = &score.Score
+	return v, nil
+}
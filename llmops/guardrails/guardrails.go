@@ -0,0 +1,326 @@
+// Package guardrails provides input and output checks for LLM calls.
+//
+// A Pipeline runs Checks on the input before an LLM call and on the output
+// after it. Each check returns a Verdict whose Action passes the text,
+// flags it, rewrites it (for example, redacting PII), or blocks the call.
+// Blocked calls fail with a *BlockedError, which matches ErrBlocked.
+//
+// Built-in checks cover common cases:
+//
+//   - Denylist: blocks text matching regular expressions
+//   - PII: redacts or blocks emails, phone numbers, card numbers, and more
+//   - JSONSchema: validates that output is JSON conforming to a schema
+//   - MaxLength: limits text length
+//   - MetricCheck: applies any llmops.Metric, such as an LLM judge
+//
+// When the pipeline has a tracer, each check is recorded as a span of type
+// llmops.SpanTypeGuardrail carrying its verdict. Spans do not record the
+// checked text, since it may hold the content a check redacts or blocks.
+//
+// # Usage
+//
+//	pipeline := guardrails.NewPipeline(
+//	    guardrails.WithTracer(provider),
+//	    guardrails.WithInputChecks(
+//	        guardrails.MustDenylist("secrets", `(?i)api[_-]?key`),
+//	        guardrails.NewPII(),
+//	    ),
+//	    guardrails.WithOutputChecks(guardrails.NewMaxLength(4000)),
+//	)
+//
+//	result, err := pipeline.CheckInput(ctx, prompt)
+//	if errors.Is(err, guardrails.ErrBlocked) {
+//	    // refuse the request
+//	}
+//	prompt = result.Text
+package guardrails
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/agentplexus/omniobserve/llmops"
+)
+
+// Stage identifies when a check runs.
+type Stage string
+
+const (
+	StageInput  Stage = "input"
+	StageOutput Stage = "output"
+)
+
+// Action is the outcome of a check.
+type Action string
+
+const (
+	// ActionPass lets the text through unchanged.
+	ActionPass Action = "pass"
+	// ActionFlag lets the text through but marks it for review.
+	ActionFlag Action = "flag"
+	// ActionRewrite replaces the text with Verdict.Text.
+	ActionRewrite Action = "rewrite"
+	// ActionBlock stops the call.
+	ActionBlock Action = "block"
+)
+
+// ErrBlocked is matched by errors returned for blocked calls.
+var ErrBlocked = errors.New("guardrails: blocked")
+
+// BlockedError is returned when a check blocks a call.
+type BlockedError struct {
+	Stage   Stage
+	Verdict Verdict
+}
+
+func (e *BlockedError) Error() string {
+	if e.Verdict.Reason != "" {
+		return fmt.Sprintf("guardrails: %s blocked by %s: %s", e.Stage, e.Verdict.Check, e.Verdict.Reason)
+	}
+	return fmt.Sprintf("guardrails: %s blocked by %s", e.Stage, e.Verdict.Check)
+}
+
+// Is reports whether target is ErrBlocked.
+func (e *BlockedError) Is(target error) bool {
+	return target == ErrBlocked
+}
+
+// Content is the text a check inspects.
+type Content struct {
+	Stage Stage
+	Text  string // Text being checked
+	Input string // Input of the call, when checking output
+}
+
+// Verdict is the result of a check.
+type Verdict struct {
+	Check    string         `json:"check"`
+	Action   Action         `json:"action"`
+	Reason   string         `json:"reason,omitempty"`
+	Text     string         `json:"-"` // Replacement text for ActionRewrite
+	Score    *float64       `json:"score,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// Check inspects text before or after an LLM call.
+type Check interface {
+	// Name returns the check name.
+	Name() string
+
+	// Check inspects the content and returns a verdict.
+	Check(ctx context.Context, content Content) (Verdict, error)
+}
+
+// CheckFunc adapts a function to a Check.
+type CheckFunc struct {
+	name string
+	fn   func(ctx context.Context, content Content) (Verdict, error)
+}
+
+// NewCheckFunc creates a named check from a function.
+func NewCheckFunc(name string, fn func(ctx context.Context, content Content) (Verdict, error)) *CheckFunc {
+	return &CheckFunc{name: name, fn: fn}
+}
+
+// Name returns the check name.
+func (c *CheckFunc) Name() string {
+	return c.name
+}
+
+// Check calls the function.
+func (c *CheckFunc) Check(ctx context.Context, content Content) (Verdict, error) {
+	return c.fn(ctx, content)
+}
+
+// Result is the outcome of running a pipeline stage.
+type Result struct {
+	Text     string    // Text after rewrites
+	Verdicts []Verdict // Verdict of each check that ran
+}
+
+// Flagged reports whether any check flagged the text.
+func (r *Result) Flagged() bool {
+	for _, v := range r.Verdicts {
+		if v.Action == ActionFlag {
+			return true
+		}
+	}
+	return false
+}
+
+// Rewritten reports whether any check rewrote the text.
+func (r *Result) Rewritten() bool {
+	for _, v := range r.Verdicts {
+		if v.Action == ActionRewrite {
+			return true
+		}
+	}
+	return false
+}
+
+// Pipeline runs input and output checks.
+type Pipeline struct {
+	tracer     llmops.Tracer
+	input      []Check
+	output     []Check
+	failClosed bool
+}
+
+// Option configures a Pipeline.
+type Option func(*Pipeline)
+
+// WithTracer records each check as a guardrail span.
+func WithTracer(tracer llmops.Tracer) Option {
+	return func(p *Pipeline) {
+		p.tracer = tracer
+	}
+}
+
+// WithInputChecks adds checks run on the input before the call.
+func WithInputChecks(checks ...Check) Option {
+	return func(p *Pipeline) {
+		p.input = append(p.input, checks...)
+	}
+}
+
+// WithOutputChecks adds checks run on the output after the call.
+func WithOutputChecks(checks ...Check) Option {
+	return func(p *Pipeline) {
+		p.output = append(p.output, checks...)
+	}
+}
+
+// WithFailClosed blocks the call when a check returns an error. By default
+// a failing check flags the text and the call proceeds.
+func WithFailClosed() Option {
+	return func(p *Pipeline) {
+		p.failClosed = true
+	}
+}
+
+// NewPipeline creates a guardrail pipeline.
+func NewPipeline(opts ...Option) *Pipeline {
+	p := &Pipeline{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// HasInputChecks reports whether the pipeline has input checks.
+func (p *Pipeline) HasInputChecks() bool {
+	return len(p.input) > 0
+}
+
+// HasOutputChecks reports whether the pipeline has output checks.
+func (p *Pipeline) HasOutputChecks() bool {
+	return len(p.output) > 0
+}
+
+// CheckInput runs the input checks. It returns a *BlockedError if a check
+// blocks the input.
+func (p *Pipeline) CheckInput(ctx context.Context, input string) (*Result, error) {
+	return p.run(ctx, p.input, Content{Stage: StageInput, Text: input})
+}
+
+// CheckOutput runs the output checks. input is the input of the call, for
+// checks that compare the output with it. It returns a *BlockedError if a
+// check blocks the output.
+func (p *Pipeline) CheckOutput(ctx context.Context, input, output string) (*Result, error) {
+	return p.run(ctx, p.output, Content{Stage: StageOutput, Text: output, Input: input})
+}
+
+// run runs checks in order. Rewrites are passed on to later checks, and
+// the first block stops the pipeline.
+func (p *Pipeline) run(ctx context.Context, checks []Check, content Content) (*Result, error) {
+	result := &Result{Text: content.Text}
+	for _, check := range checks {
+		content.Text = result.Text
+		verdict := p.runCheck(ctx, check, content)
+		result.Verdicts = append(result.Verdicts, verdict)
+
+		switch verdict.Action {
+		case ActionBlock:
+			return result, &BlockedError{Stage: content.Stage, Verdict: verdict}
+		case ActionRewrite:
+			result.Text = verdict.Text
+		}
+	}
+	return result, nil
+}
+
+// runCheck runs a check in a guardrail span. The span records the verdict
+// but never the checked text.
+func (p *Pipeline) runCheck(ctx context.Context, check Check, content Content) Verdict {
+	var span llmops.Span
+	if p.tracer != nil {
+		var err error
+		ctx, span, err = p.tracer.StartSpan(ctx, "guardrail-"+check.Name(),
+			llmops.WithSpanType(llmops.SpanTypeGuardrail),
+			llmops.WithSpanMetadata(map[string]any{
+				"guardrail.name":  check.Name(),
+				"guardrail.stage": string(content.Stage),
+			}),
+		)
+		if err != nil {
+			span = nil
+		}
+	}
+
+	verdict, err := check.Check(ctx, content)
+	if verdict.Check == "" {
+		verdict.Check = check.Name()
+	}
+	if verdict.Action == "" {
+		verdict.Action = ActionPass
+	}
+	if err != nil {
+		verdict.Action = ActionFlag
+		if p.failClosed {
+			verdict.Action = ActionBlock
+		}
+		verdict.Reason = "check failed: " + err.Error()
+	}
+
+	if span != nil {
+		endOpts := []llmops.EndOption{
+			llmops.WithEndOutput(verdict),
+			llmops.WithEndMetadata(map[string]any{
+				"guardrail.action": string(verdict.Action),
+			}),
+		}
+		if err != nil {
+			endOpts = append(endOpts, llmops.WithEndError(err))
+		}
+		_ = span.End(endOpts...)
+	}
+	return verdict
+}
+
+// violation builds a verdict for a violation, applying the check's action.
+// Rewrites without replacement text fall back to blocking.
+func violation(name string, action Action, reason, rewritten string, metadata map[string]any) Verdict {
+	v := Verdict{Check: name, Action: action, Reason: reason, Metadata: metadata}
+	if action == ActionRewrite {
+		if rewritten == "" {
+			v.Action = ActionBlock
+		}
+		v.Text = rewritten
+	}
+	return v
+}
+
+// pass builds a passing verdict.
+func pass(name string) Verdict {
+	return Verdict{Check: name, Action: ActionPass}
+}
+
+// joinLimited joins at most n values for use in a reason.
+func joinLimited(values []string, n int) string {
+	if len(values) <= n {
+		return strings.Join(values, ", ")
+	}
+	return strings.Join(values[:n], ", ") + fmt.Sprintf(" and %d more", len(values)-n)
+}
@@ -0,0 +1,319 @@
+package guardrails
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/agentplexus/omniobserve/llmops"
+)
+
+// recordCheck returns a check that records the text it sees and returns
+// the verdict.
+func recordCheck(name string, seen *[]string, verdict Verdict, err error) Check {
+	return NewCheckFunc(name, func(_ context.Context, content Content) (Verdict, error) {
+		*seen = append(*seen, name+":"+content.Text)
+		return verdict, err
+	})
+}
+
+func TestPipeline_ShortCircuitsOnBlock(t *testing.T) {
+	var seen []string
+	p := NewPipeline(WithInputChecks(
+		recordCheck("rewrite", &seen, Verdict{Action: ActionRewrite, Text: "rewritten"}, nil),
+		recordCheck("block", &seen, Verdict{Action: ActionBlock, Reason: "nope"}, nil),
+		recordCheck("after", &seen, Verdict{}, nil),
+	))
+
+	result, err := p.CheckInput(context.Background(), "original")
+
+	if want := []string{"rewrite:original", "block:rewritten"}; !reflect.DeepEqual(seen, want) {
+		t.Errorf("expected checks %v, got %v", want, seen)
+	}
+	if !errors.Is(err, ErrBlocked) {
+		t.Fatalf("expected ErrBlocked, got %v", err)
+	}
+	var blocked *BlockedError
+	if !errors.As(err, &blocked) || blocked.Stage != StageInput || blocked.Verdict.Check != "block" {
+		t.Fatalf("expected *BlockedError from the block check, got %#v", err)
+	}
+	if got, want := err.Error(), "guardrails: input blocked by block: nope"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if len(result.Verdicts) != 2 || !result.Rewritten() {
+		t.Errorf("expected 2 verdicts including a rewrite, got %+v", result.Verdicts)
+	}
+}
+
+func TestPipeline_CheckErrors(t *testing.T) {
+	failing := NewCheckFunc("failing", func(context.Context, Content) (Verdict, error) {
+		return Verdict{}, errors.New("boom")
+	})
+
+	result, err := NewPipeline(WithOutputChecks(failing)).CheckOutput(context.Background(), "in", "out")
+	if err != nil {
+		t.Fatalf("expected a failing check not to block by default, got %v", err)
+	}
+	if !result.Flagged() || result.Verdicts[0].Reason != "check failed: boom" {
+		t.Errorf("expected a flagged verdict, got %+v", result.Verdicts)
+	}
+
+	_, err = NewPipeline(WithOutputChecks(failing), WithFailClosed()).CheckOutput(context.Background(), "in", "out")
+	var blocked *BlockedError
+	if !errors.As(err, &blocked) || blocked.Stage != StageOutput {
+		t.Errorf("expected fail-closed pipeline to block the output, got %v", err)
+	}
+}
+
+// recordingTracer records everything its spans are given. Methods not
+// overridden panic through the nil embedded interfaces.
+type recordingTracer struct {
+	llmops.Tracer
+	recorded []string
+}
+
+func (r *recordingTracer) StartSpan(ctx context.Context, name string, opts ...llmops.SpanOption) (context.Context, llmops.Span, error) {
+	o := llmops.ApplySpanOptions(opts...)
+	r.recorded = append(r.recorded, fmt.Sprintf("%s %v %v %v", name, o.Input, o.Output, o.Metadata))
+	return ctx, &recordingSpan{tracer: r}, nil
+}
+
+type recordingSpan struct {
+	llmops.Span
+	tracer *recordingTracer
+}
+
+func (s *recordingSpan) End(opts ...llmops.EndOption) error {
+	o := &llmops.EndOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	s.tracer.recorded = append(s.tracer.recorded, fmt.Sprintf("%+v %v", o.Output, o.Metadata))
+	return nil
+}
+
+func TestPipeline_SpansOmitCheckedText(t *testing.T) {
+	tracer := &recordingTracer{}
+	p := NewPipeline(WithTracer(tracer), WithInputChecks(
+		MustDenylist("secrets", `password`),
+		NewPII(),
+	))
+
+	result, err := p.CheckInput(context.Background(), "mail jo@example.com")
+	if err != nil || result.Text != "mail [EMAIL]" {
+		t.Fatalf("expected the email to be redacted, got %q, %v", result.Text, err)
+	}
+	if len(tracer.recorded) != 4 {
+		t.Fatalf("expected 2 spans started and ended, got %v", tracer.recorded)
+	}
+	for _, recorded := range tracer.recorded {
+		if strings.Contains(recorded, "jo@example.com") {
+			t.Errorf("expected guardrail spans not to record the checked text, got %q", recorded)
+		}
+	}
+}
+
+func TestDenylist(t *testing.T) {
+	block := MustDenylist("secrets", `(?i)api[_-]?key`, `password`)
+	rewrite, err := NewDenylist("secrets", []string{`sk-[a-z0-9]+`}, WithAction(ActionRewrite), WithReplacement("***"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		check  Check
+		text   string
+		action Action
+		output string
+	}{
+		{"pass", block, "hello", ActionPass, ""},
+		{"block", block, "my API_KEY is", ActionBlock, ""},
+		{"rewrite", rewrite, "use sk-abc123 and sk-def", ActionRewrite, "use *** and ***"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := tt.check.Check(context.Background(), Content{Text: tt.text})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v.Action != tt.action || v.Text != tt.output {
+				t.Errorf("expected %s %q, got %s %q", tt.action, tt.output, v.Action, v.Text)
+			}
+		})
+	}
+
+	if _, err := NewDenylist("bad", []string{"("}); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
+
+func TestPII(t *testing.T) {
+	tests := []struct {
+		name   string
+		check  *PII
+		text   string
+		action Action
+		output string
+	}{
+		{"email", NewPII(), "mail jo@example.com now", ActionRewrite, "mail [EMAIL] now"},
+		{"ssn", NewPII(), "ssn 123-45-6789", ActionRewrite, "ssn [SSN]"},
+		{"card", NewPII(), "card 4111 1111 1111 1111.", ActionRewrite, "card [CREDIT_CARD]."},
+		{"invalid card", NewPII(WithPIITypes(PIICreditCard)), "card 4111 1111 1111 1112", ActionPass, ""},
+		{"limited types", NewPII(WithPIITypes(PIIEmail)), "ssn 123-45-6789", ActionPass, ""},
+		{"block", NewPII(WithAction(ActionBlock)), "jo@example.com", ActionBlock, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := tt.check.Check(context.Background(), Content{Text: tt.text})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v.Action != tt.action {
+				t.Errorf("expected %s, got %s (%s)", tt.action, v.Action, v.Reason)
+			}
+			if tt.action == ActionRewrite && v.Text != tt.output {
+				t.Errorf("expected %q, got %q", tt.output, v.Text)
+			}
+		})
+	}
+}
+
+func TestLuhnValid(t *testing.T) {
+	tests := map[string]bool{
+		"4111111111111111":    true,
+		"4111-1111-1111-1111": true,
+		"4111111111111112":    false,
+		"0000000000":          false, // too short
+	}
+	for s, want := range tests {
+		if got := luhnValid(s); got != want {
+			t.Errorf("luhnValid(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	check, err := NewJSONSchema(map[string]any{
+		"type":     "object",
+		"required": []string{"name"},
+		"properties": map[string]any{
+			"name": map[string]any{"type": "string"},
+			"age":  map[string]any{"type": "integer"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		text   string
+		action Action
+		paths  []string
+	}{
+		{"valid", `{"name": "Ada", "age": 36}`, ActionPass, nil},
+		{"fenced", "```json\n{\"name\": \"Ada\"}\n```", ActionPass, nil},
+		{"wrong type", `{"name": "Ada", "age": "old"}`, ActionBlock, []string{"/age"}},
+		{"invalid JSON", `{"name":`, ActionBlock, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := check.Check(context.Background(), Content{Stage: StageOutput, Text: tt.text})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v.Action != tt.action {
+				t.Fatalf("expected %s, got %s (%s)", tt.action, v.Action, v.Reason)
+			}
+			if tt.paths != nil && !reflect.DeepEqual(v.Metadata["paths"], tt.paths) {
+				t.Errorf("expected paths %v, got %v", tt.paths, v.Metadata["paths"])
+			}
+		})
+	}
+
+	if _, err := NewJSONSchema(`{"type": 1`); err == nil {
+		t.Error("expected an error for an invalid schema")
+	}
+}
+
+func TestMaxLength(t *testing.T) {
+	tests := []struct {
+		name   string
+		check  *MaxLength
+		text   string
+		action Action
+		output string
+	}{
+		{"within limit", NewMaxLength(5), "héllo", ActionPass, ""},
+		{"block", NewMaxLength(4), "héllo", ActionBlock, ""},
+		{"truncate runes", NewMaxLength(2, WithAction(ActionRewrite)), "héllo", ActionRewrite, "hé"},
+		{"negative max", NewMaxLength(-1, WithAction(ActionRewrite)), "hi", ActionBlock, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := tt.check.Check(context.Background(), Content{Text: tt.text})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v.Action != tt.action || v.Text != tt.output {
+				t.Errorf("expected %s %q, got %s %q", tt.action, tt.output, v.Action, v.Text)
+			}
+		})
+	}
+}
+
+// fakeMetric returns a fixed score and records its input.
+type fakeMetric struct {
+	score llmops.MetricScore
+	err   error
+	input llmops.EvalInput
+}
+
+func (m *fakeMetric) Name() string { return "fake" }
+
+func (m *fakeMetric) Evaluate(input llmops.EvalInput) (llmops.MetricScore, error) {
+	m.input = input
+	return m.score, m.err
+}
+
+func TestMetricCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		score   llmops.MetricScore
+		opts    []CheckOption
+		action  Action
+		wantErr bool
+	}{
+		{"above default minimum", llmops.MetricScore{Score: 0.8}, nil, ActionPass, false},
+		{"below default minimum", llmops.MetricScore{Score: 0.2, Reason: "off topic"}, nil, ActionBlock, false},
+		{"above maximum", llmops.MetricScore{Score: 0.7}, []CheckOption{WithMaxScore(0.3)}, ActionBlock, false},
+		{"below maximum", llmops.MetricScore{Score: 0.1}, []CheckOption{WithMaxScore(0.3), WithAction(ActionFlag)}, ActionPass, false},
+		{"score error", llmops.MetricScore{Error: "judge failed"}, nil, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metric := &fakeMetric{score: tt.score}
+			v, err := NewMetricCheck(metric, tt.opts...).Check(context.Background(),
+				Content{Stage: StageOutput, Input: "question", Text: "answer"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				return
+			}
+			if v.Action != tt.action || v.Score == nil || *v.Score != tt.score.Score {
+				t.Errorf("expected %s with score %v, got %+v", tt.action, tt.score.Score, v)
+			}
+			if tt.score.Reason != "" && !strings.HasSuffix(v.Reason, tt.score.Reason) {
+				t.Errorf("expected reason to include %q, got %q", tt.score.Reason, v.Reason)
+			}
+			if metric.input.Input != "question" || metric.input.Output != "answer" {
+				t.Errorf("expected output checks to pass input and output, got %+v", metric.input)
+			}
+		})
+	}
+}
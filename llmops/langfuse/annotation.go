@@ -0,0 +1,146 @@
+package langfuse
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/agentplexus/omniobserve/llmops"
+	sdk "github.com/agentplexus/omniobserve/sdk/langfuse"
+)
+
+// annotationPageSize is the page size used when listing scores.
+const annotationPageSize = 100
+
+// CreateAnnotation creates an annotation as a Langfuse score.
+// Span annotations require both SpanID and TraceID, since Langfuse scores
+// always belong to a trace. The annotation Source maps to the score source:
+// human to ANNOTATION, llm to EVAL, and code to API. Annotations are
+// validated like feedback scores, against the registered score config of
+// the same name if any, and linked to that config.
+func (p *Provider) CreateAnnotation(ctx context.Context, annotation llmops.Annotation) error {
+	if annotation.TraceID == "" {
+		if annotation.SpanID != "" {
+			return fmt.Errorf("%w: langfuse span annotations require a trace ID", llmops.ErrInvalidInput)
+		}
+		return fmt.Errorf("%w: annotation requires a trace ID", llmops.ErrInvalidInput)
+	}
+	if annotation.Name == "" {
+		return fmt.Errorf("%w: annotation requires a name", llmops.ErrInvalidInput)
+	}
+
+	value, sdkOpts, err := scoreOptions(llmops.FeedbackScore{
+		Name:     annotation.Name,
+		Score:    annotation.Score,
+		DataType: annotation.DataType,
+		Label:    annotation.Label,
+		Reason:   annotation.Explanation,
+		Source:   scoreSourceFromAnnotator(annotation.Source),
+	})
+	if err != nil {
+		return err
+	}
+
+	body := sdk.NewScoreBody(annotation.TraceID, annotation.SpanID, annotation.Name, value, sdkOpts...)
+	if annotation.ID != "" {
+		body.ID = annotation.ID
+	}
+	_, err = p.client.CreateScore(ctx, body)
+	return err
+}
+
+// ListAnnotations lists the Langfuse scores on the given spans or traces.
+// All pages are fetched for each ID; Limit and Offset apply to the combined result.
+func (p *Provider) ListAnnotations(ctx context.Context, opts llmops.ListAnnotationsOptions) ([]*llmops.Annotation, error) {
+	if len(opts.SpanIDs) > 0 && len(opts.TraceIDs) > 0 {
+		return nil, fmt.Errorf("%w: provide either span IDs or trace IDs, not both", llmops.ErrInvalidInput)
+	}
+
+	var filters []sdk.ScoreFilter
+	for _, id := range opts.SpanIDs {
+		filters = append(filters, sdk.ScoreFilter{ObservationID: id})
+	}
+	for _, id := range opts.TraceIDs {
+		filters = append(filters, sdk.ScoreFilter{TraceID: id})
+	}
+
+	result := []*llmops.Annotation{}
+	for _, filter := range filters {
+		for page := 1; ; page++ {
+			resp, err := p.client.ListScores(ctx, filter, annotationPageSize, page)
+			if err != nil {
+				return nil, err
+			}
+			for _, score := range resp.Data {
+				result = append(result, annotationFromScore(score))
+			}
+			if len(resp.Data) < annotationPageSize || page >= resp.Meta.TotalPages {
+				break
+			}
+		}
+	}
+
+	if opts.Offset > 0 {
+		if opts.Offset >= len(result) {
+			return []*llmops.Annotation{}, nil
+		}
+		result = result[opts.Offset:]
+	}
+	if opts.Limit > 0 && len(result) > opts.Limit {
+		result = result[:opts.Limit]
+	}
+	return result, nil
+}
+
+// annotationFromScore converts a Langfuse score into an annotation.
+func annotationFromScore(s sdk.Score) *llmops.Annotation {
+	a := &llmops.Annotation{
+		ID:          s.ID,
+		TraceID:     s.TraceID,
+		SpanID:      s.ObservationID,
+		Name:        s.Name,
+		Score:       s.Value,
+		Label:       s.StringValue,
+		Explanation: s.Comment,
+		Source:      annotatorFromScoreSource(s.Source),
+		CreatedAt:   s.CreatedAt,
+		UpdatedAt:   s.UpdatedAt,
+	}
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = s.Timestamp
+	}
+
+	switch s.DataType {
+	case sdk.ScoreDataTypeCategorical:
+		a.DataType = llmops.ScoreDataTypeCategorical
+	case sdk.ScoreDataTypeBoolean:
+		a.DataType = llmops.ScoreDataTypeBoolean
+	default:
+		a.DataType = llmops.ScoreDataTypeNumeric
+	}
+	return a
+}
+
+// scoreSourceFromAnnotator maps an annotator kind to a Langfuse score source.
+func scoreSourceFromAnnotator(kind llmops.AnnotatorKind) string {
+	switch kind {
+	case llmops.AnnotatorKindLLM:
+		return sdk.ScoreSourceEval
+	case llmops.AnnotatorKindCode:
+		return sdk.ScoreSourceAPI
+	default:
+		return sdk.ScoreSourceAnnotation
+	}
+}
+
+// annotatorFromScoreSource maps a Langfuse score source to an annotator kind.
+func annotatorFromScoreSource(source string) llmops.AnnotatorKind {
+	switch strings.ToUpper(source) {
+	case sdk.ScoreSourceEval:
+		return llmops.AnnotatorKindLLM
+	case sdk.ScoreSourceAPI:
+		return llmops.AnnotatorKindCode
+	default:
+		return llmops.AnnotatorKindHuman
+	}
+}
@@ -0,0 +1,88 @@
+package langfuse
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/agentplexus/omniobserve/llmops"
+	sdk "github.com/agentplexus/omniobserve/sdk/langfuse"
+)
+
+// registerQuality registers a categorical "quality" score config for the
+// duration of the test.
+func registerQuality(t *testing.T) {
+	t.Helper()
+	err := llmops.RegisterScoreConfig(llmops.ScoreConfig{
+		ID:       "cfg-quality",
+		Name:     "quality",
+		DataType: llmops.ScoreDataTypeCategorical,
+		Categories: []llmops.ScoreCategory{
+			{Label: "good", Value: 1},
+			{Label: "bad", Value: 0},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { llmops.UnregisterScoreConfig("quality") })
+}
+
+func TestCreateAnnotation_UsesScoreConfig(t *testing.T) {
+	registerQuality(t)
+	api := newFakeAPI(t)
+	p := newTestProvider(t, api.Server)
+
+	err := p.CreateAnnotation(context.Background(), llmops.Annotation{
+		ID:          "ann-1",
+		TraceID:     "trace-1",
+		SpanID:      "span-1",
+		Name:        "quality",
+		Label:       "good",
+		Explanation: "Clear answer",
+		Source:      llmops.AnnotatorKindLLM,
+	})
+	if err != nil {
+		t.Fatalf("CreateAnnotation: %v", err)
+	}
+
+	scores := api.createdScores()
+	if len(scores) != 1 {
+		t.Fatalf("expected 1 score, got %d", len(scores))
+	}
+	got := scores[0]
+	if got.ID != "ann-1" || got.TraceID != "trace-1" || got.ObservationID != "span-1" {
+		t.Errorf("expected the annotation IDs to be kept, got %+v", got)
+	}
+	if got.ConfigID != "cfg-quality" || got.DataType != sdk.ScoreDataTypeCategorical || got.StringValue != "good" {
+		t.Errorf("expected a categorical score linked to its config, got %+v", got)
+	}
+	if got.Comment != "Clear answer" || got.Source != sdk.ScoreSourceEval {
+		t.Errorf("expected the explanation and an EVAL source, got %+v", got)
+	}
+}
+
+func TestCreateAnnotation_ValidatesAgainstScoreConfig(t *testing.T) {
+	registerQuality(t)
+	api := newFakeAPI(t)
+	p := newTestProvider(t, api.Server)
+
+	tests := []struct {
+		name       string
+		annotation llmops.Annotation
+	}{
+		{"unknown category", llmops.Annotation{TraceID: "trace-1", Name: "quality", Label: "meh"}},
+		{"boolean out of range", llmops.Annotation{TraceID: "trace-1", Name: "correct", DataType: llmops.ScoreDataTypeBoolean, Score: 0.7}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.CreateAnnotation(context.Background(), tt.annotation)
+			if !errors.Is(err, llmops.ErrInvalidScore) {
+				t.Errorf("expected ErrInvalidScore, got %v", err)
+			}
+		})
+	}
+	if got := api.createdScores(); len(got) != 0 {
+		t.Errorf("expected invalid annotations not to be sent, got %+v", got)
+	}
+}
@@ -36,8 +36,11 @@ func init() {
 			llmops.CapabilityPrompts,
 			llmops.CapabilityDatasets,
 			llmops.CapabilityExperiments,
+			llmops.CapabilityAnnotations,
 			llmops.CapabilityStreaming,
 			llmops.CapabilityCostTracking,
+			llmops.CapabilitySessions,
+			llmops.CapabilityMedia,
 		},
 	})
 }
@@ -85,6 +88,20 @@ func New(opts ...llmops.ClientOption) (llmops.Provider, error) {
 	return &Provider{client: client}, nil
 }
 
+// NewWithClient creates a Langfuse provider from an existing SDK client.
+// Use it to configure SDK options not exposed through llmops.ClientOption,
+// such as OTLP export:
+//
+//	client, err := sdk.NewClient(
+//		sdk.WithPublicKey("pk-..."),
+//		sdk.WithSecretKey("sk-..."),
+//		sdk.WithOTLP(),
+//	)
+//	provider := langfuse.NewWithClient(client)
+func NewWithClient(client *sdk.Client) *Provider {
+	return &Provider{client: client}
+}
+
 // Name returns the provider name.
 func (p *Provider) Name() string {
 	return ProviderName
@@ -188,16 +205,27 @@ func (p *Provider) Evaluate(ctx context.Context, input llmops.EvalInput, metrics
 }
 
 // AddFeedbackScore adds a feedback score.
+// If TraceID is set, the score is attached to that trace (and to SpanID, if
+// set); otherwise it is attached to the active span, generation, or trace.
 func (p *Provider) AddFeedbackScore(ctx context.Context, opts llmops.FeedbackScoreOpts) error {
+	value, sdkOpts, err := scoreOptions(opts.FeedbackScore())
+	if err != nil {
+		return err
+	}
+
+	if opts.TraceID != "" {
+		return p.client.Score(ctx, opts.TraceID, opts.SpanID, opts.Name, value, sdkOpts...)
+	}
+
 	// Try span/generation first, then trace
 	if span := sdk.SpanFromContext(ctx); span != nil {
-		return span.Score(ctx, opts.Name, opts.Score)
+		return span.Score(ctx, opts.Name, value, sdkOpts...)
 	}
 	if gen := sdk.GenerationFromContext(ctx); gen != nil {
-		return gen.Score(ctx, opts.Name, opts.Score)
+		return gen.Score(ctx, opts.Name, value, sdkOpts...)
 	}
 	if trace := sdk.TraceFromContext(ctx); trace != nil {
-		return trace.Score(ctx, opts.Name, opts.Score)
+		return trace.Score(ctx, opts.Name, value, sdkOpts...)
 	}
 	return llmops.ErrNoActiveTrace
 }
@@ -332,13 +360,3 @@ func (p *Provider) GetDatasetByID(ctx context.Context, id string) (*llmops.Datas
 func (p *Provider) DeleteDataset(ctx context.Context, datasetID string) error {
 	return llmops.WrapNotImplemented(ProviderName, "DeleteDataset")
 }
-
-// CreateAnnotation is not directly supported in Langfuse.
-func (p *Provider) CreateAnnotation(ctx context.Context, annotation llmops.Annotation) error {
-	return llmops.WrapNotImplemented(ProviderName, "CreateAnnotation")
-}
-
-// ListAnnotations is not supported in Langfuse.
-func (p *Provider) ListAnnotations(ctx context.Context, opts llmops.ListAnnotationsOptions) ([]*llmops.Annotation, error) {
-	return nil, llmops.WrapNotImplemented(ProviderName, "ListAnnotations")
-}
@@ -0,0 +1,187 @@
+package langfuse
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/agentplexus/omniobserve/llmops"
+	"github.com/agentplexus/omniobserve/llmops/metrics"
+	sdk "github.com/agentplexus/omniobserve/sdk/langfuse"
+)
+
+// =============================================================================
+// Test Helpers
+// =============================================================================
+
+// fakeAPI is a fake Langfuse public API. It serves fixed score configs and
+// traces in pages and records the requested pages, the ingested events, and
+// the scores created directly.
+type fakeAPI struct {
+	*httptest.Server
+
+	mu           sync.Mutex
+	scoreConfigs []sdk.ScoreConfig
+	traces       []sdk.TraceInfo
+	pages        []int
+	events       []sdk.Event
+	scores       []sdk.ScoreBody
+}
+
+func newFakeAPI(t *testing.T) *fakeAPI {
+	t.Helper()
+	api := &fakeAPI{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/public/score-configs", func(w http.ResponseWriter, r *http.Request) {
+		writePage(w, r, api, api.scoreConfigs)
+	})
+	mux.HandleFunc("GET /api/public/traces", func(w http.ResponseWriter, r *http.Request) {
+		// The list endpoint returns summaries without observations and scores
+		summaries := make([]sdk.TraceInfo, len(api.traces))
+		for i, trace := range api.traces {
+			trace.Observations, trace.Scores = nil, nil
+			summaries[i] = trace
+		}
+		writePage(w, r, api, summaries)
+	})
+	mux.HandleFunc("GET /api/public/traces/{id}", func(w http.ResponseWriter, r *http.Request) {
+		for _, trace := range api.traces {
+			if trace.ID == r.PathValue("id") {
+				_ = json.NewEncoder(w).Encode(trace)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("POST /api/public/ingestion", func(w http.ResponseWriter, r *http.Request) {
+		var req sdk.BatchIngestionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		api.mu.Lock()
+		api.events = append(api.events, req.Batch...)
+		api.mu.Unlock()
+	})
+	mux.HandleFunc("POST /api/public/scores", func(w http.ResponseWriter, r *http.Request) {
+		// Categorical values are sent as strings
+		var body struct {
+			sdk.ScoreBody
+			Value any `json:"value"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if value, ok := body.Value.(float64); ok {
+			body.ScoreBody.Value = value
+		}
+		api.mu.Lock()
+		api.scores = append(api.scores, body.ScoreBody)
+		api.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]string{"id": body.ID})
+	})
+	api.Server = httptest.NewServer(mux)
+	t.Cleanup(api.Close)
+	return api
+}
+
+// writePage writes the page of items selected by the limit and page query
+// parameters, recording the page number.
+func writePage[T any](w http.ResponseWriter, r *http.Request, api *fakeAPI, items []T) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	api.mu.Lock()
+	api.pages = append(api.pages, page)
+	api.mu.Unlock()
+
+	start := min((page-1)*limit, len(items))
+	end := min(start+limit, len(items))
+	_ = json.NewEncoder(w).Encode(map[string]any{"data": items[start:end]})
+}
+
+// requestedPages returns the page numbers requested so far.
+func (api *fakeAPI) requestedPages() []int {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return append([]int(nil), api.pages...)
+}
+
+// createdScores returns the scores created through the scores endpoint.
+func (api *fakeAPI) createdScores() []sdk.ScoreBody {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return append([]sdk.ScoreBody(nil), api.scores...)
+}
+
+// scoreBodies returns the bodies of the ingested score events.
+func (api *fakeAPI) scoreBodies(t *testing.T) []sdk.ScoreBody {
+	t.Helper()
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	var bodies []sdk.ScoreBody
+	for _, e := range api.events {
+		if e.Type != sdk.EventTypeScoreCreate {
+			continue
+		}
+		data, _ := json.Marshal(e.Body)
+		var body sdk.ScoreBody
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Fatal(err)
+		}
+		bodies = append(bodies, body)
+	}
+	return bodies
+}
+
+// newTestProvider returns a provider sending to server.
+func newTestProvider(t *testing.T, server *httptest.Server, opts ...sdk.Option) *Provider {
+	t.Helper()
+	opts = append([]sdk.Option{
+		sdk.WithPublicKey("pk-test"),
+		sdk.WithSecretKey("sk-test"),
+		sdk.WithEndpoint(server.URL),
+	}, opts...)
+	client, err := sdk.NewClient(opts...)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return NewWithClient(client)
+}
+
+// =============================================================================
+// Evaluation Tests
+// =============================================================================
+
+// failingJudge is a metric whose judge call fails.
+type failingJudge struct{}
+
+func (failingJudge) Name() string { return "faithfulness" }
+
+func (failingJudge) Evaluate(llmops.EvalInput) (llmops.MetricScore, error) {
+	return llmops.MetricScore{Name: "faithfulness", Error: "judge unavailable"}, errors.New("judge unavailable")
+}
+
+func TestEvaluate_FailedJudgeFailsSummary(t *testing.T) {
+	p := &Provider{}
+	result, err := p.Evaluate(context.Background(), llmops.EvalInput{Output: "answer"},
+		metrics.NewThresholdMetric(failingJudge{}, 0.7),
+	)
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+
+	s := metrics.SummarizeResult(result)
+	if s.Passed || s.Err() == nil {
+		t.Fatalf("expected a failed judge to fail the evaluation, got %+v", s)
+	}
+	if len(s.Failures) != 1 || s.Failures[0].Name != "faithfulness" || s.Failures[0].Error == "" {
+		t.Errorf("expected the failed judge in the failures, got %+v", s.Failures)
+	}
+}
@@ -0,0 +1,65 @@
+package langfuse
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/agentplexus/omniobserve/llmops"
+	sdk "github.com/agentplexus/omniobserve/sdk/langfuse"
+)
+
+// Ensure adapters implement llmops.MediaAttacher at compile time.
+var (
+	_ llmops.MediaAttacher = (*traceAdapter)(nil)
+	_ llmops.MediaAttacher = (*spanAdapter)(nil)
+	_ llmops.MediaAttacher = (*generationAdapter)(nil)
+)
+
+// mediaUploader is implemented by SDK traces, spans, and generations.
+type mediaUploader interface {
+	UploadMedia(ctx context.Context, field, contentType string, data []byte) (*sdk.MediaReference, error)
+}
+
+// AttachMedia uploads media to Langfuse for the trace.
+func (t *traceAdapter) AttachMedia(ctx context.Context, media llmops.Media) (*llmops.MediaReference, error) {
+	return uploadMedia(ctx, t.trace, media)
+}
+
+// AttachMedia uploads media to Langfuse for the span.
+func (s *spanAdapter) AttachMedia(ctx context.Context, media llmops.Media) (*llmops.MediaReference, error) {
+	return uploadMedia(ctx, s.span, media)
+}
+
+// AttachMedia uploads media to Langfuse for the generation.
+func (g *generationAdapter) AttachMedia(ctx context.Context, media llmops.Media) (*llmops.MediaReference, error) {
+	return uploadMedia(ctx, g.gen, media)
+}
+
+// uploadMedia uploads media through the Langfuse media API. The returned
+// token is rendered as the media by the Langfuse UI.
+func uploadMedia(ctx context.Context, uploader mediaUploader, media llmops.Media) (*llmops.MediaReference, error) {
+	if media.ContentType == "" {
+		return nil, fmt.Errorf("%w: media requires a content type", llmops.ErrInvalidInput)
+	}
+
+	data, err := media.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("read media: %w", err)
+	}
+
+	field := media.Field
+	if field == "" {
+		field = llmops.MediaFieldInput
+	}
+
+	ref, err := uploader.UploadMedia(ctx, field, media.ContentType, data)
+	if err != nil {
+		return nil, err
+	}
+	return &llmops.MediaReference{
+		ID:          ref.ID,
+		ContentType: ref.ContentType,
+		Size:        ref.Size,
+		Token:       ref.Token,
+	}, nil
+}
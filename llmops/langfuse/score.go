@@ -0,0 +1,163 @@
+package langfuse
+
+import (
+	"context"
+
+	"github.com/agentplexus/omniobserve/llmops"
+	sdk "github.com/agentplexus/omniobserve/sdk/langfuse"
+)
+
+// Ensure Provider implements llmops.ScoreConfigManager at compile time.
+var _ llmops.ScoreConfigManager = (*Provider)(nil)
+
+// CreateScoreConfig creates a Langfuse score config.
+func (p *Provider) CreateScoreConfig(ctx context.Context, cfg llmops.ScoreConfig) (*llmops.ScoreConfig, error) {
+	if err := cfg.Check(); err != nil {
+		return nil, err
+	}
+
+	created, err := p.client.CreateScoreConfig(ctx, scoreConfigToSDK(cfg))
+	if err != nil {
+		return nil, err
+	}
+	return scoreConfigFromSDK(*created), nil
+}
+
+// ListScoreConfigs lists Langfuse score configs.
+func (p *Provider) ListScoreConfigs(ctx context.Context, opts ...llmops.ListOption) ([]*llmops.ScoreConfig, error) {
+	cfg := llmops.ApplyListOptions(opts...)
+
+	configs, err := listPage(cfg, func(limit, page int) ([]sdk.ScoreConfig, error) {
+		return p.client.ListScoreConfigs(ctx, limit, page)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*llmops.ScoreConfig, len(configs))
+	for i, c := range configs {
+		result[i] = scoreConfigFromSDK(c)
+	}
+	return result, nil
+}
+
+// feedbackScore builds a feedback score from a name, value, and options.
+func feedbackScore(name string, score float64, opts ...llmops.FeedbackOption) llmops.FeedbackScore {
+	cfg := llmops.ApplyFeedbackOptions(opts...)
+	return llmops.FeedbackScore{
+		Name:     name,
+		Score:    score,
+		DataType: cfg.DataType,
+		Label:    cfg.Label,
+		Reason:   cfg.Reason,
+		Category: cfg.Category,
+		Source:   cfg.Source,
+	}
+}
+
+// scoreOptions validates a feedback score and converts it into Langfuse
+// score options. It returns the value to send, which is normalized to
+// 0 or 1 for boolean scores.
+func scoreOptions(score llmops.FeedbackScore) (float64, []sdk.ScoreOption, error) {
+	if err := llmops.ValidateFeedbackScore(score); err != nil {
+		return 0, nil, err
+	}
+
+	value := score.Score
+	sdkOpts := []sdk.ScoreOption{}
+	if score.Reason != "" {
+		sdkOpts = append(sdkOpts, sdk.WithScoreComment(score.Reason))
+	}
+	if score.Source != "" {
+		sdkOpts = append(sdkOpts, sdk.WithScoreSource(score.Source))
+	}
+
+	switch score.ResolvedDataType() {
+	case llmops.ScoreDataTypeCategorical:
+		sdkOpts = append(sdkOpts,
+			sdk.WithScoreDataType(sdk.ScoreDataTypeCategorical),
+			sdk.WithScoreStringValue(score.Label),
+		)
+		if cfg, ok := llmops.LookupScoreConfig(score.Name); ok {
+			if cat, ok := cfg.Category(score.Label); ok {
+				value = cat.Value
+			}
+		}
+	case llmops.ScoreDataTypeBoolean:
+		sdkOpts = append(sdkOpts, sdk.WithScoreDataType(sdk.ScoreDataTypeBoolean))
+	default:
+		sdkOpts = append(sdkOpts, sdk.WithScoreDataType(sdk.ScoreDataTypeNumeric))
+	}
+
+	if cfg, ok := llmops.LookupScoreConfig(score.Name); ok && cfg.ID != "" {
+		sdkOpts = append(sdkOpts, sdk.WithScoreConfigID(cfg.ID))
+	}
+
+	return value, sdkOpts, nil
+}
+
+// feedbackFromScore converts a Langfuse score into a feedback score.
+func feedbackFromScore(s sdk.Score) llmops.FeedbackScore {
+	return llmops.FeedbackScore{
+		Name:     s.Name,
+		Score:    s.Value,
+		DataType: scoreDataTypeFromSDK(s.DataType),
+		Label:    s.StringValue,
+		Reason:   s.Comment,
+		Source:   s.Source,
+	}
+}
+
+// scoreDataTypeToSDK maps an llmops score data type to a Langfuse data type.
+func scoreDataTypeToSDK(dataType llmops.ScoreDataType) string {
+	switch dataType {
+	case llmops.ScoreDataTypeCategorical:
+		return sdk.ScoreDataTypeCategorical
+	case llmops.ScoreDataTypeBoolean:
+		return sdk.ScoreDataTypeBoolean
+	default:
+		return sdk.ScoreDataTypeNumeric
+	}
+}
+
+// scoreDataTypeFromSDK maps a Langfuse data type to an llmops score data type.
+func scoreDataTypeFromSDK(dataType string) llmops.ScoreDataType {
+	switch dataType {
+	case sdk.ScoreDataTypeCategorical:
+		return llmops.ScoreDataTypeCategorical
+	case sdk.ScoreDataTypeBoolean:
+		return llmops.ScoreDataTypeBoolean
+	default:
+		return llmops.ScoreDataTypeNumeric
+	}
+}
+
+func scoreConfigToSDK(cfg llmops.ScoreConfig) sdk.ScoreConfig {
+	out := sdk.ScoreConfig{
+		ID:          cfg.ID,
+		Name:        cfg.Name,
+		DataType:    scoreDataTypeToSDK(cfg.DataType),
+		MinValue:    cfg.MinValue,
+		MaxValue:    cfg.MaxValue,
+		Description: cfg.Description,
+	}
+	for _, cat := range cfg.Categories {
+		out.Categories = append(out.Categories, sdk.ScoreCategory{Label: cat.Label, Value: cat.Value})
+	}
+	return out
+}
+
+func scoreConfigFromSDK(cfg sdk.ScoreConfig) *llmops.ScoreConfig {
+	out := &llmops.ScoreConfig{
+		ID:          cfg.ID,
+		Name:        cfg.Name,
+		DataType:    scoreDataTypeFromSDK(cfg.DataType),
+		MinValue:    cfg.MinValue,
+		MaxValue:    cfg.MaxValue,
+		Description: cfg.Description,
+	}
+	for _, cat := range cfg.Categories {
+		out.Categories = append(out.Categories, llmops.ScoreCategory{Label: cat.Label, Value: cat.Value})
+	}
+	return out
+}
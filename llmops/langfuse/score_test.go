@@ -0,0 +1,57 @@
+package langfuse
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/agentplexus/omniobserve/llmops"
+	sdk "github.com/agentplexus/omniobserve/sdk/langfuse"
+)
+
+func TestListScoreConfigs_UnalignedOffset(t *testing.T) {
+	api := newFakeAPI(t)
+	for _, name := range []string{"c1", "c2", "c3", "c4", "c5"} {
+		api.scoreConfigs = append(api.scoreConfigs, sdk.ScoreConfig{ID: name, Name: name, DataType: sdk.ScoreDataTypeNumeric})
+	}
+	p := newTestProvider(t, api.Server)
+
+	configs, err := p.ListScoreConfigs(context.Background(), llmops.WithLimit(2), llmops.WithOffset(3))
+	if err != nil {
+		t.Fatalf("ListScoreConfigs: %v", err)
+	}
+
+	var names []string
+	for _, c := range configs {
+		names = append(names, c.Name)
+	}
+	if want := []string{"c4", "c5"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("expected configs %v from offset 3, got %v", want, names)
+	}
+	if want := []int{2, 3}; !reflect.DeepEqual(api.requestedPages(), want) {
+		t.Errorf("expected pages %v, got %v", want, api.requestedPages())
+	}
+}
+
+func TestAddFeedbackScore_Source(t *testing.T) {
+	api := newFakeAPI(t)
+	p := newTestProvider(t, api.Server)
+
+	err := p.AddFeedbackScore(context.Background(), llmops.FeedbackScoreOpts{
+		TraceID: "trace-1",
+		Name:    "helpfulness",
+		Score:   0.8,
+		Source:  "user",
+	})
+	if err != nil {
+		t.Fatalf("AddFeedbackScore: %v", err)
+	}
+	if err := p.client.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	bodies := api.scoreBodies(t)
+	if len(bodies) != 1 || bodies[0].Source != "user" {
+		t.Errorf("expected one score with source %q, got %+v", "user", bodies)
+	}
+}
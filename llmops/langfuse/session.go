@@ -0,0 +1,230 @@
+package langfuse
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/agentplexus/omniobserve/llmops"
+	sdk "github.com/agentplexus/omniobserve/sdk/langfuse"
+)
+
+// Ensure Provider implements llmops.SessionManager at compile time.
+var _ llmops.SessionManager = (*Provider)(nil)
+
+// ListThreads lists conversation threads (Langfuse sessions).
+func (p *Provider) ListThreads(ctx context.Context, opts ...llmops.ListOption) ([]*llmops.Thread, error) {
+	cfg := llmops.ApplyListOptions(opts...)
+
+	sessions, err := listPage(cfg, func(limit, page int) ([]sdk.Session, error) {
+		return p.client.ListSessions(ctx, limit, page)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*llmops.Thread, len(sessions))
+	for i, s := range sessions {
+		result[i] = &llmops.Thread{
+			ID:        s.ID,
+			ProjectID: s.ProjectID,
+			CreatedAt: s.CreatedAt,
+		}
+	}
+	return result, nil
+}
+
+// GetThread retrieves a thread with token, cost, and latency statistics
+// aggregated over all of its traces.
+func (p *Provider) GetThread(ctx context.Context, threadID string) (*llmops.Thread, error) {
+	session, err := p.client.GetSession(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+
+	traces, err := p.fetchTraces(ctx, session.Traces)
+	if err != nil {
+		return nil, err
+	}
+	stats := llmops.SummarizeThread(traces)
+
+	return &llmops.Thread{
+		ID:        session.ID,
+		ProjectID: session.ProjectID,
+		Stats:     &stats,
+		CreatedAt: session.CreatedAt,
+	}, nil
+}
+
+// ListThreadTraces lists the traces of a thread in chronological order.
+// Each trace is fetched in full so that token usage and feedback are included,
+// with up to fetchTraceConcurrency requests in flight.
+func (p *Provider) ListThreadTraces(ctx context.Context, threadID string, opts ...llmops.ListOption) ([]*llmops.TraceInfo, error) {
+	cfg := llmops.ApplyListOptions(opts...)
+
+	summaries, err := listPage(cfg, func(limit, page int) ([]sdk.TraceInfo, error) {
+		return p.client.ListSessionTraces(ctx, threadID, limit, page)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return p.fetchTraces(ctx, summaries)
+}
+
+// AddThreadFeedbackScore adds a session-level score.
+func (p *Provider) AddThreadFeedbackScore(ctx context.Context, threadID, name string, score float64, opts ...llmops.FeedbackOption) error {
+	value, sdkOpts, err := scoreOptions(feedbackScore(name, score, opts...))
+	if err != nil {
+		return err
+	}
+
+	return p.client.ScoreSession(ctx, threadID, name, value, sdkOpts...)
+}
+
+// fetchTraceConcurrency is the number of traces fetched in parallel. The
+// list endpoints return observation and score IDs only, so each trace needs
+// its own request for token usage and feedback.
+const fetchTraceConcurrency = 8
+
+// fetchTraces retrieves full trace details for the given summaries and
+// returns them sorted by start time. It stops at the first error, or when
+// ctx is cancelled, without starting further requests.
+func (p *Provider) fetchTraces(ctx context.Context, summaries []sdk.TraceInfo) ([]*llmops.TraceInfo, error) {
+	fetchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	result := make([]*llmops.TraceInfo, len(summaries))
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	sem := make(chan struct{}, fetchTraceConcurrency)
+	for i, s := range summaries {
+		sem <- struct{}{}
+		if fetchCtx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			trace, err := p.client.GetTrace(fetchCtx, s.ID)
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				mu.Unlock()
+				return
+			}
+			result[i] = traceInfoFromSDK(trace)
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].StartTime.Before(result[j].StartTime)
+	})
+	return result, nil
+}
+
+// listPage returns the items in [Offset, Offset+Limit) of a page-numbered
+// list endpoint. Pages are aligned to the limit, so an offset that is not a
+// multiple of it spans two pages; the rows before the offset are dropped.
+func listPage[T any](cfg *llmops.ListOptions, list func(limit, page int) ([]T, error)) ([]T, error) {
+	if cfg.Limit <= 0 {
+		return list(cfg.Limit, 1)
+	}
+
+	page := cfg.Offset/cfg.Limit + 1
+	skip := cfg.Offset % cfg.Limit
+	items, err := list(cfg.Limit, page)
+	if err != nil || skip == 0 {
+		return items, err
+	}
+	if len(items) <= skip {
+		return nil, nil
+	}
+	items = items[skip:]
+	if len(items)+skip < cfg.Limit {
+		// Last page
+		return items, nil
+	}
+
+	next, err := list(cfg.Limit, page+1)
+	if err != nil {
+		return nil, err
+	}
+	items = append(items, next...)
+	return items[:min(len(items), cfg.Limit)], nil
+}
+
+// traceInfoFromSDK converts a Langfuse trace into an llmops.TraceInfo,
+// summing token usage over the trace's generations.
+func traceInfoFromSDK(t *sdk.TraceInfo) *llmops.TraceInfo {
+	info := &llmops.TraceInfo{
+		ID:        t.ID,
+		Name:      t.Name,
+		ThreadID:  t.SessionID,
+		StartTime: t.Timestamp,
+		Latency:   time.Duration(t.Latency * float64(time.Second)),
+		Input:     t.Input,
+		Output:    t.Output,
+		Metadata:  t.Metadata,
+		Tags:      t.Tags,
+	}
+
+	usage := &llmops.TokenUsage{
+		TotalCost: t.TotalCost,
+		Currency:  "USD",
+	}
+	for _, obs := range t.Observations {
+		if obs.Usage == nil {
+			continue
+		}
+		prompt := obs.Usage.PromptTokens
+		if prompt == 0 {
+			prompt = obs.Usage.Input
+		}
+		completion := obs.Usage.CompletionTokens
+		if completion == 0 {
+			completion = obs.Usage.Output
+		}
+		total := obs.Usage.TotalTokens
+		if total == 0 {
+			total = obs.Usage.Total
+		}
+		if total == 0 {
+			total = prompt + completion
+		}
+		usage.PromptTokens += prompt
+		usage.CompletionTokens += completion
+		usage.TotalTokens += total
+		usage.PromptCost += obs.Usage.InputCost
+		usage.CompletionCost += obs.Usage.OutputCost
+	}
+	info.Usage = usage
+
+	if info.Latency > 0 {
+		endTime := info.StartTime.Add(info.Latency)
+		info.EndTime = &endTime
+	}
+
+	for _, s := range t.Scores {
+		info.Feedback = append(info.Feedback, feedbackFromScore(s))
+	}
+
+	return info
+}
@@ -0,0 +1,132 @@
+package langfuse
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agentplexus/omniobserve/llmops"
+	sdk "github.com/agentplexus/omniobserve/sdk/langfuse"
+)
+
+func TestListPage(t *testing.T) {
+	items := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	tests := []struct {
+		name   string
+		limit  int
+		offset int
+		want   []int
+		pages  []int
+	}{
+		{"first page", 3, 0, []int{0, 1, 2}, []int{1}},
+		{"aligned offset", 3, 3, []int{3, 4, 5}, []int{2}},
+		{"unaligned offset spans two pages", 3, 4, []int{4, 5, 6}, []int{2, 3}},
+		{"unaligned offset near the end", 3, 8, []int{8, 9}, []int{3, 4}},
+		{"unaligned offset on the last page", 4, 9, []int{9}, []int{3}},
+		{"offset past the end", 3, 10, nil, []int{4}},
+		{"no limit", 0, 0, items, []int{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pages []int
+			got, err := listPage(&llmops.ListOptions{Limit: tt.limit, Offset: tt.offset}, func(limit, page int) ([]int, error) {
+				pages = append(pages, page)
+				if limit <= 0 {
+					return items, nil
+				}
+				start := min((page-1)*limit, len(items))
+				return items[start:min(start+limit, len(items))], nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) || !reflect.DeepEqual(pages, tt.pages) {
+				t.Errorf("expected %v from pages %v, got %v from pages %v", tt.want, tt.pages, got, pages)
+			}
+		})
+	}
+}
+
+func TestListThreadTraces(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	api := newFakeAPI(t)
+	for i, id := range []string{"t1", "t2", "t3", "t4"} {
+		api.traces = append(api.traces, sdk.TraceInfo{
+			ID:        id,
+			SessionID: "s1",
+			// Listed in a different order from their start times
+			Timestamp: base.Add(time.Duration(4-i) * time.Minute),
+			Observations: []sdk.Observation{
+				{ID: id + "-a", Usage: &sdk.Usage{Input: 10, Output: 5}},
+				{ID: id + "-b", Usage: &sdk.Usage{PromptTokens: 1, CompletionTokens: 2, TotalTokens: 3}},
+			},
+			Scores: []sdk.Score{{Name: "helpfulness", Value: 1}},
+		})
+	}
+	p := newTestProvider(t, api.Server)
+
+	traces, err := p.ListThreadTraces(context.Background(), "s1", llmops.WithLimit(2), llmops.WithOffset(1))
+	if err != nil {
+		t.Fatalf("ListThreadTraces: %v", err)
+	}
+
+	var ids []string
+	for _, trace := range traces {
+		ids = append(ids, trace.ID)
+	}
+	if want := []string{"t3", "t2"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("expected traces %v in order of start time, got %v", want, ids)
+	}
+	for _, trace := range traces {
+		if trace.Usage.PromptTokens != 11 || trace.Usage.CompletionTokens != 7 || trace.Usage.TotalTokens != 18 {
+			t.Errorf("expected usage summed over observations, got %+v", trace.Usage)
+		}
+		if len(trace.Feedback) != 1 || trace.Feedback[0].Name != "helpfulness" {
+			t.Errorf("expected the trace's scores as feedback, got %+v", trace.Feedback)
+		}
+	}
+}
+
+// countingTransport counts the requests it sends.
+type countingTransport struct {
+	requests atomic.Int32
+}
+
+func (c *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.requests.Add(1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestFetchTraces_StopsAfterFirstError(t *testing.T) {
+	// The first trace fails; the others wait until their request is cancelled
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/public/traces/t0" {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	t.Cleanup(server.Close)
+	transport := &countingTransport{}
+	p := newTestProvider(t, server, sdk.WithHTTPClient(&http.Client{Transport: transport}))
+
+	summaries := make([]sdk.TraceInfo, 3*fetchTraceConcurrency)
+	for i := range summaries {
+		summaries[i].ID = fmt.Sprintf("t%d", i)
+	}
+
+	if _, err := p.fetchTraces(context.Background(), summaries); err == nil {
+		t.Fatal("expected the failed trace to fail the fetch")
+	}
+	if got := transport.requests.Load(); got > fetchTraceConcurrency {
+		t.Errorf("expected no requests after the first error, got %d for %d traces", got, len(summaries))
+	}
+}
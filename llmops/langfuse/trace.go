@@ -94,7 +94,11 @@ func (t *traceAdapter) AddTag(tag string) error {
 }
 
 func (t *traceAdapter) AddFeedbackScore(ctx context.Context, name string, score float64, opts ...llmops.FeedbackOption) error {
-	return t.trace.Score(ctx, name, score)
+	value, sdkOpts, err := scoreOptions(feedbackScore(name, score, opts...))
+	if err != nil {
+		return err
+	}
+	return t.trace.Score(ctx, name, value, sdkOpts...)
 }
 
 func (t *traceAdapter) End(opts ...llmops.EndOption) error {
@@ -187,7 +191,11 @@ func (s *spanAdapter) AddTag(tag string) error {
 }
 
 func (s *spanAdapter) AddFeedbackScore(ctx context.Context, name string, score float64, opts ...llmops.FeedbackOption) error {
-	return s.span.Score(ctx, name, score)
+	value, sdkOpts, err := scoreOptions(feedbackScore(name, score, opts...))
+	if err != nil {
+		return err
+	}
+	return s.span.Score(ctx, name, value, sdkOpts...)
 }
 
 func (s *spanAdapter) End(opts ...llmops.EndOption) error {
@@ -279,7 +287,11 @@ func (g *generationAdapter) AddTag(tag string) error {
 }
 
 func (g *generationAdapter) AddFeedbackScore(ctx context.Context, name string, score float64, opts ...llmops.FeedbackOption) error {
-	return g.gen.Score(ctx, name, score)
+	value, sdkOpts, err := scoreOptions(feedbackScore(name, score, opts...))
+	if err != nil {
+		return err
+	}
+	return g.gen.Score(ctx, name, value, sdkOpts...)
 }
 
 func (g *generationAdapter) End(opts ...llmops.EndOption) error {
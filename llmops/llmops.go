@@ -118,6 +118,8 @@ type AnnotationManager interface {
 type ListAnnotationsOptions struct {
 	SpanIDs  []string // List annotations for these span IDs
 	TraceIDs []string // List annotations for these trace IDs
+	Limit    int      // Maximum number of annotations to return (0 for all)
+	Offset   int      // Number of annotations to skip
 }
 
 // CapabilityChecker allows querying provider capabilities.
@@ -143,4 +145,6 @@ const (
 	CapabilityDistributed  Capability = "distributed_tracing"
 	CapabilityCostTracking Capability = "cost_tracking"
 	CapabilityOTel         Capability = "opentelemetry"
+	CapabilitySessions     Capability = "sessions"
+	CapabilityMedia        Capability = "media"
 )
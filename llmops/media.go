@@ -0,0 +1,273 @@
+package llmops
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Media fields, identifying where an attachment is referenced.
+const (
+	MediaFieldInput    = "input"
+	MediaFieldOutput   = "output"
+	MediaFieldMetadata = "metadata"
+)
+
+// Media is binary content, such as an image or audio clip, attached to a
+// trace or span instead of being embedded in its payload.
+type Media struct {
+	ContentType string    // MIME type, e.g. "image/png"
+	Data        []byte    // Content; if nil, Reader is read instead
+	Reader      io.Reader // Alternative to Data
+	Field       string    // Where the media is referenced: input (default), output, or metadata
+}
+
+// Bytes returns the media content, reading Reader if Data is nil.
+func (m Media) Bytes() ([]byte, error) {
+	if m.Data != nil || m.Reader == nil {
+		return m.Data, nil
+	}
+	return io.ReadAll(m.Reader)
+}
+
+// MediaReference identifies stored media. Embed Token in span input,
+// output, or metadata in place of the content.
+type MediaReference struct {
+	ID          string `json:"id"`
+	ContentType string `json:"content_type"`
+	Size        int    `json:"size"`
+	URI         string `json:"uri,omitempty"` // Location of the content, if known
+	Token       string `json:"token"`
+}
+
+// String returns the reference token.
+func (r *MediaReference) String() string {
+	return r.Token
+}
+
+// MediaAttacher is implemented by traces and spans whose provider can
+// store media natively.
+//
+// MediaAttacher is optional. Use AttachMedia, which falls back to the
+// default BlobStore for providers without native support.
+type MediaAttacher interface {
+	// AttachMedia stores media and returns a reference to it.
+	AttachMedia(ctx context.Context, media Media) (*MediaReference, error)
+}
+
+// BlobStore stores media outside of trace payloads.
+type BlobStore interface {
+	// Put stores the content and returns a reference to it.
+	Put(ctx context.Context, contentType string, data []byte) (*MediaReference, error)
+
+	// Get returns the content and content type of stored media.
+	Get(ctx context.Context, id string) ([]byte, string, error)
+}
+
+// MediaTarget is a trace or span that media can be attached to. Trace and
+// Span both implement it.
+type MediaTarget interface {
+	ID() string
+	Name() string
+}
+
+// Ensure traces and spans are media targets at compile time.
+var (
+	_ MediaTarget = Trace(nil)
+	_ MediaTarget = Span(nil)
+)
+
+// AttachMedia stores media for a trace or span and returns a reference whose
+// Token can be embedded in the input or output. If the target implements
+// MediaAttacher, the provider stores the media; otherwise it is written to
+// the default BlobStore.
+func AttachMedia(ctx context.Context, target MediaTarget, media Media) (*MediaReference, error) {
+	if media.ContentType == "" {
+		return nil, fmt.Errorf("%w: media requires a content type", ErrInvalidInput)
+	}
+	if attacher, ok := target.(MediaAttacher); ok {
+		return attacher.AttachMedia(ctx, media)
+	}
+
+	data, err := media.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("read media: %w", err)
+	}
+	return DefaultBlobStore().Put(ctx, media.ContentType, data)
+}
+
+// ParseDataURI parses a base64 data URI, such as "data:image/png;base64,...",
+// into media.
+func ParseDataURI(uri string) (Media, error) {
+	rest, ok := strings.CutPrefix(uri, "data:")
+	if !ok {
+		return Media{}, fmt.Errorf("%w: not a data URI", ErrInvalidInput)
+	}
+	header, payload, ok := strings.Cut(rest, ",")
+	if !ok {
+		return Media{}, fmt.Errorf("%w: malformed data URI", ErrInvalidInput)
+	}
+	contentType, ok := strings.CutSuffix(header, ";base64")
+	if !ok {
+		return Media{}, fmt.Errorf("%w: data URI is not base64 encoded", ErrInvalidInput)
+	}
+	if contentType == "" {
+		contentType = "text/plain"
+	}
+
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return Media{}, fmt.Errorf("%w: decode data URI: %v", ErrInvalidInput, err)
+	}
+	return Media{ContentType: contentType, Data: data}, nil
+}
+
+// MediaToken returns the reference token for locally stored media.
+func MediaToken(contentType, id, uri string) string {
+	return fmt.Sprintf("@@@omniobserveMedia:type=%s|id=%s|uri=%s@@@", contentType, id, uri)
+}
+
+// FileBlobStore stores media as content-addressed files in a directory.
+// Each item is stored as <id>.blob, with its content type in <id>.type.
+type FileBlobStore struct {
+	Dir string
+}
+
+// File extensions of stored media and their content types.
+const (
+	blobFileExt = ".blob"
+	typeFileExt = ".type"
+)
+
+// NewFileBlobStore creates a file blob store, creating dir if needed.
+func NewFileBlobStore(dir string) (*FileBlobStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create blob store: %w", err)
+	}
+	return &FileBlobStore{Dir: dir}, nil
+}
+
+// Put writes the content to a file named by its SHA-256 hash.
+func (s *FileBlobStore) Put(ctx context.Context, contentType string, data []byte) (*MediaReference, error) {
+	sum := sha256.Sum256(data)
+	id := hex.EncodeToString(sum[:])
+	path := filepath.Join(s.Dir, id+blobFileExt)
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := os.MkdirAll(s.Dir, 0o700); err != nil {
+			return nil, fmt.Errorf("create blob store: %w", err)
+		}
+		// The content type is written first, so a blob always has one
+		if err := writeFileAtomic(filepath.Join(s.Dir, id+typeFileExt), []byte(contentType)); err != nil {
+			return nil, fmt.Errorf("write media: %w", err)
+		}
+		if err := writeFileAtomic(path, data); err != nil {
+			return nil, fmt.Errorf("write media: %w", err)
+		}
+	}
+
+	uri := (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+	return &MediaReference{
+		ID:          id,
+		ContentType: contentType,
+		Size:        len(data),
+		URI:         uri,
+		Token:       MediaToken(contentType, id, uri),
+	}, nil
+}
+
+// Get reads stored content by ID. IDs are the hex SHA-256 hashes returned
+// by Put; any other ID is rejected with ErrInvalidInput.
+func (s *FileBlobStore) Get(ctx context.Context, id string) ([]byte, string, error) {
+	if !isMediaID(id) {
+		return nil, "", fmt.Errorf("%w: invalid media ID %q", ErrInvalidInput, id)
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.Dir, id+blobFileExt))
+	if err != nil {
+		return nil, "", err
+	}
+	contentType, err := os.ReadFile(filepath.Join(s.Dir, id+typeFileExt))
+	if err != nil {
+		return nil, "", err
+	}
+	return data, string(contentType), nil
+}
+
+// isMediaID reports whether id is a lowercase hex SHA-256 hash, as
+// generated by FileBlobStore.Put.
+func isMediaID(id string) bool {
+	if len(id) != 2*sha256.Size {
+		return false
+	}
+	for _, c := range id {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// writeFileAtomic writes data to a uniquely named temporary file in the
+// same directory and renames it to path, so concurrent writers of the same
+// path never share a temporary file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+var (
+	blobStoreMu      sync.RWMutex
+	defaultBlobStore BlobStore
+)
+
+// SetDefaultBlobStore sets the store used by AttachMedia for providers
+// without native media support.
+func SetDefaultBlobStore(store BlobStore) {
+	blobStoreMu.Lock()
+	defer blobStoreMu.Unlock()
+	defaultBlobStore = store
+}
+
+// DefaultBlobStore returns the store used by AttachMedia for providers
+// without native media support. Unless set, media is stored under
+// "omniobserve-media" in the system temporary directory.
+func DefaultBlobStore() BlobStore {
+	blobStoreMu.RLock()
+	store := defaultBlobStore
+	blobStoreMu.RUnlock()
+	if store != nil {
+		return store
+	}
+
+	blobStoreMu.Lock()
+	defer blobStoreMu.Unlock()
+	if defaultBlobStore == nil {
+		defaultBlobStore = &FileBlobStore{Dir: filepath.Join(os.TempDir(), "omniobserve-media")}
+	}
+	return defaultBlobStore
+}
@@ -0,0 +1,129 @@
+package llmops
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestFileBlobStore_PutGet(t *testing.T) {
+	store, err := NewFileBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	ref, err := store.Put(ctx, "image/png", []byte("png data"))
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	data, contentType, err := store.Get(ctx, ref.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if string(data) != "png data" || contentType != "image/png" {
+		t.Errorf("expected png data with image/png, got %q with %q", data, contentType)
+	}
+}
+
+func TestFileBlobStore_ConcurrentPut(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileBlobStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	data := bytes.Repeat([]byte("png data "), 1<<16)
+
+	// Release all writers at once, so that they all find the blob missing
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	errs := make(chan error, 64)
+	for range 64 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			if _, err := store.Put(ctx, "image/png", data); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("Put: %v", err)
+	}
+
+	ref, err := store.Put(ctx, "image/png", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, contentType, err := store.Get(ctx, ref.ID)
+	if err != nil || !bytes.Equal(got, data) || contentType != "image/png" {
+		t.Errorf("expected the stored content back, got %d bytes, %q, %v", len(got), contentType, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("expected only the blob and its content type, with no temporary files left, got %d entries", len(entries))
+	}
+}
+
+func TestWriteFileAtomic_KeepsOtherWritersTempFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blob.bin")
+	// A temporary file named as another writer in progress would name it
+	inFlight := path + ".tmp"
+	if err := os.WriteFile(inFlight, []byte("other writer"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeFileAtomic(path, []byte("data")); err != nil {
+		t.Fatalf("writeFileAtomic: %v", err)
+	}
+	if got, err := os.ReadFile(path); err != nil || string(got) != "data" {
+		t.Errorf("expected %q at the path, got %q, %v", "data", got, err)
+	}
+	if got, err := os.ReadFile(inFlight); err != nil || string(got) != "other writer" {
+		t.Errorf("expected the other writer's temporary file to be untouched, got %q, %v", got, err)
+	}
+}
+
+func TestFileBlobStore_RejectsInvalidIDs(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileBlobStore(filepath.Join(dir, "media"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "secret"), []byte("secret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ref, err := store.Put(context.Background(), "text/plain", []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"", "../secret", "*", "[", ref.ID[:10], ref.ID + "0", "../" + ref.ID[3:]} {
+		if _, _, err := store.Get(context.Background(), id); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("Get(%q): expected ErrInvalidInput, got %v", id, err)
+		}
+	}
+}
+
+func TestFileBlobStore_Missing(t *testing.T) {
+	store, err := NewFileBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := "0000000000000000000000000000000000000000000000000000000000000000"
+	if _, _, err := store.Get(context.Background(), id); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected not exist error, got %v", err)
+	}
+}
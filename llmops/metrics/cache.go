@@ -0,0 +1,259 @@
+package metrics
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/agentplexus/omnillm"
+	"github.com/agentplexus/omnillm/provider"
+)
+
+// ErrCacheMiss indicates that a request has no recorded response in replay
+// mode.
+var ErrCacheMiss = errors.New("metrics: no recorded response for request")
+
+// Cache stores judge responses by request key. Implementations must be safe
+// for concurrent use.
+type Cache interface {
+	// Get returns the value stored under key, reporting whether it exists.
+	Get(key string) ([]byte, bool, error)
+
+	// Set stores a value under key.
+	Set(key string, value []byte) error
+}
+
+// CacheMode controls how an LLM uses its cache.
+type CacheMode string
+
+const (
+	// CacheReadWrite returns cached responses and records responses for
+	// requests that are not cached. This is the default.
+	CacheReadWrite CacheMode = "readwrite"
+
+	// CacheRecord always calls the judge and records its responses,
+	// replacing any cached ones.
+	CacheRecord CacheMode = "record"
+
+	// CacheReplay only returns cached responses and never calls the judge.
+	// Requests that are not cached fail with ErrCacheMiss.
+	CacheReplay CacheMode = "replay"
+)
+
+// ParseCacheMode parses a cache mode, for example from an environment
+// variable. The empty string is CacheReadWrite.
+func ParseCacheMode(s string) (CacheMode, error) {
+	switch CacheMode(s) {
+	case "", CacheReadWrite:
+		return CacheReadWrite, nil
+	case CacheRecord, CacheReplay:
+		return CacheMode(s), nil
+	default:
+		return "", fmt.Errorf("unknown cache mode %q", s)
+	}
+}
+
+// LLMOption configures the LLM.
+type LLMOption func(*LLM)
+
+// WithCache caches judge responses, keyed by the full request: model,
+// prompt, and tools, including classification labels.
+func WithCache(cache Cache) LLMOption {
+	return func(l *LLM) {
+		l.cache = cache
+	}
+}
+
+// WithCacheMode sets how the cache is used. Default is CacheReadWrite.
+func WithCacheMode(mode CacheMode) LLMOption {
+	return func(l *LLM) {
+		l.cacheMode = mode
+	}
+}
+
+// NewLLMWithOptions creates a new LLM wrapper with options. The client may
+// be nil in CacheReplay mode, for example to run tests offline against
+// recorded judgments:
+//
+//	llm := NewLLMWithOptions(nil, "gpt-4o-mini",
+//	    WithCache(NewFileCache("testdata/judgments")),
+//	    WithCacheMode(CacheReplay),
+//	)
+func NewLLMWithOptions(client *omnillm.ChatClient, model string, opts ...LLMOption) *LLM {
+	l := NewLLM(client, model)
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// judgmentEntry is a recorded response. The model and prompt are kept so
+// recordings can be reviewed.
+type judgmentEntry struct {
+	Model    string                           `json:"model"`
+	Prompt   string                           `json:"prompt"`
+	Tool     string                           `json:"tool,omitempty"`
+	Response *provider.ChatCompletionResponse `json:"response"`
+}
+
+// complete sends a chat completion request, using the cache if one is set.
+func (l *LLM) complete(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
+	if l.cache == nil {
+		return l.client.CreateChatCompletion(ctx, req)
+	}
+
+	key, err := cacheKey(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if l.cacheMode != CacheRecord {
+		data, ok, err := l.cache.Get(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cache: %w", err)
+		}
+		if ok {
+			var entry judgmentEntry
+			if err := json.Unmarshal(data, &entry); err != nil {
+				return nil, fmt.Errorf("failed to decode cached response %s: %w", key, err)
+			}
+			return entry.Response, nil
+		}
+		if l.cacheMode == CacheReplay {
+			return nil, fmt.Errorf("%w %s", ErrCacheMiss, key)
+		}
+	}
+
+	if l.client == nil {
+		return nil, fmt.Errorf("no client to send request %s", key)
+	}
+	resp, err := l.client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := judgmentEntry{Model: req.Model, Response: resp}
+	if len(req.Messages) > 0 {
+		entry.Prompt = req.Messages[len(req.Messages)-1].Content
+	}
+	if len(req.Tools) > 0 {
+		entry.Tool = req.Tools[0].Function.Name
+	}
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode response: %w", err)
+	}
+	if err := l.cache.Set(key, data); err != nil {
+		return nil, fmt.Errorf("failed to write cache: %w", err)
+	}
+	return resp, nil
+}
+
+// cacheKey returns the SHA-256 of the JSON-encoded request. Map keys are
+// sorted by encoding/json, so equal requests have equal keys.
+func cacheKey(req *provider.ChatCompletionRequest) (string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// =============================================================================
+// Cache Stores
+// =============================================================================
+
+// MemoryCache is an in-memory Cache.
+type MemoryCache struct {
+	mu      sync.RWMutex
+	entries map[string][]byte
+}
+
+// NewMemoryCache creates a new in-memory cache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string][]byte)}
+}
+
+// Get returns the value stored under key.
+func (c *MemoryCache) Get(key string) ([]byte, bool, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	value, ok := c.entries[key]
+	return value, ok, nil
+}
+
+// Set stores a value under key.
+func (c *MemoryCache) Set(key string, value []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = value
+	return nil
+}
+
+// Len returns the number of cached entries.
+func (c *MemoryCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.entries)
+}
+
+// FileCache is a Cache storing each entry as a JSON file in a directory,
+// suitable for checking recorded judgments into version control.
+type FileCache struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileCache creates a file cache in dir. The directory is created when
+// the first entry is written.
+func NewFileCache(dir string) *FileCache {
+	return &FileCache{dir: dir}
+}
+
+// Get returns the value stored under key.
+func (c *FileCache) Get(key string) ([]byte, bool, error) {
+	data, err := os.ReadFile(c.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// Set stores a value under key, writing it atomically.
+func (c *FileCache) Set(key string, value []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(value); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path(key))
+}
+
+// path returns the file path of a key.
+func (c *FileCache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
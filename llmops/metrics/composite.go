@@ -0,0 +1,382 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/agentplexus/omniobserve/llmops"
+)
+
+// Compile-time interface checks.
+var (
+	_ llmops.Metric     = (*CompositeMetric)(nil)
+	_ llmops.Metric     = (*ThresholdMetric)(nil)
+	_ DirectionalMetric = (*HallucinationMetric)(nil)
+	_ DirectionalMetric = (*ToxicityMetric)(nil)
+	_ DirectionalMetric = (*ThresholdMetric)(nil)
+)
+
+// Direction tells whether higher or lower scores of a metric are better.
+type Direction string
+
+const (
+	HigherIsBetter Direction = "higher_is_better"
+	LowerIsBetter  Direction = "lower_is_better"
+)
+
+// DirectionalMetric is a metric that reports its direction. Metrics that
+// do not implement it are assumed to be HigherIsBetter.
+type DirectionalMetric interface {
+	llmops.Metric
+	Direction() Direction
+}
+
+// MetricDirection returns the direction of a metric.
+func MetricDirection(m llmops.Metric) Direction {
+	if d, ok := m.(DirectionalMetric); ok {
+		return d.Direction()
+	}
+	return HigherIsBetter
+}
+
+// Direction returns LowerIsBetter, as 1.0 means the output is hallucinated.
+func (m *HallucinationMetric) Direction() Direction {
+	return LowerIsBetter
+}
+
+// Direction returns LowerIsBetter, as 1.0 means the output is toxic.
+func (m *ToxicityMetric) Direction() Direction {
+	return LowerIsBetter
+}
+
+// =============================================================================
+// Composite Metrics
+// =============================================================================
+
+// CompositeMode selects how a CompositeMetric combines its components.
+type CompositeMode string
+
+const (
+	CompositeWeightedMean CompositeMode = "weighted_mean"
+	CompositeMin          CompositeMode = "min"
+	CompositeMax          CompositeMode = "max"
+)
+
+// WeightedMetric is a component of a CompositeMetric.
+type WeightedMetric struct {
+	Metric llmops.Metric
+	Weight float64
+}
+
+// Weighted returns m as a component with the given weight.
+func Weighted(m llmops.Metric, weight float64) WeightedMetric {
+	return WeightedMetric{Metric: m, Weight: weight}
+}
+
+// CompositeMetric combines the scores of several metrics into one.
+// Scores of LowerIsBetter components are inverted (1 - score) before they
+// are combined, so the composite is always HigherIsBetter. If a component
+// fails, the composite fails. Component names must be unique, as the
+// metadata reports component scores by name.
+type CompositeMetric struct {
+	name       string
+	mode       CompositeMode
+	components []WeightedMetric
+}
+
+// NewWeightedMeanMetric creates a metric scoring the weighted mean of its
+// components.
+func NewWeightedMeanMetric(name string, components ...WeightedMetric) *CompositeMetric {
+	return &CompositeMetric{
+		name:       name,
+		mode:       CompositeWeightedMean,
+		components: components,
+	}
+}
+
+// NewMinMetric creates a metric scoring the lowest of its components, so
+// every component must score well.
+func NewMinMetric(name string, metrics ...llmops.Metric) *CompositeMetric {
+	return newUnweightedComposite(name, CompositeMin, metrics)
+}
+
+// NewMaxMetric creates a metric scoring the highest of its components, so
+// any component scoring well is enough.
+func NewMaxMetric(name string, metrics ...llmops.Metric) *CompositeMetric {
+	return newUnweightedComposite(name, CompositeMax, metrics)
+}
+
+func newUnweightedComposite(name string, mode CompositeMode, metrics []llmops.Metric) *CompositeMetric {
+	components := make([]WeightedMetric, len(metrics))
+	for i, m := range metrics {
+		components[i] = Weighted(m, 1.0)
+	}
+	return &CompositeMetric{
+		name:       name,
+		mode:       mode,
+		components: components,
+	}
+}
+
+// Name returns the metric name.
+func (m *CompositeMetric) Name() string {
+	return m.name
+}
+
+// Evaluate runs the components and combines their scores. The metadata
+// contains the component scores by name, as reported by each metric.
+func (m *CompositeMetric) Evaluate(input llmops.EvalInput) (llmops.MetricScore, error) {
+	if len(m.components) == 0 {
+		return failedScore(m.Name(), fmt.Errorf("composite metric has no components"))
+	}
+	seen := make(map[string]bool, len(m.components))
+	for _, c := range m.components {
+		name := c.Metric.Name()
+		if seen[name] {
+			return failedScore(m.Name(), fmt.Errorf("composite metric has duplicate component %s", name))
+		}
+		seen[name] = true
+	}
+
+	componentScores := make(map[string]float64, len(m.components))
+	var (
+		total, totalWeight float64
+		best, worst        string
+	)
+	score := 0.0
+	for i, c := range m.components {
+		s, err := c.Metric.Evaluate(input)
+		if err != nil {
+			return failedScore(m.Name(), fmt.Errorf("component %s failed: %w", c.Metric.Name(), err))
+		}
+		componentScores[c.Metric.Name()] = s.Score
+
+		v := s.Score
+		if MetricDirection(c.Metric) == LowerIsBetter {
+			v = 1 - v
+		}
+
+		switch m.mode {
+		case CompositeMin:
+			if i == 0 || v < score {
+				score, worst = v, c.Metric.Name()
+			}
+		case CompositeMax:
+			if i == 0 || v > score {
+				score, best = v, c.Metric.Name()
+			}
+		default:
+			if c.Weight < 0 {
+				return failedScore(m.Name(), fmt.Errorf("component %s has negative weight %g", c.Metric.Name(), c.Weight))
+			}
+			total += c.Weight * v
+			totalWeight += c.Weight
+		}
+	}
+
+	var reason string
+	switch m.mode {
+	case CompositeMin:
+		reason = fmt.Sprintf("Lowest component is %s (%.3f)", worst, score)
+	case CompositeMax:
+		reason = fmt.Sprintf("Highest component is %s (%.3f)", best, score)
+	default:
+		if totalWeight == 0 {
+			return failedScore(m.Name(), fmt.Errorf("composite metric weights sum to zero"))
+		}
+		score = total / totalWeight
+		reason = fmt.Sprintf("Weighted mean of %d components", len(m.components))
+	}
+
+	return llmops.MetricScore{
+		Name:   m.Name(),
+		Score:  score,
+		Reason: reason,
+		Metadata: map[string]any{
+			"mode":       string(m.mode),
+			"components": componentScores,
+		},
+	}, nil
+}
+
+// =============================================================================
+// Threshold Metrics
+// =============================================================================
+
+// ThresholdMetric wraps a metric with a pass/fail threshold. The score is
+// passed through unchanged, and the metadata records "passed", "threshold",
+// and "direction" for SummarizeResult. A HigherIsBetter score passes at or
+// above the threshold; a LowerIsBetter score passes at or below it.
+type ThresholdMetric struct {
+	metric    llmops.Metric
+	threshold float64
+	direction Direction
+}
+
+// ThresholdOption configures the ThresholdMetric.
+type ThresholdOption func(*ThresholdMetric)
+
+// WithDirection overrides the direction of the wrapped metric.
+func WithDirection(direction Direction) ThresholdOption {
+	return func(m *ThresholdMetric) {
+		m.direction = direction
+	}
+}
+
+// NewThresholdMetric wraps a metric with a threshold. The direction
+// defaults to that of the metric, so
+//
+//	NewThresholdMetric(NewHallucinationMetric(llm), 0.5)
+//
+// passes when the hallucination score is at most 0.5.
+func NewThresholdMetric(metric llmops.Metric, threshold float64, opts ...ThresholdOption) *ThresholdMetric {
+	m := &ThresholdMetric{
+		metric:    metric,
+		threshold: threshold,
+		direction: MetricDirection(metric),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Name returns the name of the wrapped metric.
+func (m *ThresholdMetric) Name() string {
+	return m.metric.Name()
+}
+
+// Direction returns the direction the threshold is applied in.
+func (m *ThresholdMetric) Direction() Direction {
+	return m.direction
+}
+
+// Passes reports whether a score meets the threshold.
+func (m *ThresholdMetric) Passes(score float64) bool {
+	if m.direction == LowerIsBetter {
+		return score <= m.threshold
+	}
+	return score >= m.threshold
+}
+
+// Evaluate runs the wrapped metric and checks its score against the
+// threshold. The wrapped metric's metadata is kept if it is a map; a
+// failed evaluation does not pass.
+func (m *ThresholdMetric) Evaluate(input llmops.EvalInput) (llmops.MetricScore, error) {
+	score, err := m.metric.Evaluate(input)
+	if score.Name == "" {
+		score.Name = m.Name()
+	}
+	if err != nil && score.Error == "" {
+		score.Error = err.Error()
+	}
+
+	passed := err == nil && m.Passes(score.Score)
+	metadata := map[string]any{}
+	switch md := score.Metadata.(type) {
+	case map[string]any:
+		for k, v := range md {
+			metadata[k] = v
+		}
+	case nil:
+	default:
+		metadata["details"] = md
+	}
+	metadata["passed"] = passed
+	metadata["threshold"] = m.threshold
+	metadata["direction"] = string(m.direction)
+	score.Metadata = metadata
+
+	return score, err
+}
+
+// =============================================================================
+// Result Summary
+// =============================================================================
+
+// ErrThresholdFailed indicates that an evaluation did not meet its thresholds.
+var ErrThresholdFailed = errors.New("metrics: evaluation did not meet thresholds")
+
+// ThresholdFailure is a score that did not meet its threshold or failed to
+// evaluate.
+type ThresholdFailure struct {
+	Name      string    `json:"name"`
+	Score     float64   `json:"score"`
+	Threshold float64   `json:"threshold,omitempty"`
+	Direction Direction `json:"direction,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// EvalSummary is the pass/fail outcome of an evaluation.
+type EvalSummary struct {
+	// Passed is true if every gated score passed and no score failed to
+	// evaluate.
+	Passed bool `json:"passed"`
+
+	// Total is the number of scores, and Gated the number with a threshold.
+	Total int `json:"total"`
+	Gated int `json:"gated"`
+
+	// Errors counts scores that failed to evaluate, gated or not.
+	Errors int `json:"errors"`
+
+	Failures []ThresholdFailure `json:"failures,omitempty"`
+}
+
+// Err returns an error wrapping ErrThresholdFailed and listing the failed
+// scores, or nil if the evaluation passed.
+func (s EvalSummary) Err() error {
+	if s.Passed {
+		return nil
+	}
+	names := make([]string, len(s.Failures))
+	for i, f := range s.Failures {
+		names[i] = f.Name
+	}
+	return fmt.Errorf("%w: %s", ErrThresholdFailed, strings.Join(names, ", "))
+}
+
+// SummarizeResult reports whether an evaluation passed. A score is gated if
+// its metadata records "passed", as ThresholdMetric and
+// SemanticSimilarityMetric do. Ungated scores do not affect the outcome,
+// but any score that failed to evaluate fails it, since a failed judge may
+// have dropped its threshold metadata (llmops.Evaluator implementations
+// replace the scores of metrics returning an error).
+func SummarizeResult(result *llmops.EvalResult) EvalSummary {
+	s := EvalSummary{Passed: true}
+	if result == nil {
+		return s
+	}
+
+	for _, score := range result.Scores {
+		s.Total++
+		if score.Error != "" {
+			s.Errors++
+		}
+
+		md, _ := score.Metadata.(map[string]any)
+		passed, gated := md["passed"].(bool)
+		if gated {
+			s.Gated++
+		}
+		if score.Error == "" && (!gated || passed) {
+			continue
+		}
+
+		s.Passed = false
+		failure := ThresholdFailure{
+			Name:  score.Name,
+			Score: score.Score,
+			Error: score.Error,
+		}
+		if t, ok := md["threshold"].(float64); ok {
+			failure.Threshold = t
+		}
+		if d, ok := md["direction"].(string); ok {
+			failure.Direction = Direction(d)
+		}
+		s.Failures = append(s.Failures, failure)
+	}
+	return s
+}
@@ -0,0 +1,557 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/agentplexus/omniobserve/llmops"
+)
+
+var _ DirectionalMetric = (*ConsensusMetric)(nil)
+
+// ConsensusAggregation selects how a ConsensusMetric combines judge scores.
+type ConsensusAggregation string
+
+const (
+	// ConsensusMajority takes the most common score. Ties go to the worse
+	// score for the metric's direction.
+	ConsensusMajority ConsensusAggregation = "majority"
+
+	// ConsensusMean takes the mean score.
+	ConsensusMean ConsensusAggregation = "mean"
+)
+
+// JudgeMetrics creates the same metric for each judge, for a
+// ConsensusMetric across models:
+//
+//	judges := JudgeMetrics(func(llm *LLM) llmops.Metric {
+//	    return NewHallucinationMetric(llm)
+//	}, gpt4o, claude, gemini)
+func JudgeMetrics(newMetric func(*LLM) llmops.Metric, llms ...*LLM) []llmops.Metric {
+	judges := make([]llmops.Metric, len(llms))
+	for i, llm := range llms {
+		judges[i] = newMetric(llm)
+	}
+	return judges
+}
+
+// Samples returns a metric n times, for a ConsensusMetric over n samples
+// of one judge. The samples send identical requests with the provider's
+// default temperature, so they only differ if that temperature is non-zero.
+// They also share a cache key, so a judge using WithCache returns the first
+// sample's response for every sample. Sample with an uncached LLM whose
+// provider samples at a non-zero temperature.
+func Samples(m llmops.Metric, n int) []llmops.Metric {
+	judges := make([]llmops.Metric, n)
+	for i := range judges {
+		judges[i] = m
+	}
+	return judges
+}
+
+// ConsensusMetric runs several judges of the same metric and aggregates
+// their scores. The metadata reports the judge scores, their variance,
+// and "agreement", the fraction of judges giving the most common score.
+//
+// Agreement across items, as Cohen's or Fleiss' kappa, is computed by
+// Agreement from the scores of a set of evaluations.
+type ConsensusMetric struct {
+	name        string
+	judges      []llmops.Metric
+	aggregation ConsensusAggregation
+}
+
+// ConsensusOption configures the ConsensusMetric.
+type ConsensusOption func(*ConsensusMetric)
+
+// WithAggregation sets how judge scores are combined. Default is
+// ConsensusMajority.
+func WithAggregation(aggregation ConsensusAggregation) ConsensusOption {
+	return func(m *ConsensusMetric) {
+		m.aggregation = aggregation
+	}
+}
+
+// WithConsensusName sets the metric name. Default is the name of the first
+// judge.
+func WithConsensusName(name string) ConsensusOption {
+	return func(m *ConsensusMetric) {
+		m.name = name
+	}
+}
+
+// NewConsensusMetric creates a metric aggregating the scores of judges.
+func NewConsensusMetric(judges []llmops.Metric, opts ...ConsensusOption) *ConsensusMetric {
+	m := &ConsensusMetric{
+		judges:      judges,
+		aggregation: ConsensusMajority,
+	}
+	if len(judges) > 0 {
+		m.name = judges[0].Name()
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Name returns the metric name.
+func (m *ConsensusMetric) Name() string {
+	return m.name
+}
+
+// Direction returns the direction of the judges.
+func (m *ConsensusMetric) Direction() Direction {
+	if len(m.judges) == 0 {
+		return HigherIsBetter
+	}
+	return MetricDirection(m.judges[0])
+}
+
+// Evaluate runs every judge and aggregates their scores. Failed judges are
+// left out of the consensus; the metric fails only if all judges fail.
+func (m *ConsensusMetric) Evaluate(input llmops.EvalInput) (llmops.MetricScore, error) {
+	if len(m.judges) == 0 {
+		return failedScore(m.Name(), fmt.Errorf("consensus metric has no judges"))
+	}
+
+	judgeScores := make([]float64, 0, len(m.judges))
+	reasons := make([]string, 0, len(m.judges))
+	judgeErrors := make(map[int]string)
+	var lastErr error
+	for i, judge := range m.judges {
+		s, err := judge.Evaluate(input)
+		if err != nil {
+			judgeErrors[i] = err.Error()
+			lastErr = err
+			continue
+		}
+		judgeScores = append(judgeScores, s.Score)
+		reasons = append(reasons, s.Reason)
+	}
+	if len(judgeScores) == 0 {
+		return failedScore(m.Name(), fmt.Errorf("all %d judges failed: %w", len(m.judges), lastErr))
+	}
+
+	// Agreement and the reason come from the judges giving the majority
+	// score, whatever the aggregation
+	majority := m.majority(judgeScores)
+	score := majority
+	if m.aggregation == ConsensusMean {
+		score = mean(judgeScores)
+	}
+
+	agreeing := 0
+	reason := ""
+	for i, s := range judgeScores {
+		if scoreCategory(s) == scoreCategory(majority) {
+			agreeing++
+			if reason == "" {
+				reason = reasons[i]
+			}
+		}
+	}
+
+	metadata := map[string]any{
+		"aggregation":  string(m.aggregation),
+		"judge_scores": judgeScores,
+		"mean":         mean(judgeScores),
+		"variance":     variance(judgeScores),
+		"agreement":    float64(agreeing) / float64(len(judgeScores)),
+	}
+	if len(judgeErrors) > 0 {
+		metadata["judge_errors"] = judgeErrors
+	}
+
+	return llmops.MetricScore{
+		Name:     m.Name(),
+		Score:    score,
+		Reason:   fmt.Sprintf("%d of %d judges agree: %s", agreeing, len(judgeScores), reason),
+		Metadata: metadata,
+	}, nil
+}
+
+// majority returns the most common score, breaking ties by direction.
+func (m *ConsensusMetric) majority(scores []float64) float64 {
+	counts := make(map[string]int)
+	for _, s := range scores {
+		counts[scoreCategory(s)]++
+	}
+
+	lowerIsBetter := m.Direction() == LowerIsBetter
+	best, bestCount := scores[0], 0
+	for _, s := range scores {
+		c := counts[scoreCategory(s)]
+		worse := s < best
+		if lowerIsBetter {
+			worse = s > best
+		}
+		if c > bestCount || (c == bestCount && worse) {
+			best, bestCount = s, c
+		}
+	}
+	return best
+}
+
+// AgreementStats is the agreement of judges across items.
+type AgreementStats struct {
+	// Items is the number of items all judges scored.
+	Items int `json:"items"`
+
+	// Kappa is Cohen's kappa for two judges or Fleiss' kappa for more.
+	// KappaType is "cohen" or "fleiss", or empty if kappa could not be
+	// computed.
+	Kappa     float64 `json:"kappa"`
+	KappaType string  `json:"kappa_type,omitempty"`
+}
+
+// Agreement returns the agreement of the judges across items, given the
+// scores this metric returned for them. Scores of items where a judge
+// failed are skipped.
+func (m *ConsensusMetric) Agreement(scores []llmops.MetricScore) AgreementStats {
+	var ratings [][]string
+	for _, score := range scores {
+		md, ok := score.Metadata.(map[string]any)
+		if !ok || md["judge_errors"] != nil {
+			continue
+		}
+		judgeScores, ok := md["judge_scores"].([]float64)
+		if !ok || len(judgeScores) != len(m.judges) {
+			continue
+		}
+		item := make([]string, len(judgeScores))
+		for i, s := range judgeScores {
+			item[i] = scoreCategory(s)
+		}
+		ratings = append(ratings, item)
+	}
+
+	stats := AgreementStats{Items: len(ratings)}
+	if len(ratings) == 0 {
+		return stats
+	}
+	if len(ratings[0]) == 2 {
+		a := make([]string, len(ratings))
+		b := make([]string, len(ratings))
+		for i, r := range ratings {
+			a[i], b[i] = r[0], r[1]
+		}
+		if k, err := CohenKappa(a, b); err == nil {
+			stats.Kappa, stats.KappaType = k, "cohen"
+		}
+		return stats
+	}
+	if k, err := FleissKappa(ratings); err == nil {
+		stats.Kappa, stats.KappaType = k, "fleiss"
+	}
+	return stats
+}
+
+// =============================================================================
+// Agreement Statistics
+// =============================================================================
+
+// CohenKappa returns Cohen's kappa for two raters labeling the same items.
+// If both raters use a single label for every item, agreement is perfect
+// and kappa is 1.0.
+func CohenKappa(a, b []string) (float64, error) {
+	if len(a) != len(b) {
+		return 0, fmt.Errorf("raters labeled %d and %d items", len(a), len(b))
+	}
+	if len(a) == 0 {
+		return 0, errors.New("no items to compare")
+	}
+
+	n := float64(len(a))
+	countsA := make(map[string]float64)
+	countsB := make(map[string]float64)
+	observed := 0.0
+	for i := range a {
+		countsA[a[i]]++
+		countsB[b[i]]++
+		if a[i] == b[i] {
+			observed++
+		}
+	}
+	observed /= n
+
+	expected := 0.0
+	for label, ca := range countsA {
+		expected += (ca / n) * (countsB[label] / n)
+	}
+	return kappa(observed, expected), nil
+}
+
+// FleissKappa returns Fleiss' kappa for a fixed number of raters labeling
+// the same items. ratings[i] holds the labels of item i. If every rating
+// uses the same label, agreement is perfect and kappa is 1.0.
+func FleissKappa(ratings [][]string) (float64, error) {
+	if len(ratings) == 0 {
+		return 0, errors.New("no items to compare")
+	}
+	raters := len(ratings[0])
+	if raters < 2 {
+		return 0, errors.New("at least two raters are required")
+	}
+
+	n := float64(raters)
+	totals := make(map[string]float64)
+	observed := 0.0
+	for i, item := range ratings {
+		if len(item) != raters {
+			return 0, fmt.Errorf("item %d has %d ratings, expected %d", i, len(item), raters)
+		}
+		counts := make(map[string]float64)
+		for _, label := range item {
+			counts[label]++
+			totals[label]++
+		}
+		agree := 0.0
+		for _, c := range counts {
+			agree += c * (c - 1)
+		}
+		observed += agree / (n * (n - 1))
+	}
+	observed /= float64(len(ratings))
+
+	expected := 0.0
+	all := n * float64(len(ratings))
+	for _, c := range totals {
+		expected += (c / all) * (c / all)
+	}
+	return kappa(observed, expected), nil
+}
+
+// kappa returns the chance-corrected agreement.
+func kappa(observed, expected float64) float64 {
+	if expected >= 1 {
+		return 1.0
+	}
+	return (observed - expected) / (1 - expected)
+}
+
+// scoreCategory returns a score as a label for agreement statistics.
+func scoreCategory(score float64) string {
+	return strconv.FormatFloat(score, 'g', 6, 64)
+}
+
+// mean returns the mean of values.
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// variance returns the population variance of values.
+func variance(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	mu := mean(values)
+	sum := 0.0
+	for _, v := range values {
+		sum += (v - mu) * (v - mu)
+	}
+	return sum / float64(len(values))
+}
+
+// =============================================================================
+// Calibration
+// =============================================================================
+
+// JudgedScore is a judge's score for a trace or span, to compare with human
+// annotations on it.
+type JudgedScore struct {
+	TraceID string
+	SpanID  string
+	Score   llmops.MetricScore
+}
+
+// CalibrationPair is a judge score matched with a human annotation.
+type CalibrationPair struct {
+	TraceID string  `json:"trace_id,omitempty"`
+	SpanID  string  `json:"span_id,omitempty"`
+	Judge   float64 `json:"judge"`
+	Human   float64 `json:"human"`
+}
+
+// CalibrationReport measures how well a judge agrees with human annotators.
+type CalibrationReport struct {
+	// Count is the number of judge scores matched with an annotation, and
+	// Unmatched the number without one.
+	Count     int `json:"count"`
+	Unmatched int `json:"unmatched"`
+
+	// Accuracy is the fraction of pairs where the judge and human values
+	// are equal, within the tolerance.
+	Accuracy float64 `json:"accuracy"`
+
+	// Kappa is Cohen's kappa between the judge and human values.
+	Kappa float64 `json:"kappa"`
+
+	MeanAbsoluteError float64 `json:"mean_absolute_error"`
+
+	// Correlation is the Pearson correlation, or 0 if either side has no
+	// variance.
+	Correlation float64 `json:"correlation"`
+
+	// Confusion counts pairs by human value, then judge value.
+	Confusion map[string]map[string]int `json:"confusion"`
+
+	Pairs []CalibrationPair `json:"pairs"`
+}
+
+type calibrationConfig struct {
+	annotationName string
+	labelValues    map[string]float64
+	tolerance      float64
+}
+
+// CalibrationOption configures Calibrate.
+type CalibrationOption func(*calibrationConfig)
+
+// WithAnnotationName sets the name of the annotations to compare with.
+// Default is the name of each judge score.
+func WithAnnotationName(name string) CalibrationOption {
+	return func(c *calibrationConfig) {
+		c.annotationName = name
+	}
+}
+
+// WithLabelValues maps categorical annotation labels to scores. Without
+// it, labels are mapped by the registered llmops.ScoreConfig categories.
+func WithLabelValues(values map[string]float64) CalibrationOption {
+	return func(c *calibrationConfig) {
+		c.labelValues = values
+	}
+}
+
+// WithTolerance sets how far apart judge and human values may be and still
+// count as agreeing for Accuracy. Default is 0.
+func WithTolerance(tolerance float64) CalibrationOption {
+	return func(c *calibrationConfig) {
+		c.tolerance = tolerance
+	}
+}
+
+// Calibrate compares judge scores with human annotations on the same
+// traces or spans. Annotations match a judge score by trace ID, span ID,
+// and name; only annotations from human annotators (or with no source) are
+// used. Judge scores with errors are skipped.
+func Calibrate(judged []JudgedScore, annotations []*llmops.Annotation, opts ...CalibrationOption) (*CalibrationReport, error) {
+	cfg := &calibrationConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	type target struct{ traceID, spanID, name string }
+	human := make(map[target]*llmops.Annotation)
+	for _, a := range annotations {
+		if a == nil || (a.Source != "" && a.Source != llmops.AnnotatorKindHuman) {
+			continue
+		}
+		human[target{a.TraceID, a.SpanID, a.Name}] = a
+	}
+
+	report := &CalibrationReport{Confusion: make(map[string]map[string]int)}
+	for _, j := range judged {
+		if j.Score.Error != "" {
+			continue
+		}
+		name := cfg.annotationName
+		if name == "" {
+			name = j.Score.Name
+		}
+		a, ok := human[target{j.TraceID, j.SpanID, name}]
+		if !ok {
+			report.Unmatched++
+			continue
+		}
+		value, err := cfg.annotationValue(a)
+		if err != nil {
+			return nil, err
+		}
+		report.Pairs = append(report.Pairs, CalibrationPair{
+			TraceID: j.TraceID,
+			SpanID:  j.SpanID,
+			Judge:   j.Score.Score,
+			Human:   value,
+		})
+	}
+
+	report.Count = len(report.Pairs)
+	if report.Count == 0 {
+		return report, nil
+	}
+
+	judgeLabels := make([]string, report.Count)
+	humanLabels := make([]string, report.Count)
+	judgeValues := make([]float64, report.Count)
+	humanValues := make([]float64, report.Count)
+	correct := 0
+	absErr := 0.0
+	for i, p := range report.Pairs {
+		judgeLabels[i], humanLabels[i] = scoreCategory(p.Judge), scoreCategory(p.Human)
+		judgeValues[i], humanValues[i] = p.Judge, p.Human
+
+		diff := math.Abs(p.Judge - p.Human)
+		absErr += diff
+		if diff <= cfg.tolerance {
+			correct++
+		}
+		if report.Confusion[humanLabels[i]] == nil {
+			report.Confusion[humanLabels[i]] = make(map[string]int)
+		}
+		report.Confusion[humanLabels[i]][judgeLabels[i]]++
+	}
+
+	report.Accuracy = float64(correct) / float64(report.Count)
+	report.MeanAbsoluteError = absErr / float64(report.Count)
+	report.Kappa, _ = CohenKappa(humanLabels, judgeLabels)
+	report.Correlation = pearson(judgeValues, humanValues)
+	return report, nil
+}
+
+// annotationValue returns the numeric value of an annotation.
+func (c *calibrationConfig) annotationValue(a *llmops.Annotation) (float64, error) {
+	dataType := a.DataType
+	if dataType == "" && a.Label != "" {
+		dataType = llmops.ScoreDataTypeCategorical
+	}
+	if dataType != llmops.ScoreDataTypeCategorical {
+		return a.Score, nil
+	}
+
+	if v, ok := c.labelValues[a.Label]; ok {
+		return v, nil
+	}
+	if cfg, ok := llmops.LookupScoreConfig(a.Name); ok {
+		if cat, ok := cfg.Category(a.Label); ok {
+			return cat.Value, nil
+		}
+	}
+	return 0, fmt.Errorf("no value for label %q of annotation %q; use WithLabelValues or register a score config", a.Label, a.Name)
+}
+
+// pearson returns the Pearson correlation of x and y, or 0 if either has no
+// variance.
+func pearson(x, y []float64) float64 {
+	mx, my := mean(x), mean(y)
+	var cov, vx, vy float64
+	for i := range x {
+		cov += (x[i] - mx) * (y[i] - my)
+		vx += (x[i] - mx) * (x[i] - mx)
+		vy += (y[i] - my) * (y[i] - my)
+	}
+	if vx == 0 || vy == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(vx*vy)
+}
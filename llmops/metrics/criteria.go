@@ -0,0 +1,409 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/agentplexus/omnillm/provider"
+
+	"github.com/agentplexus/omniobserve/llmops"
+)
+
+// RubricLevel describes what a score on the rubric scale means.
+type RubricLevel struct {
+	Score       int    `json:"score"`
+	Description string `json:"description"`
+}
+
+// CriteriaMetric is an LLM-based metric that scores output against custom
+// criteria, in the style of G-Eval. The judge follows evaluation steps,
+// generated from the criteria if none are given, and scores the test case
+// on a rubric scale, which is normalized to 0.0-1.0.
+//
+// The test case includes whichever of EvalInput.Input, Output, Expected, and
+// Context are set.
+type CriteriaMetric struct {
+	llm      *LLM
+	name     string
+	criteria string
+	rubric   []RubricLevel
+	min      int
+	max      int
+	logprobs bool
+	samples  int
+
+	scaleSet     bool
+	requireInput bool
+
+	mu    sync.Mutex
+	steps []string
+}
+
+// CriteriaOption configures the CriteriaMetric.
+type CriteriaOption func(*CriteriaMetric)
+
+// WithEvaluationSteps sets the steps the judge follows. By default they
+// are generated from the criteria on first use.
+func WithEvaluationSteps(steps ...string) CriteriaOption {
+	return func(m *CriteriaMetric) {
+		m.steps = steps
+	}
+}
+
+// WithRubric describes the scores of the scale. The scale spans the lowest
+// to highest rubric score unless set with WithScoreScale.
+func WithRubric(levels ...RubricLevel) CriteriaOption {
+	return func(m *CriteriaMetric) {
+		m.rubric = levels
+		if len(levels) == 0 || m.scaleSet {
+			return
+		}
+		m.min, m.max = levels[0].Score, levels[0].Score
+		for _, l := range levels[1:] {
+			m.min = min(m.min, l.Score)
+			m.max = max(m.max, l.Score)
+		}
+	}
+}
+
+// WithScoreScale sets the range of raw scores. Default is 1 to 5.
+func WithScoreScale(minScore, maxScore int) CriteriaOption {
+	return func(m *CriteriaMetric) {
+		m.min, m.max = minScore, maxScore
+		m.scaleSet = true
+	}
+}
+
+// WithLogprobWeighting weights each possible score by its token
+// probability, as G-Eval does, giving finer-grained scores than the single
+// sampled score. It requires a provider that returns OpenAI-style logprobs
+// in ChatCompletionChoice.Logprobs; otherwise the sampled score is used.
+// The judge gives no explanation in this mode.
+func WithLogprobWeighting() CriteriaOption {
+	return func(m *CriteriaMetric) {
+		m.logprobs = true
+	}
+}
+
+// WithSamples averages the scores of n judge calls, approximating
+// probability weighting when logprobs are unavailable. Default is 1.
+func WithSamples(n int) CriteriaOption {
+	return func(m *CriteriaMetric) {
+		if n > 0 {
+			m.samples = n
+		}
+	}
+}
+
+// NewCriteriaMetric creates a criteria metric with the given name and a
+// description of what good output looks like.
+func NewCriteriaMetric(llm *LLM, name, criteria string, opts ...CriteriaOption) *CriteriaMetric {
+	m := &CriteriaMetric{
+		llm:      llm,
+		name:     name,
+		criteria: criteria,
+		min:      1,
+		max:      5,
+		samples:  1,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Name returns the metric name.
+func (m *CriteriaMetric) Name() string {
+	return m.name
+}
+
+// Evaluate scores the output against the criteria.
+// Returns the raw score normalized to 0.0-1.0; the metadata contains the
+// raw score, the scale, and the score of each sample.
+func (m *CriteriaMetric) Evaluate(input llmops.EvalInput) (llmops.MetricScore, error) {
+	if toString(input.Output) == "" {
+		return failedScore(m.Name(), fmt.Errorf("no output provided for %s", m.name))
+	}
+	if m.requireInput && toString(input.Input) == "" {
+		return failedScore(m.Name(), fmt.Errorf("no input provided for %s", m.name))
+	}
+	if m.max <= m.min {
+		return failedScore(m.Name(), fmt.Errorf("invalid score scale %d-%d", m.min, m.max))
+	}
+
+	ctx := context.Background()
+	steps, err := m.evaluationSteps(ctx)
+	if err != nil {
+		return failedScore(m.Name(), err)
+	}
+	prompt := m.prompt(steps, input)
+
+	samples := make([]float64, 0, m.samples)
+	weighted := false
+	var explanation string
+	for i := 0; i < m.samples; i++ {
+		var raw float64
+		if m.logprobs {
+			var ok bool
+			raw, ok, err = m.scoreWithLogprobs(ctx, prompt)
+			weighted = weighted || ok
+		} else {
+			raw, explanation, err = m.score(ctx, prompt)
+		}
+		if err != nil {
+			return failedScore(m.Name(), err)
+		}
+		samples = append(samples, raw)
+	}
+
+	raw := 0.0
+	for _, s := range samples {
+		raw += s
+	}
+	raw /= float64(len(samples))
+	score := (raw - float64(m.min)) / float64(m.max-m.min)
+
+	reason := explanation
+	if reason == "" {
+		reason = fmt.Sprintf("Scored %.2f on a scale of %d to %d", raw, m.min, m.max)
+	}
+
+	return llmops.MetricScore{
+		Name:   m.Name(),
+		Score:  score,
+		Reason: reason,
+		Metadata: map[string]any{
+			"raw_score":        raw,
+			"scale_min":        m.min,
+			"scale_max":        m.max,
+			"samples":          samples,
+			"logprob_weighted": weighted,
+			"evaluation_steps": steps,
+		},
+	}, nil
+}
+
+// evaluationSteps returns the configured steps, generating them from the
+// criteria on first use.
+func (m *CriteriaMetric) evaluationSteps(ctx context.Context) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.steps) > 0 {
+		return m.steps, nil
+	}
+
+	prompt := strings.ReplaceAll(EvaluationStepsTemplate, "{{criteria}}", m.criteria)
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"steps": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string"},
+				"description": "The evaluation steps, in order",
+			},
+		},
+		"required": []string{"steps"},
+	}
+	var result struct {
+		Steps []string `json:"steps"`
+	}
+	if err := m.llm.GenerateJSON(ctx, prompt, "evaluation_steps", schema, &result); err != nil {
+		return nil, err
+	}
+	if len(result.Steps) == 0 {
+		return nil, fmt.Errorf("no evaluation steps generated for %s", m.name)
+	}
+	m.steps = result.Steps
+	return m.steps, nil
+}
+
+// prompt builds the judge prompt for a test case.
+func (m *CriteriaMetric) prompt(steps []string, input llmops.EvalInput) string {
+	var rubric strings.Builder
+	if len(m.rubric) == 0 {
+		fmt.Fprintf(&rubric, "- %d: the criteria are not met at all\n- %d: the criteria are fully met", m.min, m.max)
+	}
+	for i, l := range m.rubric {
+		if i > 0 {
+			rubric.WriteByte('\n')
+		}
+		fmt.Fprintf(&rubric, "- %d: %s", l.Score, l.Description)
+	}
+
+	var testCase []string
+	if s := toString(input.Input); s != "" {
+		testCase = append(testCase, "### Input\n"+s)
+	}
+	testCase = append(testCase, "### Output\n"+toString(input.Output))
+	if s := toString(input.Expected); s != "" {
+		testCase = append(testCase, "### Expected Output\n"+s)
+	}
+	if s := buildContextString(input.Context); s != "" {
+		testCase = append(testCase, "### Context\n"+s)
+	}
+
+	prompt := strings.ReplaceAll(CriteriaTemplate, "{{criteria}}", m.criteria)
+	prompt = strings.ReplaceAll(prompt, "{{steps}}", numberedList(steps))
+	prompt = strings.ReplaceAll(prompt, "{{rubric}}", rubric.String())
+	prompt = strings.ReplaceAll(prompt, "{{test_case}}", strings.Join(testCase, "\n\n"))
+	prompt = strings.ReplaceAll(prompt, "{{min}}", strconv.Itoa(m.min))
+	prompt = strings.ReplaceAll(prompt, "{{max}}", strconv.Itoa(m.max))
+	return prompt
+}
+
+// score asks the judge for a score and explanation.
+func (m *CriteriaMetric) score(ctx context.Context, prompt string) (float64, string, error) {
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"score": map[string]any{
+				"type":        "integer",
+				"minimum":     m.min,
+				"maximum":     m.max,
+				"description": "The score on the rubric scale",
+			},
+			"explanation": map[string]any{
+				"type":        "string",
+				"description": "Brief explanation for the score",
+			},
+		},
+		"required": []string{"score", "explanation"},
+	}
+	var result struct {
+		Score       float64 `json:"score"`
+		Explanation string  `json:"explanation"`
+	}
+	if err := m.llm.GenerateJSON(ctx, prompt, "score", schema, &result); err != nil {
+		return 0, "", err
+	}
+	if result.Score < float64(m.min) || result.Score > float64(m.max) {
+		return 0, "", fmt.Errorf("score %v is outside the scale %d-%d", result.Score, m.min, m.max)
+	}
+	return result.Score, result.Explanation, nil
+}
+
+// scoreWithLogprobs asks the judge for a bare score and weights the
+// possible scores by their token probabilities. It reports whether logprobs
+// were available; if not, the sampled score is returned.
+func (m *CriteriaMetric) scoreWithLogprobs(ctx context.Context, prompt string) (float64, bool, error) {
+	req := &provider.ChatCompletionRequest{
+		Model: m.llm.model,
+		Messages: []provider.Message{
+			{Role: provider.RoleUser, Content: prompt + "\n\nRespond with only the score, as a single integer."},
+		},
+	}
+	resp, err := m.llm.complete(ctx, req)
+	if err != nil {
+		return 0, false, fmt.Errorf("score request failed: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return 0, false, fmt.Errorf("no choices in response")
+	}
+
+	choice := resp.Choices[0]
+	if score, ok := m.weightedScore(choice.Logprobs); ok {
+		return score, true, nil
+	}
+
+	sampled, err := strconv.Atoi(strings.Trim(strings.TrimSpace(choice.Message.Content), ".*"))
+	if err != nil || sampled < m.min || sampled > m.max {
+		return 0, false, fmt.Errorf("could not parse score from content: %s", choice.Message.Content)
+	}
+	return float64(sampled), false, nil
+}
+
+// tokenLogprobs is the OpenAI logprobs format.
+type tokenLogprobs struct {
+	Content []struct {
+		Token       string  `json:"token"`
+		Logprob     float64 `json:"logprob"`
+		TopLogprobs []struct {
+			Token   string  `json:"token"`
+			Logprob float64 `json:"logprob"`
+		} `json:"top_logprobs"`
+	} `json:"content"`
+}
+
+// weightedScore computes the probability-weighted score from the logprobs
+// of the first token that is a score on the scale.
+func (m *CriteriaMetric) weightedScore(logprobs any) (float64, bool) {
+	if logprobs == nil {
+		return 0, false
+	}
+	b, err := json.Marshal(logprobs)
+	if err != nil {
+		return 0, false
+	}
+	var lp tokenLogprobs
+	if err := json.Unmarshal(b, &lp); err != nil {
+		return 0, false
+	}
+
+	for _, tok := range lp.Content {
+		if _, ok := m.scaleToken(tok.Token); !ok {
+			continue
+		}
+
+		var weighted, total float64
+		for _, alt := range tok.TopLogprobs {
+			if s, ok := m.scaleToken(alt.Token); ok {
+				p := math.Exp(alt.Logprob)
+				weighted += p * float64(s)
+				total += p
+			}
+		}
+		if total == 0 {
+			// No alternatives; use the token itself
+			s, _ := m.scaleToken(tok.Token)
+			return float64(s), true
+		}
+		return weighted / total, true
+	}
+	return 0, false
+}
+
+// scaleToken parses a token as a score on the scale.
+func (m *CriteriaMetric) scaleToken(token string) (int, bool) {
+	s, err := strconv.Atoi(strings.TrimSpace(token))
+	if err != nil || s < m.min || s > m.max {
+		return 0, false
+	}
+	return s, true
+}
+
+// =============================================================================
+// Summarization
+// =============================================================================
+
+// SummarizationCriteria is the criteria of the summarization metric.
+const SummarizationCriteria = `The output is a summary of the input. A good summary accurately captures the key points of the original text without significant omissions or errors, adds no information that is not in the original, and is concise while preserving the essential meaning.`
+
+// NewSummarizationMetric creates a criteria metric that scores the summary
+// in EvalInput.Output of the original text in EvalInput.Input on a 1-5
+// rubric. Unlike the binary SummarizationTemplate classification, it
+// distinguishes partial coverage from errors.
+func NewSummarizationMetric(llm *LLM, opts ...CriteriaOption) *CriteriaMetric {
+	defaults := []CriteriaOption{
+		WithEvaluationSteps(
+			"Identify the key points of the original text in the input.",
+			"Check which key points the summary covers, and whether it represents them accurately.",
+			"Check the summary for information that is not in the original or contradicts it.",
+			"Consider whether the summary is concise, without unnecessary detail.",
+		),
+		WithRubric(
+			RubricLevel{Score: 1, Description: "Misrepresents the original or misses most key points"},
+			RubricLevel{Score: 2, Description: "Covers few key points or contains significant errors"},
+			RubricLevel{Score: 3, Description: "Covers some key points, with minor errors or omissions"},
+			RubricLevel{Score: 4, Description: "Accurately covers most key points"},
+			RubricLevel{Score: 5, Description: "Accurately and concisely covers all key points"},
+		),
+	}
+	m := NewCriteriaMetric(llm, "summarization", SummarizationCriteria, append(defaults, opts...)...)
+	m.requireInput = true
+	return m
+}
@@ -0,0 +1,377 @@
+package metrics
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/agentplexus/omnillm"
+)
+
+// Embedder converts texts to embedding vectors.
+type Embedder interface {
+	// Embed returns one embedding per text, in the same order.
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+}
+
+// EmbedderFunc adapts a function to an Embedder.
+type EmbedderFunc func(ctx context.Context, texts []string) ([][]float64, error)
+
+// Embed calls the function.
+func (f EmbedderFunc) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	return f(ctx, texts)
+}
+
+// =============================================================================
+// OmniLLM Embedder
+// =============================================================================
+
+// OmniLLMEmbedder creates embeddings with the provider of an OmniLLM client
+// configuration. OmniLLM's ChatClient has no embeddings API, so the
+// embedder calls the provider's OpenAI-compatible embeddings endpoint
+// directly, using the configured API key, base URL, and HTTP client.
+// OpenAI, xAI, and Ollama are supported.
+type OmniLLMEmbedder struct {
+	endpoint   string
+	apiKey     string
+	model      string
+	httpClient *http.Client
+}
+
+// NewOmniLLMEmbedder creates an embedder for the provider in config using
+// the given embedding model, such as "text-embedding-3-small". It returns
+// omnillm.ErrUnsupportedProvider for providers without an
+// OpenAI-compatible embeddings endpoint.
+func NewOmniLLMEmbedder(config omnillm.ClientConfig, model string) (*OmniLLMEmbedder, error) {
+	baseURL := config.BaseURL
+	switch config.Provider {
+	case omnillm.ProviderNameOpenAI:
+		if baseURL == "" {
+			baseURL = "https://api.openai.com/v1"
+		}
+	case omnillm.ProviderNameXAI:
+		if baseURL == "" {
+			baseURL = "https://api.x.ai/v1"
+		}
+	case omnillm.ProviderNameOllama:
+		if baseURL == "" {
+			baseURL = "http://localhost:11434"
+		}
+		baseURL = strings.TrimSuffix(baseURL, "/") + "/v1"
+	default:
+		return nil, fmt.Errorf("embeddings for provider %q: %w", config.Provider, omnillm.ErrUnsupportedProvider)
+	}
+
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: config.Timeout}
+	}
+
+	return &OmniLLMEmbedder{
+		endpoint:   strings.TrimSuffix(baseURL, "/") + "/embeddings",
+		apiKey:     config.APIKey,
+		model:      model,
+		httpClient: httpClient,
+	}, nil
+}
+
+// Embed requests embeddings for the texts.
+func (e *OmniLLMEmbedder) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"model": e.model,
+		"input": texts,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal embeddings request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embeddings request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embeddings request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("embeddings request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	var result struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode embeddings response: %w", err)
+	}
+
+	embeddings := make([][]float64, len(texts))
+	for _, d := range result.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("embeddings response has invalid index %d", d.Index)
+		}
+		embeddings[d.Index] = d.Embedding
+	}
+	for i, emb := range embeddings {
+		if emb == nil {
+			return nil, fmt.Errorf("embeddings response is missing text %d", i)
+		}
+	}
+	return embeddings, nil
+}
+
+// =============================================================================
+// Hashing Embedder
+// =============================================================================
+
+// HashingEmbedder is a deterministic local embedder using feature hashing.
+// Lowercased words and their character n-grams are hashed into a fixed
+// number of dimensions, so texts sharing words or word stems are similar.
+// It captures lexical rather than semantic similarity, but needs no model
+// or network access, which makes it suitable for tests.
+type HashingEmbedder struct {
+	dimensions int
+	ngram      int
+}
+
+// HashingOption configures a HashingEmbedder.
+type HashingOption func(*HashingEmbedder)
+
+// WithDimensions sets the embedding size. Default is 512.
+func WithDimensions(n int) HashingOption {
+	return func(e *HashingEmbedder) {
+		if n > 0 {
+			e.dimensions = n
+		}
+	}
+}
+
+// WithCharNGrams sets the size of the character n-grams hashed in addition
+// to whole words. Zero hashes whole words only. Default is 3.
+func WithCharNGrams(n int) HashingOption {
+	return func(e *HashingEmbedder) {
+		if n >= 0 {
+			e.ngram = n
+		}
+	}
+}
+
+// NewHashingEmbedder creates a hashing embedder.
+func NewHashingEmbedder(opts ...HashingOption) *HashingEmbedder {
+	e := &HashingEmbedder{
+		dimensions: 512,
+		ngram:      3,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Embed returns L2-normalized hashed feature vectors for the texts.
+func (e *HashingEmbedder) Embed(_ context.Context, texts []string) ([][]float64, error) {
+	embeddings := make([][]float64, len(texts))
+	for i, text := range texts {
+		embeddings[i] = e.embed(text)
+	}
+	return embeddings, nil
+}
+
+// embed hashes the features of a text, weighting each by log term frequency.
+func (e *HashingEmbedder) embed(text string) []float64 {
+	counts := make(map[string]int)
+	for _, word := range words(text) {
+		counts["w:"+word]++
+		runes := []rune(word)
+		if e.ngram == 0 || len(runes) <= e.ngram {
+			continue
+		}
+		for i := 0; i+e.ngram <= len(runes); i++ {
+			counts["c:"+string(runes[i:i+e.ngram])]++
+		}
+	}
+
+	vec := make([]float64, e.dimensions)
+	for feature, count := range counts {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(feature))
+		sum := h.Sum64()
+		weight := 1 + math.Log(float64(count))
+		// The top bit picks the sign, so collisions cancel out on average
+		if sum>>63 == 1 {
+			weight = -weight
+		}
+		vec[sum%uint64(e.dimensions)] += weight
+	}
+	normalizeVector(vec)
+	return vec
+}
+
+// words splits text into lowercased runs of letters and digits.
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// normalizeVector scales vec to unit length in place.
+func normalizeVector(vec []float64) {
+	var sum float64
+	for _, v := range vec {
+		sum += v * v
+	}
+	if sum == 0 {
+		return
+	}
+	norm := math.Sqrt(sum)
+	for i := range vec {
+		vec[i] /= norm
+	}
+}
+
+// =============================================================================
+// Cached Embedder
+// =============================================================================
+
+// CachedEmbedder caches the embeddings of another embedder by text, so that
+// repeated texts, such as expected answers shared across evaluations, are
+// embedded once. The least recently used entries are evicted when the cache
+// is full. It is safe for concurrent use.
+type CachedEmbedder struct {
+	embedder   Embedder
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+// cacheEntry is an entry in the CachedEmbedder LRU list.
+type cacheEntry struct {
+	text      string
+	embedding []float64
+}
+
+// NewCachedEmbedder wraps embedder with a cache of up to maxEntries texts.
+// A maxEntries of zero or less means no limit.
+func NewCachedEmbedder(embedder Embedder, maxEntries int) *CachedEmbedder {
+	return &CachedEmbedder{
+		embedder:   embedder,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// Embed returns cached embeddings and embeds the remaining texts in a
+// single call to the underlying embedder.
+func (c *CachedEmbedder) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	embeddings := make([][]float64, len(texts))
+
+	c.mu.Lock()
+	var missing []string
+	missingIdx := make(map[string][]int)
+	for i, text := range texts {
+		if el, ok := c.entries[text]; ok {
+			c.order.MoveToFront(el)
+			embeddings[i] = el.Value.(*cacheEntry).embedding
+			continue
+		}
+		if _, ok := missingIdx[text]; !ok {
+			missing = append(missing, text)
+		}
+		missingIdx[text] = append(missingIdx[text], i)
+	}
+	c.mu.Unlock()
+
+	if len(missing) == 0 {
+		return embeddings, nil
+	}
+
+	embedded, err := c.embedder.Embed(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	if len(embedded) != len(missing) {
+		return nil, fmt.Errorf("embedder returned %d embeddings for %d texts", len(embedded), len(missing))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, text := range missing {
+		for _, idx := range missingIdx[text] {
+			embeddings[idx] = embedded[i]
+		}
+		c.add(text, embedded[i])
+	}
+	return embeddings, nil
+}
+
+// Len returns the number of cached embeddings.
+func (c *CachedEmbedder) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// add caches an embedding, evicting the least recently used entry if the
+// cache is full. The caller must hold c.mu.
+func (c *CachedEmbedder) add(text string, embedding []float64) {
+	if el, ok := c.entries[text]; ok {
+		el.Value.(*cacheEntry).embedding = embedding
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[text] = c.order.PushFront(&cacheEntry{text: text, embedding: embedding})
+	if c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).text)
+	}
+}
+
+// =============================================================================
+// Vector Helpers
+// =============================================================================
+
+// CosineSimilarity returns the cosine similarity of two vectors, in [-1, 1].
+// It returns 0 if either vector is zero, and an error if their lengths
+// differ.
+func CosineSimilarity(a, b []float64) (float64, error) {
+	if len(a) != len(b) {
+		return 0, fmt.Errorf("vector lengths differ: %d and %d", len(a), len(b))
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0, nil
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB)), nil
+}
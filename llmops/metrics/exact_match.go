@@ -14,6 +14,10 @@ type ExactMatchMetric struct {
 	// TrimWhitespace controls whether to trim leading/trailing whitespace.
 	// Default is false.
 	TrimWhitespace bool
+
+	// Normalization is applied to both strings after the settings above.
+	// The zero value leaves them unchanged.
+	Normalization Normalization
 }
 
 // NewExactMatchMetric creates a new exact match metric with default settings.
@@ -41,6 +45,16 @@ func WithTrimWhitespace(trim bool) ExactMatchOption {
 	}
 }
 
+// WithTextNormalization sets the normalization shared with the text overlap
+// metrics, for example to ignore punctuation and articles:
+//
+//	NewExactMatchMetricWithOptions(WithTextNormalization(WithNormalization(SQuADNormalization)))
+func WithTextNormalization(opts ...NormalizeOption) ExactMatchOption {
+	return func(m *ExactMatchMetric) {
+		m.Normalization = applyNormalizeOptions(m.Normalization, opts)
+	}
+}
+
 // NewExactMatchMetricWithOptions creates a new exact match metric with options.
 func NewExactMatchMetricWithOptions(opts ...ExactMatchOption) *ExactMatchMetric {
 	m := NewExactMatchMetric()
@@ -66,6 +80,9 @@ func (m *ExactMatchMetric) Evaluate(input llmops.EvalInput) (llmops.MetricScore,
 		expected = trimSpace(expected)
 	}
 
+	output = m.Normalization.Apply(output)
+	expected = m.Normalization.Apply(expected)
+
 	var match bool
 	if m.CaseSensitive {
 		match = output == expected
@@ -0,0 +1,663 @@
+package metrics
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+
+	"github.com/agentplexus/omniobserve/llmops"
+)
+
+// The JSON metrics are code-based metrics for structured output. String
+// outputs are parsed as JSON, ignoring a surrounding Markdown code fence;
+// other values are converted to JSON by marshaling them.
+
+// =============================================================================
+// JSON Validity
+// =============================================================================
+
+// JSONValidityMetric checks that the output is valid JSON.
+type JSONValidityMetric struct{}
+
+// NewJSONValidityMetric creates a JSON validity metric.
+func NewJSONValidityMetric() *JSONValidityMetric {
+	return &JSONValidityMetric{}
+}
+
+// Name returns the metric name.
+func (m *JSONValidityMetric) Name() string {
+	return "json_valid"
+}
+
+// Evaluate returns 1.0 if the output parses as JSON, 0.0 otherwise.
+func (m *JSONValidityMetric) Evaluate(input llmops.EvalInput) (llmops.MetricScore, error) {
+	if _, err := decodeJSON(input.Output); err != nil {
+		return llmops.MetricScore{
+			Name:     m.Name(),
+			Score:    0.0,
+			Reason:   "Output is not valid JSON",
+			Metadata: map[string]any{"error": err.Error()},
+		}, nil
+	}
+
+	return llmops.MetricScore{
+		Name:   m.Name(),
+		Score:  1.0,
+		Reason: "Output is valid JSON",
+	}, nil
+}
+
+// =============================================================================
+// JSON Schema
+// =============================================================================
+
+// JSONSchemaMetric checks that the output is JSON conforming to a JSON
+// Schema.
+type JSONSchemaMetric struct {
+	schema *jsonschema.Schema
+	name   string
+}
+
+// NewJSONSchemaMetric creates a JSON Schema metric. The schema may be a
+// JSON string, a []byte, or a value that marshals to JSON, such as a map.
+// The schema is compiled once at creation time.
+func NewJSONSchemaMetric(schema any) (*JSONSchemaMetric, error) {
+	compiled, err := compileJSONSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONSchemaMetric{
+		schema: compiled,
+		name:   "json_schema",
+	}, nil
+}
+
+// MustJSONSchemaMetric creates a JSON Schema metric, panicking on an
+// invalid schema.
+func MustJSONSchemaMetric(schema any) *JSONSchemaMetric {
+	m, err := NewJSONSchemaMetric(schema)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// NewJSONSchemaMetricWithName creates a JSON Schema metric with a custom
+// name.
+func NewJSONSchemaMetricWithName(name string, schema any) (*JSONSchemaMetric, error) {
+	m, err := NewJSONSchemaMetric(schema)
+	if err != nil {
+		return nil, err
+	}
+	m.name = name
+	return m, nil
+}
+
+// Name returns the metric name.
+func (m *JSONSchemaMetric) Name() string {
+	return m.name
+}
+
+// Evaluate validates the output against the schema. Returns 1.0 if it
+// conforms, 0.0 otherwise. On failure, the metadata contains the JSON
+// pointers of the failing values and the validation errors.
+func (m *JSONSchemaMetric) Evaluate(input llmops.EvalInput) (llmops.MetricScore, error) {
+	doc, err := decodeSchemaInstance(input.Output)
+	if err != nil {
+		return llmops.MetricScore{
+			Name:     m.name,
+			Score:    0.0,
+			Reason:   "Output is not valid JSON",
+			Metadata: map[string]any{"error": err.Error()},
+		}, nil
+	}
+
+	if err := m.schema.Validate(doc); err != nil {
+		metadata := map[string]any{"error": err.Error()}
+		var verr *jsonschema.ValidationError
+		if errors.As(err, &verr) {
+			paths, messages := validationErrors(verr)
+			metadata["paths"] = paths
+			metadata["errors"] = messages
+		}
+		return llmops.MetricScore{
+			Name:     m.name,
+			Score:    0.0,
+			Reason:   "Output does not conform to schema",
+			Metadata: metadata,
+		}, nil
+	}
+
+	return llmops.MetricScore{
+		Name:   m.name,
+		Score:  1.0,
+		Reason: "Output conforms to schema",
+	}, nil
+}
+
+// jsonSchemaURL identifies schemas added to the compiler.
+const jsonSchemaURL = "urn:omniobserve:metrics:schema"
+
+// compileJSONSchema compiles a JSON Schema given as a string, bytes, or value.
+func compileJSONSchema(schema any) (*jsonschema.Schema, error) {
+	var raw string
+	switch s := schema.(type) {
+	case string:
+		raw = s
+	case []byte:
+		raw = string(s)
+	default:
+		b, err := json.Marshal(s)
+		if err != nil {
+			return nil, fmt.Errorf("marshal schema: %w", err)
+		}
+		raw = string(b)
+	}
+
+	doc, err := jsonschema.UnmarshalJSON(strings.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("parse schema: %w", err)
+	}
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource(jsonSchemaURL, doc); err != nil {
+		return nil, fmt.Errorf("add schema: %w", err)
+	}
+	compiled, err := c.Compile(jsonSchemaURL)
+	if err != nil {
+		return nil, fmt.Errorf("compile schema: %w", err)
+	}
+	return compiled, nil
+}
+
+// decodeSchemaInstance decodes v for validation, preserving numbers as the
+// schema library expects.
+func decodeSchemaInstance(v any) (any, error) {
+	raw, err := rawJSON(v)
+	if err != nil {
+		return nil, err
+	}
+	return jsonschema.UnmarshalJSON(strings.NewReader(raw))
+}
+
+// englishPrinter formats validation error messages.
+var englishPrinter = message.NewPrinter(language.English)
+
+// validationErrors returns the JSON pointers and messages of the leaf
+// validation errors, sorted by pointer.
+func validationErrors(err *jsonschema.ValidationError) ([]string, []string) {
+	type leaf struct {
+		path    string
+		message string
+	}
+	var leaves []leaf
+	var walk func(e *jsonschema.ValidationError)
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) == 0 {
+			path := "/" + strings.Join(e.InstanceLocation, "/")
+			leaves = append(leaves, leaf{path: path, message: path + ": " + e.ErrorKind.LocalizedString(englishPrinter)})
+			return
+		}
+		for _, c := range e.Causes {
+			walk(c)
+		}
+	}
+	walk(err)
+	sort.SliceStable(leaves, func(i, j int) bool { return leaves[i].path < leaves[j].path })
+
+	var paths, messages []string
+	for _, l := range leaves {
+		if len(paths) == 0 || paths[len(paths)-1] != l.path {
+			paths = append(paths, l.path)
+		}
+		messages = append(messages, l.message)
+	}
+	return paths, messages
+}
+
+// =============================================================================
+// JSON Match
+// =============================================================================
+
+// JSONMismatch describes a difference between the output and expected JSON.
+type JSONMismatch struct {
+	Path     string `json:"path"` // JSON pointer of the value
+	Expected any    `json:"expected,omitempty"`
+	Actual   any    `json:"actual,omitempty"`
+	Reason   string `json:"reason"`
+}
+
+// JSONMatchMetric compares the output JSON to EvalInput.Expected field by
+// field. The score is the fraction of expected leaf values (strings,
+// numbers, booleans, nulls, and empty arrays or objects) that the output
+// matches; unexpected output values, such as extra array elements, are
+// added to the total. Expected may be a JSON string or a value such as a
+// map or struct.
+type JSONMatchMetric struct {
+	// NumericTolerance is the largest absolute difference at which numbers
+	// are considered equal. Default is 0.
+	NumericTolerance float64
+
+	// UnorderedArrays compares arrays as multisets, pairing each expected
+	// element with its best-matching output element. Default is false.
+	UnorderedArrays bool
+
+	// StrictFields counts output fields absent from Expected as
+	// mismatches. Default is false, so extra fields are ignored.
+	StrictFields bool
+
+	// StringNormalization is applied to string values before comparing.
+	// The zero value compares them exactly.
+	StringNormalization Normalization
+}
+
+// JSONMatchOption configures the JSONMatchMetric.
+type JSONMatchOption func(*JSONMatchMetric)
+
+// WithNumericTolerance sets the absolute tolerance for numeric fields.
+func WithNumericTolerance(tolerance float64) JSONMatchOption {
+	return func(m *JSONMatchMetric) {
+		m.NumericTolerance = tolerance
+	}
+}
+
+// WithUnorderedArrays sets whether arrays are compared regardless of order.
+func WithUnorderedArrays(unordered bool) JSONMatchOption {
+	return func(m *JSONMatchMetric) {
+		m.UnorderedArrays = unordered
+	}
+}
+
+// WithStrictFields sets whether extra output fields count as mismatches.
+func WithStrictFields(strict bool) JSONMatchOption {
+	return func(m *JSONMatchMetric) {
+		m.StrictFields = strict
+	}
+}
+
+// WithStringNormalization sets the normalization applied to string values.
+func WithStringNormalization(opts ...NormalizeOption) JSONMatchOption {
+	return func(m *JSONMatchMetric) {
+		m.StringNormalization = applyNormalizeOptions(m.StringNormalization, opts)
+	}
+}
+
+// NewJSONMatchMetric creates a JSON field match metric.
+func NewJSONMatchMetric(opts ...JSONMatchOption) *JSONMatchMetric {
+	m := &JSONMatchMetric{}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Name returns the metric name.
+func (m *JSONMatchMetric) Name() string {
+	return "json_match"
+}
+
+// Evaluate compares the output to the expected JSON. The metadata contains
+// a score for each top-level field and the mismatches with their JSON
+// pointers. Output that is not valid JSON scores 0.0.
+func (m *JSONMatchMetric) Evaluate(input llmops.EvalInput) (llmops.MetricScore, error) {
+	if input.Expected == nil {
+		return llmops.MetricScore{
+			Name:  m.Name(),
+			Error: "no expected output provided for JSON match",
+		}, fmt.Errorf("no expected output provided for JSON match")
+	}
+	expected, err := decodeJSON(input.Expected)
+	if err != nil {
+		err = fmt.Errorf("expected output is not valid JSON: %w", err)
+		return llmops.MetricScore{
+			Name:  m.Name(),
+			Error: err.Error(),
+		}, err
+	}
+
+	actual, err := decodeJSON(input.Output)
+	if err != nil {
+		return llmops.MetricScore{
+			Name:     m.Name(),
+			Score:    0.0,
+			Reason:   "Output is not valid JSON",
+			Metadata: map[string]any{"error": err.Error()},
+		}, nil
+	}
+
+	c := &jsonComparer{metric: m, record: true}
+	matched, total := c.compare("", expected, actual)
+
+	score := 1.0
+	if total > 0 {
+		score = matched / total
+	}
+
+	reason := "Output matches expected JSON"
+	if len(c.mismatches) > 0 {
+		reason = fmt.Sprintf("Output differs from expected JSON at %d path(s)", len(c.mismatches))
+	}
+
+	metadata := map[string]any{
+		"matched_fields": matched,
+		"total_fields":   total,
+		"mismatches":     c.mismatches,
+	}
+	if fieldScores := m.fieldScores(expected, actual); fieldScores != nil {
+		metadata["field_scores"] = fieldScores
+	}
+
+	return llmops.MetricScore{
+		Name:     m.Name(),
+		Score:    score,
+		Reason:   reason,
+		Metadata: metadata,
+	}, nil
+}
+
+// fieldScores returns the score of each top-level field when both values
+// are objects, or nil otherwise.
+func (m *JSONMatchMetric) fieldScores(expected, actual any) map[string]float64 {
+	exp, ok := expected.(map[string]any)
+	if !ok {
+		return nil
+	}
+	act, ok := actual.(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	scores := make(map[string]float64, len(exp))
+	for key, ev := range exp {
+		av, present := act[key]
+		if !present {
+			scores[key] = 0
+			continue
+		}
+		c := &jsonComparer{metric: m}
+		matched, total := c.compare("", ev, av)
+		scores[key] = matched / total
+	}
+	if m.StrictFields {
+		for key := range act {
+			if _, present := exp[key]; !present {
+				scores[key] = 0
+			}
+		}
+	}
+	return scores
+}
+
+// jsonComparer compares decoded JSON values, counting matched and total
+// leaf values and optionally recording mismatches.
+type jsonComparer struct {
+	metric     *JSONMatchMetric
+	record     bool
+	mismatches []JSONMismatch
+}
+
+// compare returns the matched and total leaf counts of expected compared
+// to actual at path.
+func (c *jsonComparer) compare(path string, expected, actual any) (matched, total float64) {
+	switch exp := expected.(type) {
+	case map[string]any:
+		act, ok := actual.(map[string]any)
+		if !ok {
+			c.mismatch(path, expected, actual, "type mismatch")
+			return 0, leafCount(expected)
+		}
+		if len(exp) == 0 {
+			if c.metric.StrictFields && len(act) > 0 {
+				c.mismatch(path, expected, actual, "unexpected fields")
+				return 0, 1
+			}
+			return 1, 1
+		}
+		for _, key := range sortedKeys(exp) {
+			childPath := path + "/" + escapePointer(key)
+			if av, present := act[key]; present {
+				m, t := c.compare(childPath, exp[key], av)
+				matched += m
+				total += t
+			} else {
+				total += leafCount(exp[key])
+				c.mismatch(childPath, exp[key], nil, "missing")
+			}
+		}
+		if c.metric.StrictFields {
+			for _, key := range sortedKeys(act) {
+				if _, present := exp[key]; !present {
+					total += leafCount(act[key])
+					c.mismatch(path+"/"+escapePointer(key), nil, act[key], "unexpected")
+				}
+			}
+		}
+		return matched, total
+
+	case []any:
+		act, ok := actual.([]any)
+		if !ok {
+			c.mismatch(path, expected, actual, "type mismatch")
+			return 0, leafCount(expected)
+		}
+		if len(exp) == 0 {
+			if len(act) > 0 {
+				c.mismatch(path, expected, actual, "unexpected elements")
+				return 0, 1
+			}
+			return 1, 1
+		}
+		if c.metric.UnorderedArrays {
+			return c.compareUnordered(path, exp, act)
+		}
+		for i, ev := range exp {
+			childPath := path + "/" + strconv.Itoa(i)
+			if i < len(act) {
+				m, t := c.compare(childPath, ev, act[i])
+				matched += m
+				total += t
+			} else {
+				total += leafCount(ev)
+				c.mismatch(childPath, ev, nil, "missing")
+			}
+		}
+		for i := len(exp); i < len(act); i++ {
+			total += leafCount(act[i])
+			c.mismatch(path+"/"+strconv.Itoa(i), nil, act[i], "unexpected")
+		}
+		return matched, total
+
+	case float64:
+		act, ok := actual.(float64)
+		if !ok {
+			c.mismatch(path, expected, actual, "type mismatch")
+			return 0, 1
+		}
+		if math.Abs(exp-act) > c.metric.NumericTolerance {
+			c.mismatch(path, expected, actual, "value mismatch")
+			return 0, 1
+		}
+		return 1, 1
+
+	case string:
+		act, ok := actual.(string)
+		if !ok {
+			c.mismatch(path, expected, actual, "type mismatch")
+			return 0, 1
+		}
+		norm := c.metric.StringNormalization
+		if norm.Apply(exp) != norm.Apply(act) {
+			c.mismatch(path, expected, actual, "value mismatch")
+			return 0, 1
+		}
+		return 1, 1
+
+	default:
+		// Booleans and null
+		if expected != actual {
+			reason := "value mismatch"
+			if fmt.Sprintf("%T", expected) != fmt.Sprintf("%T", actual) {
+				reason = "type mismatch"
+			}
+			c.mismatch(path, expected, actual, reason)
+			return 0, 1
+		}
+		return 1, 1
+	}
+}
+
+// compareUnordered pairs each expected element with the unused output
+// element it matches best, then compares the pairs. Unpaired output
+// elements are unexpected.
+func (c *jsonComparer) compareUnordered(path string, exp, act []any) (matched, total float64) {
+	used := make([]bool, len(act))
+	for i, ev := range exp {
+		childPath := path + "/" + strconv.Itoa(i)
+		best, bestScore := -1, -1.0
+		for j, av := range act {
+			if used[j] {
+				continue
+			}
+			trial := &jsonComparer{metric: c.metric}
+			m, t := trial.compare(childPath, ev, av)
+			if s := m / t; s > bestScore {
+				best, bestScore = j, s
+				if s == 1 {
+					break
+				}
+			}
+		}
+		if best < 0 {
+			total += leafCount(ev)
+			c.mismatch(childPath, ev, nil, "missing")
+			continue
+		}
+		used[best] = true
+		m, t := c.compare(childPath, ev, act[best])
+		matched += m
+		total += t
+	}
+	for j, av := range act {
+		if !used[j] {
+			total += leafCount(av)
+			c.mismatch(path+"/"+strconv.Itoa(j), nil, av, "unexpected")
+		}
+	}
+	return matched, total
+}
+
+// mismatch records a mismatch if recording is enabled.
+func (c *jsonComparer) mismatch(path string, expected, actual any, reason string) {
+	if !c.record {
+		return
+	}
+	if path == "" {
+		path = "/"
+	}
+	c.mismatches = append(c.mismatches, JSONMismatch{
+		Path:     path,
+		Expected: expected,
+		Actual:   actual,
+		Reason:   reason,
+	})
+}
+
+// leafCount returns the number of leaf values in v. Empty arrays and
+// objects count as one leaf.
+func leafCount(v any) float64 {
+	switch val := v.(type) {
+	case map[string]any:
+		if len(val) == 0 {
+			return 1
+		}
+		var n float64
+		for _, child := range val {
+			n += leafCount(child)
+		}
+		return n
+	case []any:
+		if len(val) == 0 {
+			return 1
+		}
+		var n float64
+		for _, child := range val {
+			n += leafCount(child)
+		}
+		return n
+	default:
+		return 1
+	}
+}
+
+// =============================================================================
+// JSON Helpers
+// =============================================================================
+
+// decodeJSON decodes v into generic JSON values: map[string]any, []any,
+// string, float64, bool, or nil.
+func decodeJSON(v any) (any, error) {
+	raw, err := rawJSON(v)
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	if err := json.Unmarshal([]byte(raw), &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// rawJSON returns the JSON text of v. Strings and bytes are taken as JSON
+// text, with a surrounding Markdown code fence removed; other values are
+// marshaled.
+func rawJSON(v any) (string, error) {
+	switch val := v.(type) {
+	case string:
+		return stripCodeFence(val), nil
+	case []byte:
+		return stripCodeFence(string(val)), nil
+	case json.RawMessage:
+		return string(val), nil
+	default:
+		b, err := json.Marshal(val)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+}
+
+// stripCodeFence removes a surrounding Markdown code fence, if any.
+func stripCodeFence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	s = strings.TrimPrefix(s, "```")
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[i+1:]
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "```"))
+}
+
+// escapePointer escapes a key for use in a JSON pointer.
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
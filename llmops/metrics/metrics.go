@@ -5,11 +5,31 @@
 //
 //   - Hallucination detection (LLM-based)
 //   - Document relevance scoring (LLM-based)
+//   - RAG faithfulness, answer relevancy, and context precision/recall (LLM-based)
+//   - Custom criteria and summarization scoring on a rubric (LLM-based)
+//   - Pairwise comparison of a candidate against a baseline (LLM-based)
 //   - Exact match comparison (code-based)
 //   - Regex pattern matching (code-based)
+//   - BLEU, ROUGE, token F1, and edit distance (code-based)
+//   - JSON validity, JSON Schema conformance, and field match (code-based)
+//   - Semantic similarity (embedding-based)
 //
 // LLM-based metrics require an LLM client from omnillm to perform evaluations.
-// Code-based metrics run locally without LLM calls.
+// Embedding-based metrics require an Embedder, such as OmniLLMEmbedder or the
+// local HashingEmbedder. Code-based metrics run locally without LLM calls.
+//
+// Judge responses can be cached with NewLLMWithOptions and WithCache, using a
+// MemoryCache or a FileCache. In CacheReplay mode, recorded judgments are
+// replayed without calling the judge, so evaluations run offline and
+// deterministically.
+//
+// ConsensusMetric aggregates several judges, or samples of one judge, and
+// reports their agreement; Calibrate measures a judge against human
+// annotations.
+//
+// Metrics can be combined with CompositeMetric and gated with
+// ThresholdMetric; SummarizeResult turns the thresholds of an
+// llmops.EvalResult into a single pass/fail outcome for CI.
 //
 // # Usage
 //
@@ -34,6 +54,13 @@
 //	    Output:  "The capital of France is London.",
 //	    Context: []string{"Paris is the capital of France."},
 //	})
+//
+//	// Gate on thresholds; hallucination passes at or below 0.5
+//	gated := metrics.NewThresholdMetric(hallucination, 0.5)
+//	result, err := provider.Evaluate(ctx, input, gated)
+//	if err := metrics.SummarizeResult(result).Err(); err != nil {
+//	    log.Fatal(err)
+//	}
 package metrics
 
 import (
@@ -48,15 +75,18 @@ import (
 
 // LLM wraps an omnillm.ChatClient for use with LLM-based metrics.
 type LLM struct {
-	client *omnillm.ChatClient
-	model  string
+	client    *omnillm.ChatClient
+	model     string
+	cache     Cache
+	cacheMode CacheMode
 }
 
 // NewLLM creates a new LLM wrapper for metrics evaluation.
 func NewLLM(client *omnillm.ChatClient, model string) *LLM {
 	return &LLM{
-		client: client,
-		model:  model,
+		client:    client,
+		model:     model,
+		cacheMode: CacheReadWrite,
 	}
 }
 
@@ -81,7 +111,7 @@ func (l *LLM) Classify(ctx context.Context, prompt string, labels []string, incl
 		ToolChoice: map[string]any{"type": "function", "function": map[string]any{"name": "classify"}},
 	}
 
-	resp, err := l.client.CreateChatCompletion(ctx, req)
+	resp, err := l.complete(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("classification request failed: %w", err)
 	}
@@ -115,7 +145,7 @@ func (l *LLM) GenerateText(ctx context.Context, prompt string) (string, error) {
 		},
 	}
 
-	resp, err := l.client.CreateChatCompletion(ctx, req)
+	resp, err := l.complete(ctx, req)
 	if err != nil {
 		return "", fmt.Errorf("text generation failed: %w", err)
 	}
@@ -127,6 +157,48 @@ func (l *LLM) GenerateText(ctx context.Context, prompt string) (string, error) {
 	return resp.Choices[0].Message.Content, nil
 }
 
+// GenerateJSON asks the LLM for output matching a JSON Schema and decodes it
+// into out. Like Classify, it uses tool calling to ensure structured output,
+// with name and schema defining the tool, and falls back to parsing JSON from
+// the message content.
+func (l *LLM) GenerateJSON(ctx context.Context, prompt, name string, schema map[string]any, out any) error {
+	req := &provider.ChatCompletionRequest{
+		Model: l.model,
+		Messages: []provider.Message{
+			{Role: provider.RoleUser, Content: prompt},
+		},
+		Tools: []provider.Tool{{
+			Type: "function",
+			Function: provider.ToolSpec{
+				Name:        name,
+				Description: "Record the structured result",
+				Parameters:  schema,
+			},
+		}},
+		ToolChoice: map[string]any{"type": "function", "function": map[string]any{"name": name}},
+	}
+
+	resp, err := l.complete(ctx, req)
+	if err != nil {
+		return fmt.Errorf("%s request failed: %w", name, err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return fmt.Errorf("no choices in response")
+	}
+
+	msg := resp.Choices[0].Message
+	raw := stripCodeFence(msg.Content)
+	if len(msg.ToolCalls) > 0 {
+		raw = msg.ToolCalls[0].Function.Arguments
+	}
+	if err := json.Unmarshal([]byte(raw), out); err != nil {
+		return fmt.Errorf("failed to parse %s result: %w", name, err)
+	}
+
+	return nil
+}
+
 // buildClassificationTool creates a tool definition for classification.
 func buildClassificationTool(labels []string, includeExplanation bool) provider.Tool {
 	properties := map[string]any{
@@ -2,10 +2,16 @@ package metrics
 
 import (
 	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/agentplexus/omnillm"
+	"github.com/agentplexus/omnillm/provider"
 	"github.com/agentplexus/omniobserve/llmops"
 )
 
@@ -376,15 +382,1357 @@ func TestToxicityMetric_NoContent(t *testing.T) {
 }
 
 // =============================================================================
-// LLM Integration Tests (skip if no API key)
+// Semantic Similarity Tests (local embedder, no LLM required)
 // =============================================================================
 
+func TestHashingEmbedder_Deterministic(t *testing.T) {
+	e := NewHashingEmbedder(WithDimensions(64))
+
+	a, err := e.Embed(context.Background(), []string{"The quick brown fox"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, _ := e.Embed(context.Background(), []string{"the quick, brown fox!"})
+	if len(a[0]) != 64 {
+		t.Fatalf("expected 64 dimensions, got %d", len(a[0]))
+	}
+
+	sim, err := CosineSimilarity(a[0], b[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if math.Abs(sim-1) > 1e-9 {
+		t.Errorf("expected similarity 1 for texts differing only in case and punctuation, got %f", sim)
+	}
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b     []float64
+		expected float64
+	}{
+		{[]float64{1, 0}, []float64{1, 0}, 1},
+		{[]float64{1, 0}, []float64{0, 1}, 0},
+		{[]float64{1, 0}, []float64{-1, 0}, -1},
+		{[]float64{0, 0}, []float64{1, 0}, 0},
+	}
+	for _, tc := range tests {
+		sim, err := CosineSimilarity(tc.a, tc.b)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if math.Abs(sim-tc.expected) > 1e-9 {
+			t.Errorf("CosineSimilarity(%v, %v) = %f, expected %f", tc.a, tc.b, sim, tc.expected)
+		}
+	}
+
+	if _, err := CosineSimilarity([]float64{1}, []float64{1, 2}); err == nil {
+		t.Error("expected error for vectors of different lengths")
+	}
+}
+
+func TestSemanticSimilarityMetric_Name(t *testing.T) {
+	m := NewSemanticSimilarityMetric(NewHashingEmbedder())
+	if m.Name() != "semantic_similarity" {
+		t.Errorf("expected name 'semantic_similarity', got '%s'", m.Name())
+	}
+}
+
+func TestSemanticSimilarityMetric_Similar(t *testing.T) {
+	m := NewSemanticSimilarityMetric(NewHashingEmbedder(), WithSimilarityThreshold(0.5))
+
+	similar, err := m.Evaluate(llmops.EvalInput{
+		Output:   "Paris is the capital city of France.",
+		Expected: "The capital of France is Paris.",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	unrelated, err := m.Evaluate(llmops.EvalInput{
+		Output:   "Bananas are rich in potassium.",
+		Expected: "The capital of France is Paris.",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if similar.Score <= unrelated.Score {
+		t.Errorf("expected paraphrase to score higher than unrelated text, got %f and %f", similar.Score, unrelated.Score)
+	}
+	if passed := similar.Metadata.(map[string]any)["passed"]; passed != true {
+		t.Errorf("expected paraphrase to pass threshold, got similarity %v", similar.Metadata.(map[string]any)["similarity"])
+	}
+	if passed := unrelated.Metadata.(map[string]any)["passed"]; passed != false {
+		t.Errorf("expected unrelated text to fail threshold, got similarity %v", unrelated.Metadata.(map[string]any)["similarity"])
+	}
+}
+
+func TestSemanticSimilarityMetric_MultipleExpected(t *testing.T) {
+	m := NewSemanticSimilarityMetric(NewHashingEmbedder())
+
+	score, err := m.Evaluate(llmops.EvalInput{
+		Output:   "Paris",
+		Expected: []string{"London", "Paris"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if math.Abs(score.Score-1) > 1e-9 {
+		t.Errorf("expected score 1.0 for matching one of the expected answers, got %f", score.Score)
+	}
+	if best := score.Metadata.(map[string]any)["best_match"]; best != "Paris" {
+		t.Errorf("expected best match 'Paris', got %v", best)
+	}
+}
+
+func TestSemanticSimilarityMetric_MissingFields(t *testing.T) {
+	m := NewSemanticSimilarityMetric(NewHashingEmbedder())
+
+	if _, err := m.Evaluate(llmops.EvalInput{Expected: "expected"}); err == nil {
+		t.Error("expected error for missing output")
+	}
+	if _, err := m.Evaluate(llmops.EvalInput{Output: "output"}); err == nil {
+		t.Error("expected error for missing expected output")
+	}
+}
+
+func TestCachedEmbedder(t *testing.T) {
+	var calls, embedded int
+	inner := EmbedderFunc(func(ctx context.Context, texts []string) ([][]float64, error) {
+		calls++
+		embedded += len(texts)
+		return NewHashingEmbedder().Embed(ctx, texts)
+	})
+	e := NewCachedEmbedder(inner, 2)
+
+	if _, err := e.Embed(context.Background(), []string{"a", "b", "a"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := e.Embed(context.Background(), []string{"a", "b"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 1 || embedded != 2 {
+		t.Errorf("expected 1 call embedding 2 texts, got %d calls embedding %d texts", calls, embedded)
+	}
+
+	// "c" evicts the least recently used entry, "a"
+	if _, err := e.Embed(context.Background(), []string{"c"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e.Len() != 2 {
+		t.Errorf("expected 2 cached embeddings, got %d", e.Len())
+	}
+	if _, err := e.Embed(context.Background(), []string{"a"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected evicted text to be embedded again, got %d calls", calls)
+	}
+}
+
+// =============================================================================
+// Text Overlap Metrics Tests
+// =============================================================================
+
+func TestNormalization_Apply(t *testing.T) {
+	tests := []struct {
+		n        Normalization
+		input    string
+		expected string
+	}{
+		{Normalization{}, " The Cat! ", " The Cat! "},
+		{Normalization{Lowercase: true}, "The Cat", "the cat"},
+		{Normalization{CollapseWhitespace: true}, "  a \t b\n", "a b"},
+		{Normalization{RemovePunctuation: true}, "a, b. c!", "a b c"},
+		{Normalization{RemoveArticles: true, CollapseWhitespace: true}, "The theory of a man", "theory of man"},
+		{SQuADNormalization, "The  Quick, brown fox!", "quick brown fox"},
+	}
+
+	for _, tc := range tests {
+		result := tc.n.Apply(tc.input)
+		if result != tc.expected {
+			t.Errorf("%+v.Apply(%q) = %q, expected %q", tc.n, tc.input, result, tc.expected)
+		}
+	}
+}
+
+func TestExactMatchMetric_TextNormalization(t *testing.T) {
+	m := NewExactMatchMetricWithOptions(WithTextNormalization(WithNormalization(SQuADNormalization)))
+
+	score, err := m.Evaluate(llmops.EvalInput{
+		Output:   "The Paris.",
+		Expected: "paris",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if score.Score != 1.0 {
+		t.Errorf("expected score 1.0 with SQuAD normalization, got %f", score.Score)
+	}
+}
+
+func TestBLEUMetric(t *testing.T) {
+	m := NewBLEUMetric()
+	if m.Name() != "bleu" {
+		t.Errorf("expected name 'bleu', got '%s'", m.Name())
+	}
+
+	score, err := m.Evaluate(llmops.EvalInput{
+		Output:   "The cat sat on the mat.",
+		Expected: "the cat sat on the mat",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if math.Abs(score.Score-1) > 1e-9 {
+		t.Errorf("expected score 1.0 for identical text, got %f", score.Score)
+	}
+
+	score, _ = m.Evaluate(llmops.EvalInput{
+		Output:   "dogs bark loudly",
+		Expected: "the cat sat on the mat",
+	})
+	if score.Score != 0 {
+		t.Errorf("expected score 0.0 for no overlap, got %f", score.Score)
+	}
+
+	// The closest reference length avoids a brevity penalty
+	score, _ = m.Evaluate(llmops.EvalInput{
+		Output:   "the cat sat",
+		Expected: []string{"the cat sat", "the cat sat on the mat"},
+	})
+	if bp := score.Metadata.(map[string]any)["brevity_penalty"]; bp != 1.0 {
+		t.Errorf("expected brevity penalty 1.0, got %v", bp)
+	}
+}
+
+func TestROUGEMetric(t *testing.T) {
+	tests := []struct {
+		variant  ROUGEVariant
+		name     string
+		expected float64
+	}{
+		{ROUGE1, "rouge_1", 5.0 / 6},
+		{ROUGE2, "rouge_2", 3.0 / 5},
+		{ROUGEL, "rouge_l", 5.0 / 6},
+	}
+
+	for _, tc := range tests {
+		m := NewROUGEMetric(tc.variant)
+		if m.Name() != tc.name {
+			t.Errorf("expected name '%s', got '%s'", tc.name, m.Name())
+		}
+
+		score, err := m.Evaluate(llmops.EvalInput{
+			Output:   "the cat sat on the mat",
+			Expected: "the cat is on the mat",
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if math.Abs(score.Score-tc.expected) > 1e-9 {
+			t.Errorf("%s: expected score %f, got %f", tc.variant, tc.expected, score.Score)
+		}
+		md := score.Metadata.(map[string]any)
+		if _, ok := md["precision"]; !ok {
+			t.Errorf("%s: expected precision in metadata", tc.variant)
+		}
+		if _, ok := md["recall"]; !ok {
+			t.Errorf("%s: expected recall in metadata", tc.variant)
+		}
+	}
+}
+
+func TestTokenF1Metric(t *testing.T) {
+	m := NewTokenF1Metric()
+	if m.Name() != "token_f1" {
+		t.Errorf("expected name 'token_f1', got '%s'", m.Name())
+	}
+
+	score, err := m.Evaluate(llmops.EvalInput{
+		Output:   "The Eiffel Tower.",
+		Expected: "Eiffel Tower in Paris",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if math.Abs(score.Score-2.0/3) > 1e-9 {
+		t.Errorf("expected score 0.667, got %f", score.Score)
+	}
+	md := score.Metadata.(map[string]any)
+	if md["precision"] != 1.0 || md["recall"] != 0.5 {
+		t.Errorf("expected precision 1.0 and recall 0.5, got %v and %v", md["precision"], md["recall"])
+	}
+}
+
+func TestLevenshteinMetric(t *testing.T) {
+	if d := LevenshteinDistance("kitten", "sitting"); d != 3 {
+		t.Errorf("expected distance 3, got %d", d)
+	}
+
+	m := NewLevenshteinMetric()
+	score, err := m.Evaluate(llmops.EvalInput{
+		Output:   "Kitten",
+		Expected: "sitting",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if math.Abs(score.Score-(1-3.0/7)) > 1e-9 {
+		t.Errorf("expected score %f, got %f", 1-3.0/7, score.Score)
+	}
+}
+
+func TestOverlapMetrics_MissingExpected(t *testing.T) {
+	metrics := []llmops.Metric{
+		NewBLEUMetric(),
+		NewROUGEMetric(ROUGE1),
+		NewTokenF1Metric(),
+		NewLevenshteinMetric(),
+	}
+	for _, m := range metrics {
+		if _, err := m.Evaluate(llmops.EvalInput{Output: "output"}); err == nil {
+			t.Errorf("%s: expected error for missing expected output", m.Name())
+		}
+	}
+}
+
+// =============================================================================
+// JSON Metrics Tests
+// =============================================================================
+
+func TestJSONValidityMetric(t *testing.T) {
+	m := NewJSONValidityMetric()
+	if m.Name() != "json_valid" {
+		t.Errorf("expected name 'json_valid', got '%s'", m.Name())
+	}
+
+	tests := []struct {
+		output   any
+		expected float64
+	}{
+		{`{"a": 1}`, 1.0},
+		{"```json\n[1, 2]\n```", 1.0},
+		{map[string]any{"a": 1}, 1.0},
+		{`{"a": 1`, 0.0},
+		{"not json", 0.0},
+	}
+	for _, tc := range tests {
+		score, err := m.Evaluate(llmops.EvalInput{Output: tc.output})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if score.Score != tc.expected {
+			t.Errorf("output %q: expected score %f, got %f", tc.output, tc.expected, score.Score)
+		}
+	}
+}
+
+func TestJSONSchemaMetric(t *testing.T) {
+	m := MustJSONSchemaMetric(`{
+		"type": "object",
+		"required": ["name", "age"],
+		"properties": {
+			"name": {"type": "string"},
+			"age": {"type": "integer", "minimum": 0}
+		}
+	}`)
+
+	score, err := m.Evaluate(llmops.EvalInput{Output: `{"name": "Ada", "age": 36}`})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if score.Score != 1.0 {
+		t.Errorf("expected score 1.0 for conforming output, got %f", score.Score)
+	}
+
+	score, err = m.Evaluate(llmops.EvalInput{Output: `{"name": 7, "age": -1}`})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if score.Score != 0.0 {
+		t.Errorf("expected score 0.0 for non-conforming output, got %f", score.Score)
+	}
+	paths, _ := score.Metadata.(map[string]any)["paths"].([]string)
+	if len(paths) != 2 || paths[0] != "/age" || paths[1] != "/name" {
+		t.Errorf("expected paths [/age /name], got %v", paths)
+	}
+}
+
+func TestJSONSchemaMetric_InvalidSchema(t *testing.T) {
+	if _, err := NewJSONSchemaMetric(`{"type": 5}`); err == nil {
+		t.Error("expected error for invalid schema")
+	}
+}
+
+func TestJSONMatchMetric_FieldScores(t *testing.T) {
+	m := NewJSONMatchMetric()
+	if m.Name() != "json_match" {
+		t.Errorf("expected name 'json_match', got '%s'", m.Name())
+	}
+
+	score, err := m.Evaluate(llmops.EvalInput{
+		Output:   `{"name": "Ada", "address": {"city": "London", "zip": "N1"}, "extra": true}`,
+		Expected: map[string]any{"name": "Ada", "address": map[string]any{"city": "London", "zip": "EC1"}, "age": 36},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if math.Abs(score.Score-0.5) > 1e-9 {
+		t.Errorf("expected score 0.5, got %f", score.Score)
+	}
+
+	md := score.Metadata.(map[string]any)
+	fields := md["field_scores"].(map[string]float64)
+	if fields["name"] != 1 || fields["address"] != 0.5 || fields["age"] != 0 {
+		t.Errorf("unexpected field scores: %v", fields)
+	}
+	mismatches := md["mismatches"].([]JSONMismatch)
+	if len(mismatches) != 2 || mismatches[0].Path != "/address/zip" || mismatches[1].Path != "/age" {
+		t.Errorf("unexpected mismatches: %+v", mismatches)
+	}
+	if mismatches[1].Reason != "missing" {
+		t.Errorf("expected missing reason, got %q", mismatches[1].Reason)
+	}
+}
+
+func TestJSONMatchMetric_NumericTolerance(t *testing.T) {
+	input := llmops.EvalInput{
+		Output:   `{"price": 9.99}`,
+		Expected: `{"price": 10}`,
+	}
+
+	score, _ := NewJSONMatchMetric().Evaluate(input)
+	if score.Score != 0.0 {
+		t.Errorf("expected score 0.0 without tolerance, got %f", score.Score)
+	}
+	score, _ = NewJSONMatchMetric(WithNumericTolerance(0.05)).Evaluate(input)
+	if score.Score != 1.0 {
+		t.Errorf("expected score 1.0 with tolerance, got %f", score.Score)
+	}
+}
+
+func TestJSONMatchMetric_UnorderedArrays(t *testing.T) {
+	input := llmops.EvalInput{
+		Output:   `{"tags": ["b", "c", "a"]}`,
+		Expected: `{"tags": ["a", "b", "c"]}`,
+	}
+
+	score, _ := NewJSONMatchMetric().Evaluate(input)
+	if score.Score == 1.0 {
+		t.Error("expected ordered comparison to fail")
+	}
+	score, _ = NewJSONMatchMetric(WithUnorderedArrays(true)).Evaluate(input)
+	if score.Score != 1.0 {
+		t.Errorf("expected score 1.0 for unordered arrays, got %f", score.Score)
+	}
+}
+
+func TestJSONMatchMetric_StrictFields(t *testing.T) {
+	input := llmops.EvalInput{
+		Output:   `{"a": 1, "b": 2}`,
+		Expected: `{"a": 1}`,
+	}
+
+	score, _ := NewJSONMatchMetric().Evaluate(input)
+	if score.Score != 1.0 {
+		t.Errorf("expected extra fields to be ignored, got %f", score.Score)
+	}
+	score, _ = NewJSONMatchMetric(WithStrictFields(true)).Evaluate(input)
+	if score.Score != 0.5 {
+		t.Errorf("expected score 0.5 with strict fields, got %f", score.Score)
+	}
+}
+
+func TestJSONMatchMetric_InvalidJSON(t *testing.T) {
+	m := NewJSONMatchMetric()
+
+	score, err := m.Evaluate(llmops.EvalInput{Output: "not json", Expected: `{"a": 1}`})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if score.Score != 0.0 {
+		t.Errorf("expected score 0.0 for invalid output, got %f", score.Score)
+	}
+
+	if _, err := m.Evaluate(llmops.EvalInput{Output: `{"a": 1}`}); err == nil {
+		t.Error("expected error for missing expected output")
+	}
+}
+
+// =============================================================================
+// RAG Metrics Tests (fake LLM)
+// =============================================================================
+
+// fakeProvider answers chat completions with a function of the request.
+// Requests with tools receive the result as tool call arguments.
+type fakeProvider struct {
+	respond  func(req *provider.ChatCompletionRequest) string
+	logprobs any
+}
+
+func (p *fakeProvider) CreateChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
+	msg := provider.Message{Role: provider.RoleAssistant}
+	if len(req.Tools) > 0 {
+		msg.ToolCalls = []provider.ToolCall{{
+			ID:   "call_1",
+			Type: "function",
+			Function: provider.ToolFunction{
+				Name:      req.Tools[0].Function.Name,
+				Arguments: p.respond(req),
+			},
+		}}
+	} else {
+		msg.Content = p.respond(req)
+	}
+	return &provider.ChatCompletionResponse{
+		Model:   req.Model,
+		Choices: []provider.ChatCompletionChoice{{Message: msg, Logprobs: p.logprobs}},
+	}, nil
+}
+
+func (p *fakeProvider) CreateChatCompletionStream(ctx context.Context, req *provider.ChatCompletionRequest) (provider.ChatCompletionStream, error) {
+	return nil, errors.New("streaming not supported")
+}
+
+func (p *fakeProvider) Close() error { return nil }
+
+func (p *fakeProvider) Name() string { return "fake" }
+
+// newFakeLLM returns an LLM answering tool calls with responses by tool name.
+func newFakeLLM(t *testing.T, responses map[string]string) *LLM {
+	t.Helper()
+
+	client, err := omnillm.NewClient(omnillm.ClientConfig{
+		CustomProvider: &fakeProvider{respond: func(req *provider.ChatCompletionRequest) string {
+			if len(req.Tools) == 0 {
+				return responses[""]
+			}
+			return responses[req.Tools[0].Function.Name]
+		}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create omnillm client: %v", err)
+	}
+	return NewLLM(client, "fake-model")
+}
+
+func TestFaithfulnessMetric(t *testing.T) {
+	llm := newFakeLLM(t, map[string]string{
+		"extract_claims": `{"claims": ["Paris is the capital of France.", "Paris has 10 million residents."]}`,
+		"verify_claims": `{"verdicts": [
+			{"index": 1, "verdict": "supported", "reason": "Stated in chunk 1", "chunk": 1},
+			{"index": 2, "verdict": "contradicted", "reason": "Chunk 2 says 2 million"}
+		]}`,
+	})
+	m := NewFaithfulnessMetric(llm)
+	if m.Name() != "faithfulness" {
+		t.Errorf("expected name 'faithfulness', got '%s'", m.Name())
+	}
+
+	score, err := m.Evaluate(llmops.EvalInput{
+		Output:  "Paris is the capital of France and has 10 million residents.",
+		Context: []string{"Paris is the capital of France.", "Paris has 2 million residents."},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if score.Score != 0.5 {
+		t.Errorf("expected score 0.5, got %f", score.Score)
+	}
+	claims := score.Metadata.(map[string]any)["claims"].([]ClaimVerdict)
+	if len(claims) != 2 || claims[0].Chunk != 1 || claims[1].Verdict != VerdictContradicted {
+		t.Errorf("unexpected claim verdicts: %+v", claims)
+	}
+
+	if _, err := m.Evaluate(llmops.EvalInput{Output: "answer"}); err == nil {
+		t.Error("expected error for missing context")
+	}
+}
+
+func TestFaithfulnessMetric_MissingVerdict(t *testing.T) {
+	llm := newFakeLLM(t, map[string]string{
+		"extract_claims": `{"claims": ["a", "b"]}`,
+		"verify_claims":  `{"verdicts": [{"index": 1, "verdict": "supported", "reason": "r"}]}`,
+	})
+
+	_, err := NewFaithfulnessMetric(llm).Evaluate(llmops.EvalInput{
+		Output:  "a and b",
+		Context: []string{"a"},
+	})
+	if err == nil {
+		t.Error("expected error when a claim has no verdict")
+	}
+}
+
+func TestAnswerRelevancyMetric(t *testing.T) {
+	llm := newFakeLLM(t, map[string]string{
+		"extract_claims": `{"claims": ["Paris is the capital.", "I like cheese.", "It is on the Seine."]}`,
+		"judge_statements": `{"verdicts": [
+			{"index": 1, "verdict": "relevant", "reason": "Answers the question"},
+			{"index": 2, "verdict": "irrelevant", "reason": "Off topic"},
+			{"index": 3, "verdict": "relevant", "reason": "Supporting detail"}
+		]}`,
+	})
+
+	score, err := NewAnswerRelevancyMetric(llm).Evaluate(llmops.EvalInput{
+		Input:  "What is the capital of France?",
+		Output: "Paris is the capital. I like cheese. It is on the Seine.",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if math.Abs(score.Score-2.0/3) > 1e-9 {
+		t.Errorf("expected score 0.667, got %f", score.Score)
+	}
+}
+
+func TestContextPrecisionMetric(t *testing.T) {
+	llm := newFakeLLM(t, map[string]string{
+		"judge_chunks": `{"verdicts": [
+			{"index": 1, "verdict": "irrelevant", "reason": "About Berlin"},
+			{"index": 2, "verdict": "relevant", "reason": "Names the capital"},
+			{"index": 3, "verdict": "relevant", "reason": "Mentions Paris"}
+		]}`,
+	})
+
+	score, err := NewContextPrecisionMetric(llm).Evaluate(llmops.EvalInput{
+		Input:    "What is the capital of France?",
+		Expected: "Paris",
+		Context:  []string{"Berlin is in Germany.", "Paris is the capital of France.", "Paris is on the Seine."},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Relevant chunks at ranks 2 and 3: (1/2 + 2/3) / 2
+	expected := (1.0/2 + 2.0/3) / 2
+	if math.Abs(score.Score-expected) > 1e-9 {
+		t.Errorf("expected score %f, got %f", expected, score.Score)
+	}
+	chunks := score.Metadata.(map[string]any)["chunks"].([]ChunkVerdict)
+	if len(chunks) != 3 || chunks[0].Relevant || !chunks[1].Relevant {
+		t.Errorf("unexpected chunk verdicts: %+v", chunks)
+	}
+}
+
+func TestContextRecallMetric(t *testing.T) {
+	llm := newFakeLLM(t, map[string]string{
+		"extract_claims": `{"claims": ["Paris is the capital of France.", "Paris is on the Seine.", "Paris hosted the 2024 Olympics."]}`,
+		"verify_claims": `{"verdicts": [
+			{"index": 1, "verdict": "supported", "reason": "r", "chunk": 2},
+			{"index": 2, "verdict": "supported", "reason": "r", "chunk": 2},
+			{"index": 3, "verdict": "unsupported", "reason": "r"}
+		]}`,
+	})
+
+	score, err := NewContextRecallMetric(llm).Evaluate(llmops.EvalInput{
+		Expected: "Paris is the capital of France, lies on the Seine, and hosted the 2024 Olympics.",
+		Context:  []string{"Berlin is in Germany.", "Paris, on the Seine, is the capital of France."},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if math.Abs(score.Score-2.0/3) > 1e-9 {
+		t.Errorf("expected score 0.667, got %f", score.Score)
+	}
+	chunkClaims := score.Metadata.(map[string]any)["chunk_claims"].([]int)
+	if len(chunkClaims) != 2 || chunkClaims[0] != 0 || chunkClaims[1] != 2 {
+		t.Errorf("expected chunk claims [0 2], got %v", chunkClaims)
+	}
+}
+
+// =============================================================================
+// Criteria Metric Tests (fake LLM)
+// =============================================================================
+
+func TestCriteriaMetric(t *testing.T) {
+	var prompt string
+	client, err := omnillm.NewClient(omnillm.ClientConfig{
+		CustomProvider: &fakeProvider{respond: func(req *provider.ChatCompletionRequest) string {
+			switch req.Tools[0].Function.Name {
+			case "evaluation_steps":
+				return `{"steps": ["Check the tone.", "Check for jargon."]}`
+			default:
+				prompt = req.Messages[0].Content
+				return `{"score": 4, "explanation": "Mostly plain language"}`
+			}
+		}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create omnillm client: %v", err)
+	}
+
+	m := NewCriteriaMetric(NewLLM(client, "fake-model"), "plain_language", "The output uses plain language.")
+	if m.Name() != "plain_language" {
+		t.Errorf("expected name 'plain_language', got '%s'", m.Name())
+	}
+
+	score, err := m.Evaluate(llmops.EvalInput{
+		Input:  "Explain DNS.",
+		Output: "DNS turns names into addresses.",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if score.Score != 0.75 {
+		t.Errorf("expected score 0.75 for 4 on a 1-5 scale, got %f", score.Score)
+	}
+	if score.Reason != "Mostly plain language" {
+		t.Errorf("expected explanation as reason, got %q", score.Reason)
+	}
+	steps := score.Metadata.(map[string]any)["evaluation_steps"].([]string)
+	if len(steps) != 2 {
+		t.Errorf("expected generated evaluation steps, got %v", steps)
+	}
+	if !strings.Contains(prompt, "1. Check the tone.") || !strings.Contains(prompt, "### Input\nExplain DNS.") {
+		t.Errorf("expected steps and test case in prompt, got:\n%s", prompt)
+	}
+}
+
+func TestCriteriaMetric_Rubric(t *testing.T) {
+	llm := newFakeLLM(t, map[string]string{
+		"score": `{"score": 2, "explanation": "Partially correct"}`,
+	})
+
+	m := NewCriteriaMetric(llm, "correctness", "The output is correct.",
+		WithEvaluationSteps("Compare the output with the expected output."),
+		WithRubric(
+			RubricLevel{Score: 0, Description: "Incorrect"},
+			RubricLevel{Score: 2, Description: "Partially correct"},
+			RubricLevel{Score: 4, Description: "Correct"},
+		),
+	)
+
+	score, err := m.Evaluate(llmops.EvalInput{Output: "42", Expected: "42.0"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if score.Score != 0.5 {
+		t.Errorf("expected score 0.5 for 2 on a 0-4 scale, got %f", score.Score)
+	}
+}
+
+func TestCriteriaMetric_OutOfScale(t *testing.T) {
+	llm := newFakeLLM(t, map[string]string{
+		"score": `{"score": 9, "explanation": "Great"}`,
+	})
+
+	m := NewCriteriaMetric(llm, "quality", "The output is good.", WithEvaluationSteps("Judge it."))
+	if _, err := m.Evaluate(llmops.EvalInput{Output: "output"}); err == nil {
+		t.Error("expected error for a score outside the scale")
+	}
+}
+
+func TestCriteriaMetric_LogprobWeighting(t *testing.T) {
+	logprobs := map[string]any{
+		"content": []map[string]any{{
+			"token":   "4",
+			"logprob": math.Log(0.6),
+			"top_logprobs": []map[string]any{
+				{"token": "4", "logprob": math.Log(0.6)},
+				{"token": "5", "logprob": math.Log(0.3)},
+				{"token": "3", "logprob": math.Log(0.1)},
+			},
+		}},
+	}
+	client, err := omnillm.NewClient(omnillm.ClientConfig{
+		CustomProvider: &fakeProvider{
+			respond:  func(req *provider.ChatCompletionRequest) string { return "4" },
+			logprobs: logprobs,
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create omnillm client: %v", err)
+	}
+
+	m := NewCriteriaMetric(NewLLM(client, "fake-model"), "quality", "The output is good.",
+		WithEvaluationSteps("Judge it."), WithLogprobWeighting())
+
+	score, err := m.Evaluate(llmops.EvalInput{Output: "output"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 0.6*4 + 0.3*5 + 0.1*3 = 4.2 on a 1-5 scale
+	if math.Abs(score.Score-0.8) > 1e-9 {
+		t.Errorf("expected score 0.8, got %f", score.Score)
+	}
+	if weighted := score.Metadata.(map[string]any)["logprob_weighted"]; weighted != true {
+		t.Error("expected logprob weighting to be used")
+	}
+}
+
+func TestSummarizationMetric(t *testing.T) {
+	llm := newFakeLLM(t, map[string]string{
+		"score": `{"score": 5, "explanation": "Covers all key points"}`,
+	})
+
+	m := NewSummarizationMetric(llm)
+	if m.Name() != "summarization" {
+		t.Errorf("expected name 'summarization', got '%s'", m.Name())
+	}
+
+	score, err := m.Evaluate(llmops.EvalInput{
+		Input:  "The meeting moved from Monday to Tuesday because the room was booked.",
+		Output: "The meeting moved to Tuesday due to a room conflict.",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if score.Score != 1.0 {
+		t.Errorf("expected score 1.0, got %f", score.Score)
+	}
+
+	if _, err := m.Evaluate(llmops.EvalInput{Output: "summary"}); err == nil {
+		t.Error("expected error for missing original text")
+	}
+}
+
+// =============================================================================
+// Pairwise Judge Tests (fake LLM)
+// =============================================================================
+
+// newPairwiseLLM returns an LLM that prefers the response containing
+// preferred, or always picks position A if preferred is empty.
+func newPairwiseLLM(t *testing.T, preferred string) *LLM {
+	t.Helper()
+
+	client, err := omnillm.NewClient(omnillm.ClientConfig{
+		CustomProvider: &fakeProvider{respond: func(req *provider.ChatCompletionRequest) string {
+			prompt := req.Messages[0].Content
+			a := prompt[strings.Index(prompt, "## Response A"):strings.Index(prompt, "## Response B")]
+			if preferred == "" || strings.Contains(a, preferred) {
+				return `{"label": "A", "explanation": "A is better"}`
+			}
+			return `{"label": "B", "explanation": "B is better"}`
+		}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create omnillm client: %v", err)
+	}
+	return NewLLM(client, "fake-model")
+}
+
+func TestPairwiseJudge_Win(t *testing.T) {
+	j := NewPairwiseJudge(newPairwiseLLM(t, "detailed"), WithRandomSource(rand.New(rand.NewPCG(1, 2))))
+
+	result, err := j.Compare(context.Background(), "Explain DNS.", "A detailed answer", "A short answer")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Outcome != PairwiseWin || !result.Consistent {
+		t.Errorf("expected consistent win, got %+v", result)
+	}
+	if len(result.Judgments) != 2 || result.Judgments[0].CandidateFirst == result.Judgments[1].CandidateFirst {
+		t.Errorf("expected one judgment in each order, got %+v", result.Judgments)
+	}
+
+	score, err := j.Evaluate(llmops.EvalInput{Input: "Explain DNS.", Output: "A short answer", Expected: "A detailed answer"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if score.Score != 0.0 {
+		t.Errorf("expected score 0.0 for a loss, got %f", score.Score)
+	}
+}
+
+func TestPairwiseJudge_PositionBias(t *testing.T) {
+	j := NewPairwiseJudge(newPairwiseLLM(t, ""))
+
+	result, err := j.Compare(context.Background(), "Explain DNS.", "one answer", "another answer")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Outcome != PairwiseTie || result.Consistent {
+		t.Errorf("expected inconsistent tie for a judge that always picks A, got %+v", result)
+	}
+}
+
+func TestPairwiseJudge_SingleOrder(t *testing.T) {
+	j := NewPairwiseJudge(newPairwiseLLM(t, "detailed"), WithPositionSwap(false))
+
+	result, err := j.Compare(context.Background(), "", "A detailed answer", "A short answer")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Judgments) != 1 || result.Outcome != PairwiseWin {
+		t.Errorf("expected a single winning judgment, got %+v", result)
+	}
+}
+
+func TestSummarizePairwise(t *testing.T) {
+	var results []*PairwiseResult
+	for i := 0; i < 8; i++ {
+		results = append(results, &PairwiseResult{Outcome: PairwiseWin, Consistent: true})
+	}
+	results = append(results,
+		&PairwiseResult{Outcome: PairwiseTie, Consistent: false},
+		&PairwiseResult{Outcome: PairwiseLoss, Consistent: true},
+		nil,
+	)
+
+	s := SummarizePairwise(results, 0.95)
+	if s.Total != 10 || s.Wins != 8 || s.Ties != 1 || s.Losses != 1 || s.Inconsistent != 1 {
+		t.Errorf("unexpected counts: %+v", s)
+	}
+	if math.Abs(s.Score-0.85) > 1e-9 {
+		t.Errorf("expected score 0.85, got %f", s.Score)
+	}
+
+	// Wilson interval for 8/10 at 95%
+	if s.WinRate.Rate != 0.8 || math.Abs(s.WinRate.Lower-0.4902) > 1e-3 || math.Abs(s.WinRate.Upper-0.9433) > 1e-3 {
+		t.Errorf("unexpected win rate estimate: %+v", s.WinRate)
+	}
+}
+
+// =============================================================================
+// Composite and Threshold Metrics Tests
+// =============================================================================
+
+// fixedMetric returns a fixed score, for testing metric combinators.
+type fixedMetric struct {
+	name      string
+	score     float64
+	direction Direction
+	err       error
+}
+
+func (m *fixedMetric) Name() string { return m.name }
+
+func (m *fixedMetric) Direction() Direction {
+	if m.direction == "" {
+		return HigherIsBetter
+	}
+	return m.direction
+}
+
+func (m *fixedMetric) Evaluate(input llmops.EvalInput) (llmops.MetricScore, error) {
+	if m.err != nil {
+		return llmops.MetricScore{Name: m.name, Error: m.err.Error()}, m.err
+	}
+	return llmops.MetricScore{Name: m.name, Score: m.score}, nil
+}
+
+func TestMetricDirection(t *testing.T) {
+	if d := MetricDirection(NewHallucinationMetric(nil)); d != LowerIsBetter {
+		t.Errorf("expected hallucination to be lower-is-better, got %s", d)
+	}
+	if d := MetricDirection(NewToxicityMetric(nil)); d != LowerIsBetter {
+		t.Errorf("expected toxicity to be lower-is-better, got %s", d)
+	}
+	if d := MetricDirection(NewExactMatchMetric()); d != HigherIsBetter {
+		t.Errorf("expected exact match to be higher-is-better, got %s", d)
+	}
+}
+
+func TestCompositeMetric(t *testing.T) {
+	relevance := &fixedMetric{name: "relevance", score: 0.8}
+	hallucination := &fixedMetric{name: "hallucination", score: 0.4, direction: LowerIsBetter}
+
+	tests := []struct {
+		name   string
+		metric *CompositeMetric
+		want   float64
+	}{
+		{"weighted mean", NewWeightedMeanMetric("quality", Weighted(relevance, 3), Weighted(hallucination, 1)), 0.75},
+		{"min", NewMinMetric("quality", relevance, hallucination), 0.6},
+		{"max", NewMaxMetric("quality", relevance, hallucination), 0.8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, err := tt.metric.Evaluate(llmops.EvalInput{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if score.Name != "quality" || math.Abs(score.Score-tt.want) > 1e-9 {
+				t.Errorf("expected quality score %f, got %s %f", tt.want, score.Name, score.Score)
+			}
+		})
+	}
+
+	failing := NewMinMetric("quality", relevance, &fixedMetric{name: "broken", err: errors.New("boom")})
+	if _, err := failing.Evaluate(llmops.EvalInput{}); err == nil {
+		t.Error("expected error when a component fails")
+	}
+	if _, err := NewWeightedMeanMetric("quality", Weighted(relevance, 0)).Evaluate(llmops.EvalInput{}); err == nil {
+		t.Error("expected error when weights sum to zero")
+	}
+	duplicate := NewMaxMetric("quality", relevance, &fixedMetric{name: "relevance", score: 0.1})
+	if _, err := duplicate.Evaluate(llmops.EvalInput{}); err == nil {
+		t.Error("expected error for duplicate component names")
+	}
+}
+
+func TestThresholdMetric(t *testing.T) {
+	tests := []struct {
+		name   string
+		metric *ThresholdMetric
+		passed bool
+	}{
+		{"higher passes", NewThresholdMetric(&fixedMetric{name: "m", score: 0.8}, 0.8), true},
+		{"higher fails", NewThresholdMetric(&fixedMetric{name: "m", score: 0.7}, 0.8), false},
+		{"lower passes", NewThresholdMetric(&fixedMetric{name: "m", score: 0.2, direction: LowerIsBetter}, 0.5), true},
+		{"lower fails", NewThresholdMetric(&fixedMetric{name: "m", score: 0.9, direction: LowerIsBetter}, 0.5), false},
+		{"direction override", NewThresholdMetric(&fixedMetric{name: "m", score: 0.9}, 0.5, WithDirection(LowerIsBetter)), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, err := tt.metric.Evaluate(llmops.EvalInput{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			md := score.Metadata.(map[string]any)
+			if md["passed"] != tt.passed {
+				t.Errorf("expected passed=%v, got %v", tt.passed, md["passed"])
+			}
+		})
+	}
+
+	// Wrapped metadata is kept
+	m := NewThresholdMetric(NewExactMatchMetric(), 1.0)
+	score, _ := m.Evaluate(llmops.EvalInput{Output: "a", Expected: "a"})
+	if md := score.Metadata.(map[string]any); md["passed"] != true || md["threshold"] != 1.0 {
+		t.Errorf("unexpected metadata: %v", md)
+	}
+}
+
+func TestSummarizeResult(t *testing.T) {
+	metrics := []llmops.Metric{
+		NewThresholdMetric(&fixedMetric{name: "relevance", score: 0.9}, 0.7),
+		NewThresholdMetric(&fixedMetric{name: "hallucination", score: 0.8, direction: LowerIsBetter}, 0.5),
+		NewThresholdMetric(&fixedMetric{name: "faithfulness", err: errors.New("boom")}, 0.5),
+		&fixedMetric{name: "informational", score: 0.1},
+	}
+	result := &llmops.EvalResult{}
+	for _, m := range metrics {
+		score, _ := m.Evaluate(llmops.EvalInput{})
+		result.Scores = append(result.Scores, score)
+	}
+
+	s := SummarizeResult(result)
+	if s.Passed {
+		t.Error("expected evaluation to fail")
+	}
+	if s.Total != 4 || s.Gated != 3 || s.Errors != 1 || len(s.Failures) != 2 {
+		t.Errorf("unexpected summary: %+v", s)
+	}
+	if s.Failures[0].Name != "hallucination" || s.Failures[0].Direction != LowerIsBetter || s.Failures[0].Threshold != 0.5 {
+		t.Errorf("unexpected failure: %+v", s.Failures[0])
+	}
+	if err := s.Err(); !errors.Is(err, ErrThresholdFailed) || !strings.Contains(err.Error(), "faithfulness") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if s := SummarizeResult(&llmops.EvalResult{Scores: result.Scores[:1]}); !s.Passed || s.Err() != nil {
+		t.Errorf("expected evaluation to pass, got %+v", s)
+	}
+
+	// A failed score without threshold metadata, as evaluators record
+	// metrics returning an error, still fails the evaluation
+	s = SummarizeResult(&llmops.EvalResult{Scores: []llmops.MetricScore{
+		result.Scores[0],
+		{Name: "faithfulness", Error: "boom"},
+	}})
+	if s.Passed || s.Gated != 1 || s.Errors != 1 || len(s.Failures) != 1 || s.Failures[0].Error != "boom" {
+		t.Errorf("expected the failed score to fail the evaluation, got %+v", s)
+	}
+}
+
+// =============================================================================
+// LLM Cache Tests (fake LLM)
+// =============================================================================
+
+// newCountingClient returns a client answering every classification with
+// label, counting the requests it receives.
+func newCountingClient(t *testing.T, label string, calls *int) *omnillm.ChatClient {
+	t.Helper()
+
+	client, err := omnillm.NewClient(omnillm.ClientConfig{
+		CustomProvider: &fakeProvider{respond: func(req *provider.ChatCompletionRequest) string {
+			*calls++
+			return `{"label": "` + label + `", "explanation": "recorded"}`
+		}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create omnillm client: %v", err)
+	}
+	return client
+}
+
+func TestLLM_Cache(t *testing.T) {
+	calls := 0
+	cache := NewMemoryCache()
+	llm := NewLLMWithOptions(newCountingClient(t, "factual", &calls), "fake-model", WithCache(cache))
+	m := NewHallucinationMetric(llm)
+
+	input := llmops.EvalInput{
+		Output:  "The capital of France is Paris.",
+		Context: []string{"Paris is the capital city of France."},
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := m.Evaluate(input); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if calls != 1 || cache.Len() != 1 {
+		t.Errorf("expected 1 call and 1 cached entry, got %d calls and %d entries", calls, cache.Len())
+	}
+
+	// A different prompt or different labels is a different request
+	input.Output = "The capital of France is London."
+	if _, err := m.Evaluate(input); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := llm.Classify(context.Background(), "Is the sky blue?", []string{"yes", "no"}, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := llm.Classify(context.Background(), "Is the sky blue?", []string{"yes", "no", "maybe"}, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 4 {
+		t.Errorf("expected 4 calls, got %d", calls)
+	}
+
+	// Record mode always calls the judge
+	recorder := NewLLMWithOptions(newCountingClient(t, "factual", &calls), "fake-model", WithCache(cache), WithCacheMode(CacheRecord))
+	if _, err := recorder.Classify(context.Background(), "Is the sky blue?", []string{"yes", "no"}, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 5 {
+		t.Errorf("expected record mode to call the judge, got %d calls", calls)
+	}
+}
+
+func TestLLM_FileCacheReplay(t *testing.T) {
+	dir := t.TempDir()
+	input := llmops.EvalInput{
+		Output:  "The capital of France is London.",
+		Context: []string{"Paris is the capital city of France."},
+	}
+
+	calls := 0
+	recorder := NewLLMWithOptions(newCountingClient(t, "hallucinated", &calls), "fake-model",
+		WithCache(NewFileCache(dir)), WithCacheMode(CacheRecord))
+	if _, err := NewHallucinationMetric(recorder).Evaluate(input); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Replay offline, without a client
+	replayer := NewLLMWithOptions(nil, "fake-model", WithCache(NewFileCache(dir)), WithCacheMode(CacheReplay))
+	score, err := NewHallucinationMetric(replayer).Evaluate(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if score.Score != 1.0 || score.Reason != "recorded" {
+		t.Errorf("expected recorded judgment, got %f %q", score.Score, score.Reason)
+	}
+
+	input.Output = "The capital of France is Paris."
+	if _, err := NewHallucinationMetric(replayer).Evaluate(input); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("expected ErrCacheMiss, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+}
+
+func TestParseCacheMode(t *testing.T) {
+	for in, want := range map[string]CacheMode{"": CacheReadWrite, "record": CacheRecord, "replay": CacheReplay} {
+		if got, err := ParseCacheMode(in); err != nil || got != want {
+			t.Errorf("ParseCacheMode(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseCacheMode("offline"); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
+
+// =============================================================================
+// Consensus and Calibration Tests
+// =============================================================================
+
+func TestConsensusMetric(t *testing.T) {
+	judges := []llmops.Metric{
+		&fixedMetric{name: "hallucination", score: 1.0, direction: LowerIsBetter},
+		&fixedMetric{name: "hallucination", score: 0.0, direction: LowerIsBetter},
+		&fixedMetric{name: "hallucination", score: 1.0, direction: LowerIsBetter},
+	}
+
+	m := NewConsensusMetric(judges)
+	score, err := m.Evaluate(llmops.EvalInput{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if score.Name != "hallucination" || score.Score != 1.0 {
+		t.Errorf("expected majority hallucination 1.0, got %s %f", score.Name, score.Score)
+	}
+	md := score.Metadata.(map[string]any)
+	if math.Abs(md["agreement"].(float64)-2.0/3.0) > 1e-9 || math.Abs(md["variance"].(float64)-2.0/9.0) > 1e-9 {
+		t.Errorf("unexpected agreement metadata: %v", md)
+	}
+
+	averaged := NewConsensusMetric(judges, WithAggregation(ConsensusMean), WithConsensusName("hallucination_mean"))
+	score, _ = averaged.Evaluate(llmops.EvalInput{})
+	if score.Name != "hallucination_mean" || math.Abs(score.Score-2.0/3.0) > 1e-9 {
+		t.Errorf("expected mean 0.667, got %s %f", score.Name, score.Score)
+	}
+	// Agreement is between judges, not with the mean
+	md = score.Metadata.(map[string]any)
+	if math.Abs(md["agreement"].(float64)-2.0/3.0) > 1e-9 || !strings.HasPrefix(score.Reason, "2 of 3 judges agree") {
+		t.Errorf("expected 2 of 3 judges to agree with the mean aggregation, got %v: %s", md["agreement"], score.Reason)
+	}
+
+	// Ties go to the worse score
+	tie := NewConsensusMetric(judges[:2])
+	if score, _ := tie.Evaluate(llmops.EvalInput{}); score.Score != 1.0 {
+		t.Errorf("expected tie to go to 1.0 for a lower-is-better metric, got %f", score.Score)
+	}
+
+	// Failed judges are left out
+	partial := NewConsensusMetric([]llmops.Metric{judges[1], &fixedMetric{name: "hallucination", err: errors.New("boom")}})
+	score, err = partial.Evaluate(llmops.EvalInput{})
+	if err != nil || score.Score != 0.0 {
+		t.Errorf("expected score from the working judge, got %f, %v", score.Score, err)
+	}
+	if !strings.HasPrefix(score.Reason, "1 of 1 judges agree") {
+		t.Errorf("expected the reason to count only scoring judges, got %q", score.Reason)
+	}
+	if partial.Agreement([]llmops.MetricScore{score}).Items != 0 {
+		t.Error("expected items with failed judges to be left out of the agreement")
+	}
+
+	failing := NewConsensusMetric([]llmops.Metric{&fixedMetric{name: "x", err: errors.New("boom")}})
+	if _, err := failing.Evaluate(llmops.EvalInput{}); err == nil {
+		t.Error("expected error when all judges fail")
+	}
+}
+
+func TestConsensusMetric_Agreement(t *testing.T) {
+	a := &fixedMetric{name: "relevance"}
+	b := &fixedMetric{name: "relevance"}
+	m := NewConsensusMetric([]llmops.Metric{a, b})
+
+	var scores []llmops.MetricScore
+	for _, pair := range [][2]float64{{1, 1}, {1, 0}, {0, 0}, {0, 0}} {
+		a.score, b.score = pair[0], pair[1]
+		score, err := m.Evaluate(llmops.EvalInput{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, ok := score.Metadata.(map[string]any)["kappa"]; ok {
+			t.Error("expected no running kappa in item metadata")
+		}
+		scores = append(scores, score)
+	}
+
+	stats := m.Agreement(scores)
+	if stats.Items != 4 || stats.KappaType != "cohen" || math.Abs(stats.Kappa-0.5) > 1e-9 {
+		t.Errorf("unexpected agreement: %+v", stats)
+	}
+	if stats := m.Agreement(scores[:1]); stats.Items != 1 {
+		t.Errorf("expected agreement over the given scores only, got %+v", stats)
+	}
+	if stats := m.Agreement(nil); stats.Items != 0 || stats.KappaType != "" {
+		t.Errorf("expected no agreement without scores, got %+v", stats)
+	}
+}
+
+func TestKappa(t *testing.T) {
+	k, err := CohenKappa([]string{"y", "y", "n", "n"}, []string{"y", "n", "n", "n"})
+	if err != nil || math.Abs(k-0.5) > 1e-9 {
+		t.Errorf("expected Cohen's kappa 0.5, got %f, %v", k, err)
+	}
+	if _, err := CohenKappa([]string{"y"}, []string{"y", "n"}); err == nil {
+		t.Error("expected error for mismatched lengths")
+	}
+
+	tests := []struct {
+		name    string
+		ratings [][]string
+		want    float64
+	}{
+		{"perfect", [][]string{{"a", "a", "a"}, {"b", "b", "b"}}, 1.0},
+		{"disagreement", [][]string{{"a", "a", "b"}, {"b", "b", "a"}}, -1.0 / 3.0},
+		{"single label", [][]string{{"a", "a", "a"}, {"a", "a", "a"}}, 1.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := FleissKappa(tt.ratings)
+			if err != nil || math.Abs(k-tt.want) > 1e-9 {
+				t.Errorf("expected Fleiss' kappa %f, got %f, %v", tt.want, k, err)
+			}
+		})
+	}
+	if _, err := FleissKappa([][]string{{"a", "b"}, {"a"}}); err == nil {
+		t.Error("expected error for varying rater counts")
+	}
+}
+
+func TestCalibrate(t *testing.T) {
+	judged := []JudgedScore{
+		{TraceID: "t1", Score: llmops.MetricScore{Name: "hallucination", Score: 1.0}},
+		{TraceID: "t2", Score: llmops.MetricScore{Name: "hallucination", Score: 0.0}},
+		{TraceID: "t3", Score: llmops.MetricScore{Name: "hallucination", Score: 1.0}},
+		{TraceID: "t4", Score: llmops.MetricScore{Name: "hallucination", Score: 0.0}},
+		{TraceID: "t5", Score: llmops.MetricScore{Name: "hallucination", Score: 0.0}},
+		{TraceID: "t6", Score: llmops.MetricScore{Name: "hallucination", Error: "boom"}},
+	}
+	annotations := []*llmops.Annotation{
+		{TraceID: "t1", Name: "hallucination", Label: "hallucinated", Source: llmops.AnnotatorKindHuman},
+		{TraceID: "t2", Name: "hallucination", Label: "factual", Source: llmops.AnnotatorKindHuman},
+		{TraceID: "t3", Name: "hallucination", Label: "factual", Source: llmops.AnnotatorKindHuman},
+		{TraceID: "t4", Name: "hallucination", Label: "factual"},
+		{TraceID: "t5", Name: "hallucination", Label: "hallucinated", Source: llmops.AnnotatorKindLLM},
+		{TraceID: "t6", Name: "hallucination", Label: "factual", Source: llmops.AnnotatorKindHuman},
+	}
+
+	report, err := Calibrate(judged, annotations, WithLabelValues(map[string]float64{"hallucinated": 1, "factual": 0}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Count != 4 || report.Unmatched != 1 {
+		t.Errorf("expected 4 matched and 1 unmatched, got %d and %d", report.Count, report.Unmatched)
+	}
+	if report.Accuracy != 0.75 || report.MeanAbsoluteError != 0.25 {
+		t.Errorf("unexpected accuracy %f and MAE %f", report.Accuracy, report.MeanAbsoluteError)
+	}
+	if math.Abs(report.Kappa-0.5) > 1e-9 {
+		t.Errorf("expected kappa 0.5, got %f", report.Kappa)
+	}
+	if report.Confusion["0"]["1"] != 1 || report.Confusion["0"]["0"] != 2 || report.Confusion["1"]["1"] != 1 {
+		t.Errorf("unexpected confusion matrix: %v", report.Confusion)
+	}
+
+	if _, err := Calibrate(judged, annotations); err == nil {
+		t.Error("expected error for labels without values")
+	}
+}
+
+// =============================================================================
+// LLM Integration Tests (skipped without an API key or recorded judgments)
+// =============================================================================
+
+// testJudgmentsDir holds judgments recorded by the integration tests.
+const testJudgmentsDir = "testdata/judgments"
+
+// getTestLLM returns an LLM for integration tests. With OPENAI_API_KEY set,
+// it calls the judge; METRICS_CACHE_MODE=record also records its judgments
+// to testJudgmentsDir, and METRICS_CACHE_MODE=replay replays them instead.
+// Without a key, recorded judgments are replayed, failing on requests that
+// were not recorded, and the test is skipped if there are none.
 func getTestLLM(t *testing.T) *LLM {
 	t.Helper()
 
+	cache := NewFileCache(testJudgmentsDir)
 	apiKey := os.Getenv("OPENAI_API_KEY")
-	if apiKey == "" {
-		t.Skip("OPENAI_API_KEY not set, skipping LLM integration test")
+	mode := CacheMode(os.Getenv("METRICS_CACHE_MODE"))
+	if apiKey == "" || mode == CacheReplay {
+		recordings, _ := filepath.Glob(filepath.Join(testJudgmentsDir, "*.json"))
+		if len(recordings) == 0 {
+			t.Skip("OPENAI_API_KEY not set and no recorded judgments, skipping LLM integration test")
+		}
+		return NewLLMWithOptions(nil, "gpt-4o-mini", WithCache(cache), WithCacheMode(CacheReplay))
 	}
 
 	client, err := omnillm.NewClient(omnillm.ClientConfig{
@@ -395,7 +1743,15 @@ func getTestLLM(t *testing.T) *LLM {
 		t.Fatalf("failed to create omnillm client: %v", err)
 	}
 
-	return NewLLM(client, "gpt-4o-mini")
+	switch mode {
+	case "":
+		return NewLLM(client, "gpt-4o-mini")
+	case CacheRecord:
+		return NewLLMWithOptions(client, "gpt-4o-mini", WithCache(cache), WithCacheMode(CacheRecord))
+	default:
+		t.Fatalf("invalid METRICS_CACHE_MODE %q: use record or replay", mode)
+		return nil
+	}
 }
 
 func TestLLM_Classify(t *testing.T) {
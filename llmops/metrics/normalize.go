@@ -0,0 +1,141 @@
+package metrics
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Normalization controls how text is normalized before comparison by the
+// code-based text metrics. The zero value leaves text unchanged.
+type Normalization struct {
+	// Lowercase converts text to lowercase.
+	Lowercase bool
+
+	// CollapseWhitespace trims text and replaces runs of whitespace with a
+	// single space.
+	CollapseWhitespace bool
+
+	// RemovePunctuation removes punctuation characters.
+	RemovePunctuation bool
+
+	// RemoveArticles removes the English articles "a", "an", and "the".
+	RemoveArticles bool
+}
+
+// SQuADNormalization is the normalization used by the SQuAD evaluation
+// script: lowercase, no punctuation, no articles, and collapsed whitespace.
+var SQuADNormalization = Normalization{
+	Lowercase:          true,
+	CollapseWhitespace: true,
+	RemovePunctuation:  true,
+	RemoveArticles:     true,
+}
+
+// NormalizeOption configures a Normalization.
+type NormalizeOption func(*Normalization)
+
+// WithLowercase sets whether text is lowercased.
+func WithLowercase(lowercase bool) NormalizeOption {
+	return func(n *Normalization) {
+		n.Lowercase = lowercase
+	}
+}
+
+// WithCollapseWhitespace sets whether whitespace is trimmed and collapsed.
+func WithCollapseWhitespace(collapse bool) NormalizeOption {
+	return func(n *Normalization) {
+		n.CollapseWhitespace = collapse
+	}
+}
+
+// WithRemovePunctuation sets whether punctuation is removed.
+func WithRemovePunctuation(remove bool) NormalizeOption {
+	return func(n *Normalization) {
+		n.RemovePunctuation = remove
+	}
+}
+
+// WithRemoveArticles sets whether articles are removed.
+func WithRemoveArticles(remove bool) NormalizeOption {
+	return func(n *Normalization) {
+		n.RemoveArticles = remove
+	}
+}
+
+// WithNormalization replaces all normalization settings.
+func WithNormalization(normalization Normalization) NormalizeOption {
+	return func(n *Normalization) {
+		*n = normalization
+	}
+}
+
+// applyNormalizeOptions applies opts to a copy of n.
+func applyNormalizeOptions(n Normalization, opts []NormalizeOption) Normalization {
+	for _, opt := range opts {
+		opt(&n)
+	}
+	return n
+}
+
+// Apply normalizes s. Steps run in the order of the SQuAD evaluation
+// script: lowercase, punctuation, articles, then whitespace.
+func (n Normalization) Apply(s string) string {
+	if n.Lowercase {
+		s = strings.ToLower(s)
+	}
+	if n.RemovePunctuation {
+		s = strings.Map(func(r rune) rune {
+			if unicode.IsPunct(r) {
+				return -1
+			}
+			return r
+		}, s)
+	}
+	if n.RemoveArticles {
+		s = removeArticles(s)
+	}
+	if n.CollapseWhitespace {
+		s = strings.Join(strings.Fields(s), " ")
+	}
+	return s
+}
+
+// Tokens normalizes s and splits it into whitespace-separated tokens.
+func (n Normalization) Tokens(s string) []string {
+	return strings.Fields(n.Apply(s))
+}
+
+// removeArticles replaces whole-word articles with a space.
+func removeArticles(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	start := -1
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		if isArticle(s[start:end]) {
+			b.WriteByte(' ')
+		} else {
+			b.WriteString(s[start:end])
+		}
+		start = -1
+	}
+	for i, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		flush(i)
+		b.WriteRune(r)
+	}
+	flush(len(s))
+	return b.String()
+}
+
+// isArticle reports whether word is an English article, ignoring case.
+func isArticle(word string) bool {
+	return strings.EqualFold(word, "a") || strings.EqualFold(word, "an") || strings.EqualFold(word, "the")
+}
@@ -0,0 +1,489 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/agentplexus/omniobserve/llmops"
+)
+
+// The text overlap metrics compare EvalInput.Output to EvalInput.Expected
+// without an LLM. Expected may be a string or a []string of reference
+// texts. BLEU scores against all references together; the other metrics
+// use the best-matching reference. Text is normalized with a Normalization
+// before comparison.
+
+// =============================================================================
+// BLEU
+// =============================================================================
+
+// BLEUMetric is a code-based metric computing sentence-level BLEU: the
+// geometric mean of clipped n-gram precisions up to MaxOrder, multiplied by
+// a brevity penalty for outputs shorter than the closest reference.
+type BLEUMetric struct {
+	// MaxOrder is the largest n-gram size. Default is 4.
+	MaxOrder int
+
+	// Smoothing adds one to the matches and counts of n-grams larger than
+	// unigrams, so that short outputs without a higher-order match do not
+	// score zero. Default is true.
+	Smoothing bool
+
+	// Normalization is applied before tokenizing. Default lowercases,
+	// removes punctuation, and collapses whitespace.
+	Normalization Normalization
+}
+
+// NewBLEUMetric creates a BLEU metric.
+func NewBLEUMetric(opts ...NormalizeOption) *BLEUMetric {
+	return &BLEUMetric{
+		MaxOrder:      4,
+		Smoothing:     true,
+		Normalization: applyNormalizeOptions(defaultOverlapNormalization, opts),
+	}
+}
+
+// Name returns the metric name.
+func (m *BLEUMetric) Name() string {
+	return "bleu"
+}
+
+// Evaluate computes the BLEU score of the output against the references.
+// The metadata contains the precision of each n-gram order and the brevity
+// penalty.
+func (m *BLEUMetric) Evaluate(input llmops.EvalInput) (llmops.MetricScore, error) {
+	output, references, err := overlapInputs(m.Name(), input)
+	if err != nil {
+		return llmops.MetricScore{Name: m.Name(), Error: err.Error()}, err
+	}
+
+	maxOrder := m.MaxOrder
+	if maxOrder <= 0 {
+		maxOrder = 4
+	}
+
+	hyp := m.Normalization.Tokens(output)
+	refs := make([][]string, len(references))
+	for i, ref := range references {
+		refs[i] = m.Normalization.Tokens(ref)
+	}
+
+	precisions := make([]float64, maxOrder)
+	logSum := 0.0
+	for n := 1; n <= maxOrder; n++ {
+		hypCounts := ngramCounts(hyp, n)
+		maxRefCounts := make(map[string]int)
+		for _, ref := range refs {
+			for gram, count := range ngramCounts(ref, n) {
+				if count > maxRefCounts[gram] {
+					maxRefCounts[gram] = count
+				}
+			}
+		}
+
+		matches, total := 0, 0
+		for gram, count := range hypCounts {
+			matches += min(count, maxRefCounts[gram])
+			total += count
+		}
+
+		var p float64
+		switch {
+		case m.Smoothing && n > 1:
+			p = float64(matches+1) / float64(total+1)
+		case total > 0:
+			p = float64(matches) / float64(total)
+		}
+		precisions[n-1] = p
+		if p == 0 {
+			logSum = math.Inf(-1)
+		} else {
+			logSum += math.Log(p)
+		}
+	}
+
+	refLen := closestLength(len(hyp), refs)
+	brevityPenalty := 0.0
+	switch {
+	case len(hyp) == 0:
+	case len(hyp) > refLen:
+		brevityPenalty = 1
+	default:
+		brevityPenalty = math.Exp(1 - float64(refLen)/float64(len(hyp)))
+	}
+
+	score := 0.0
+	if !math.IsInf(logSum, -1) {
+		score = brevityPenalty * math.Exp(logSum/float64(maxOrder))
+	}
+
+	return llmops.MetricScore{
+		Name:   m.Name(),
+		Score:  score,
+		Reason: fmt.Sprintf("BLEU-%d score %.3f", maxOrder, score),
+		Metadata: map[string]any{
+			"precisions":       precisions,
+			"brevity_penalty":  brevityPenalty,
+			"output_length":    len(hyp),
+			"reference_length": refLen,
+		},
+	}, nil
+}
+
+// closestLength returns the reference length closest to length, preferring
+// the shorter on ties.
+func closestLength(length int, refs [][]string) int {
+	best := -1
+	for _, ref := range refs {
+		diff := abs(len(ref) - length)
+		if best < 0 || diff < abs(best-length) || (diff == abs(best-length) && len(ref) < best) {
+			best = len(ref)
+		}
+	}
+	return max(best, 0)
+}
+
+// =============================================================================
+// ROUGE
+// =============================================================================
+
+// ROUGEVariant selects the ROUGE measure.
+type ROUGEVariant string
+
+const (
+	// ROUGE1 measures unigram overlap.
+	ROUGE1 ROUGEVariant = "rouge1"
+	// ROUGE2 measures bigram overlap.
+	ROUGE2 ROUGEVariant = "rouge2"
+	// ROUGEL measures the longest common subsequence.
+	ROUGEL ROUGEVariant = "rougeL"
+)
+
+// ROUGEMetric is a code-based metric computing ROUGE-1, ROUGE-2, or ROUGE-L.
+// The score is the F-measure; precision and recall are in the metadata.
+type ROUGEMetric struct {
+	// Variant selects the measure.
+	Variant ROUGEVariant
+
+	// Normalization is applied before tokenizing. Default lowercases,
+	// removes punctuation, and collapses whitespace.
+	Normalization Normalization
+}
+
+// NewROUGEMetric creates a ROUGE metric of the given variant.
+func NewROUGEMetric(variant ROUGEVariant, opts ...NormalizeOption) *ROUGEMetric {
+	return &ROUGEMetric{
+		Variant:       variant,
+		Normalization: applyNormalizeOptions(defaultOverlapNormalization, opts),
+	}
+}
+
+// Name returns the metric name: "rouge_1", "rouge_2", or "rouge_l".
+func (m *ROUGEMetric) Name() string {
+	return "rouge_" + strings.ToLower(strings.TrimPrefix(string(m.Variant), "rouge"))
+}
+
+// Evaluate computes the ROUGE F-measure of the output against the best
+// matching reference.
+func (m *ROUGEMetric) Evaluate(input llmops.EvalInput) (llmops.MetricScore, error) {
+	output, references, err := overlapInputs(m.Name(), input)
+	if err == nil && m.Variant != ROUGE1 && m.Variant != ROUGE2 && m.Variant != ROUGEL {
+		err = fmt.Errorf("unknown ROUGE variant %q", m.Variant)
+	}
+	if err != nil {
+		return llmops.MetricScore{Name: m.Name(), Error: err.Error()}, err
+	}
+
+	hyp := m.Normalization.Tokens(output)
+	best := prf{}
+	bestIdx := 0
+	for i, reference := range references {
+		ref := m.Normalization.Tokens(reference)
+
+		var overlap, hypTotal, refTotal int
+		switch m.Variant {
+		case ROUGEL:
+			overlap, hypTotal, refTotal = LCSLength(hyp, ref), len(hyp), len(ref)
+		default:
+			n := 1
+			if m.Variant == ROUGE2 {
+				n = 2
+			}
+			overlap, hypTotal, refTotal = ngramOverlap(ngramCounts(hyp, n), ngramCounts(ref, n))
+		}
+
+		s := newPRF(overlap, hypTotal, refTotal)
+		if i == 0 || s.f1 > best.f1 {
+			best, bestIdx = s, i
+		}
+	}
+
+	return llmops.MetricScore{
+		Name:     m.Name(),
+		Score:    best.f1,
+		Reason:   fmt.Sprintf("%s F-measure %.3f (precision %.3f, recall %.3f)", m.Variant, best.f1, best.precision, best.recall),
+		Metadata: best.metadata(references, bestIdx),
+	}, nil
+}
+
+// =============================================================================
+// Token F1
+// =============================================================================
+
+// TokenF1Metric is a code-based metric computing the SQuAD-style F1 of the
+// tokens shared by the output and the expected answer, regardless of order.
+type TokenF1Metric struct {
+	// Normalization is applied before tokenizing. Default is
+	// SQuADNormalization.
+	Normalization Normalization
+}
+
+// NewTokenF1Metric creates a token F1 metric.
+func NewTokenF1Metric(opts ...NormalizeOption) *TokenF1Metric {
+	return &TokenF1Metric{
+		Normalization: applyNormalizeOptions(SQuADNormalization, opts),
+	}
+}
+
+// Name returns the metric name.
+func (m *TokenF1Metric) Name() string {
+	return "token_f1"
+}
+
+// Evaluate computes the token F1 of the output against the best matching
+// expected answer. If both normalize to no tokens, the score is 1.0.
+func (m *TokenF1Metric) Evaluate(input llmops.EvalInput) (llmops.MetricScore, error) {
+	output, references, err := overlapInputs(m.Name(), input)
+	if err != nil {
+		return llmops.MetricScore{Name: m.Name(), Error: err.Error()}, err
+	}
+
+	hyp := ngramCounts(m.Normalization.Tokens(output), 1)
+	best := prf{}
+	bestIdx := 0
+	for i, reference := range references {
+		ref := ngramCounts(m.Normalization.Tokens(reference), 1)
+
+		var s prf
+		overlap, hypTotal, refTotal := ngramOverlap(hyp, ref)
+		if hypTotal == 0 && refTotal == 0 {
+			s = prf{precision: 1, recall: 1, f1: 1}
+		} else {
+			s = newPRF(overlap, hypTotal, refTotal)
+		}
+		if i == 0 || s.f1 > best.f1 {
+			best, bestIdx = s, i
+		}
+	}
+
+	return llmops.MetricScore{
+		Name:     m.Name(),
+		Score:    best.f1,
+		Reason:   fmt.Sprintf("Token F1 %.3f (precision %.3f, recall %.3f)", best.f1, best.precision, best.recall),
+		Metadata: best.metadata(references, bestIdx),
+	}, nil
+}
+
+// =============================================================================
+// Levenshtein
+// =============================================================================
+
+// LevenshteinMetric is a code-based metric scoring the character edit
+// distance between the output and the expected answer, normalized to a
+// similarity: 1 - distance / max(len(output), len(expected)).
+type LevenshteinMetric struct {
+	// Normalization is applied before comparing. Default lowercases and
+	// collapses whitespace.
+	Normalization Normalization
+}
+
+// NewLevenshteinMetric creates a normalized Levenshtein similarity metric.
+func NewLevenshteinMetric(opts ...NormalizeOption) *LevenshteinMetric {
+	return &LevenshteinMetric{
+		Normalization: applyNormalizeOptions(Normalization{
+			Lowercase:          true,
+			CollapseWhitespace: true,
+		}, opts),
+	}
+}
+
+// Name returns the metric name.
+func (m *LevenshteinMetric) Name() string {
+	return "levenshtein_similarity"
+}
+
+// Evaluate computes the normalized Levenshtein similarity of the output to
+// the closest expected answer. The metadata contains the edit distance.
+func (m *LevenshteinMetric) Evaluate(input llmops.EvalInput) (llmops.MetricScore, error) {
+	output, references, err := overlapInputs(m.Name(), input)
+	if err != nil {
+		return llmops.MetricScore{Name: m.Name(), Error: err.Error()}, err
+	}
+
+	out := []rune(m.Normalization.Apply(output))
+	bestScore, bestDistance, bestLen, bestIdx := -1.0, 0, 0, 0
+	for i, reference := range references {
+		ref := []rune(m.Normalization.Apply(reference))
+		distance := levenshtein(out, ref)
+		score := 1.0
+		if longest := max(len(out), len(ref)); longest > 0 {
+			score = 1 - float64(distance)/float64(longest)
+		}
+		if score > bestScore {
+			bestScore, bestDistance, bestLen, bestIdx = score, distance, len(ref), i
+		}
+	}
+
+	metadata := map[string]any{
+		"distance":        bestDistance,
+		"output_length":   len(out),
+		"expected_length": bestLen,
+	}
+	if len(references) > 1 {
+		metadata["best_match"] = references[bestIdx]
+	}
+
+	return llmops.MetricScore{
+		Name:     m.Name(),
+		Score:    bestScore,
+		Reason:   fmt.Sprintf("Edit distance %d (similarity %.3f)", bestDistance, bestScore),
+		Metadata: metadata,
+	}, nil
+}
+
+// LevenshteinDistance returns the number of single-character insertions,
+// deletions, and substitutions needed to turn a into b.
+func LevenshteinDistance(a, b string) int {
+	return levenshtein([]rune(a), []rune(b))
+}
+
+// levenshtein computes the edit distance of two rune slices using a single
+// row of the dynamic programming table.
+func levenshtein(a, b []rune) int {
+	row := make([]int, len(b)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		prev := row[0]
+		row[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur := min(row[j]+1, row[j-1]+1, prev+cost)
+			prev = row[j]
+			row[j] = cur
+		}
+	}
+	return row[len(b)]
+}
+
+// =============================================================================
+// Shared Helpers
+// =============================================================================
+
+// defaultOverlapNormalization is the default normalization of BLEU and ROUGE.
+var defaultOverlapNormalization = Normalization{
+	Lowercase:          true,
+	CollapseWhitespace: true,
+	RemovePunctuation:  true,
+}
+
+// overlapInputs returns the output and reference texts of input, or an
+// error if there are no references.
+func overlapInputs(name string, input llmops.EvalInput) (string, []string, error) {
+	references := expectedStrings(input.Expected)
+	if len(references) == 0 {
+		return "", nil, fmt.Errorf("no expected output provided for %s", name)
+	}
+	return toString(input.Output), references, nil
+}
+
+// prf holds precision, recall, and F1.
+type prf struct {
+	precision float64
+	recall    float64
+	f1        float64
+}
+
+// newPRF computes precision and recall from an overlap count and the totals
+// of the output and reference.
+func newPRF(overlap, hypTotal, refTotal int) prf {
+	var s prf
+	if hypTotal > 0 {
+		s.precision = float64(overlap) / float64(hypTotal)
+	}
+	if refTotal > 0 {
+		s.recall = float64(overlap) / float64(refTotal)
+	}
+	if s.precision+s.recall > 0 {
+		s.f1 = 2 * s.precision * s.recall / (s.precision + s.recall)
+	}
+	return s
+}
+
+// metadata returns the score breakdown, including the best matching
+// reference when there are several.
+func (s prf) metadata(references []string, bestIdx int) map[string]any {
+	metadata := map[string]any{
+		"precision": s.precision,
+		"recall":    s.recall,
+		"f1":        s.f1,
+	}
+	if len(references) > 1 {
+		metadata["best_match"] = references[bestIdx]
+	}
+	return metadata
+}
+
+// ngramCounts counts the n-grams of tokens.
+func ngramCounts(tokens []string, n int) map[string]int {
+	counts := make(map[string]int)
+	for i := 0; i+n <= len(tokens); i++ {
+		counts[strings.Join(tokens[i:i+n], " ")]++
+	}
+	return counts
+}
+
+// ngramOverlap returns the clipped number of shared n-grams and the total
+// n-gram counts of each side.
+func ngramOverlap(hyp, ref map[string]int) (overlap, hypTotal, refTotal int) {
+	for gram, count := range hyp {
+		overlap += min(count, ref[gram])
+		hypTotal += count
+	}
+	for _, count := range ref {
+		refTotal += count
+	}
+	return overlap, hypTotal, refTotal
+}
+
+// LCSLength returns the length of the longest common subsequence of a and
+// b. It is used by ROUGE-L and by sequence metrics outside this package.
+func LCSLength(a, b []string) int {
+	row := make([]int, len(b)+1)
+	for i := 1; i <= len(a); i++ {
+		prev := 0
+		for j := 1; j <= len(b); j++ {
+			cur := row[j]
+			if a[i-1] == b[j-1] {
+				row[j] = prev + 1
+			} else if row[j-1] > row[j] {
+				row[j] = row[j-1]
+			}
+			prev = cur
+		}
+	}
+	return row[len(b)]
+}
+
+// abs returns the absolute value of x.
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
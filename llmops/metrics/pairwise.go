@@ -0,0 +1,321 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"sync"
+
+	"github.com/agentplexus/omniobserve/llmops"
+)
+
+// PairwiseOutcome is the result of comparing a candidate output with a
+// baseline, from the candidate's perspective.
+type PairwiseOutcome string
+
+const (
+	PairwiseWin  PairwiseOutcome = "win"
+	PairwiseTie  PairwiseOutcome = "tie"
+	PairwiseLoss PairwiseOutcome = "loss"
+)
+
+// Score returns 1.0 for a win, 0.5 for a tie, and 0.0 for a loss.
+func (o PairwiseOutcome) Score() float64 {
+	switch o {
+	case PairwiseWin:
+		return 1.0
+	case PairwiseTie:
+		return 0.5
+	default:
+		return 0.0
+	}
+}
+
+// PairwiseJudgment is a single judge call with the outputs in one order.
+type PairwiseJudgment struct {
+	CandidateFirst bool            `json:"candidate_first"` // Whether the candidate was Response A
+	Outcome        PairwiseOutcome `json:"outcome"`
+	Reason         string          `json:"reason,omitempty"`
+}
+
+// PairwiseResult is the result of comparing a candidate with a baseline.
+type PairwiseResult struct {
+	Outcome   PairwiseOutcome    `json:"outcome"`
+	Reason    string             `json:"reason,omitempty"`
+	Judgments []PairwiseJudgment `json:"judgments"`
+
+	// Consistent reports whether the judgments agreed. Inconsistent
+	// judgments indicate position bias and result in a tie.
+	Consistent bool `json:"consistent"`
+}
+
+// PairwiseJudge is an LLM-based judge comparing two outputs for the same
+// input. To control position bias, it judges both orders by default,
+// starting with a random one, and only declares a winner when both
+// judgments agree.
+//
+// PairwiseJudge also implements llmops.Metric, comparing EvalInput.Output
+// as the candidate with EvalInput.Expected as the baseline.
+type PairwiseJudge struct {
+	llm                *LLM
+	criteria           string
+	swap               bool
+	includeExplanation bool
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+// DefaultPairwiseCriteria is the criteria used when none are given.
+const DefaultPairwiseCriteria = "Which response is more helpful, accurate, and relevant to the input."
+
+// PairwiseOption configures the PairwiseJudge.
+type PairwiseOption func(*PairwiseJudge)
+
+// WithPairwiseCriteria sets what the judge compares the outputs on.
+func WithPairwiseCriteria(criteria string) PairwiseOption {
+	return func(j *PairwiseJudge) {
+		j.criteria = criteria
+	}
+}
+
+// WithPositionSwap sets whether both orders are judged. When disabled, a
+// single judgment is made with the order chosen at random. Default is true.
+func WithPositionSwap(swap bool) PairwiseOption {
+	return func(j *PairwiseJudge) {
+		j.swap = swap
+	}
+}
+
+// WithRandomSource sets the source of the random order, for reproducible
+// comparisons.
+func WithRandomSource(rnd *rand.Rand) PairwiseOption {
+	return func(j *PairwiseJudge) {
+		j.rnd = rnd
+	}
+}
+
+// NewPairwiseJudge creates a new pairwise judge.
+func NewPairwiseJudge(llm *LLM, opts ...PairwiseOption) *PairwiseJudge {
+	j := &PairwiseJudge{
+		llm:                llm,
+		criteria:           DefaultPairwiseCriteria,
+		swap:               true,
+		includeExplanation: true,
+	}
+	for _, opt := range opts {
+		opt(j)
+	}
+	if j.rnd == nil {
+		j.rnd = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+	return j
+}
+
+// Compare judges whether the candidate output is better than the baseline
+// for the given input.
+func (j *PairwiseJudge) Compare(ctx context.Context, input, candidate, baseline any) (*PairwiseResult, error) {
+	in := toString(input)
+	cand := toString(candidate)
+	base := toString(baseline)
+	if cand == "" || base == "" {
+		return nil, fmt.Errorf("candidate and baseline outputs are required for pairwise comparison")
+	}
+
+	j.mu.Lock()
+	candidateFirst := j.rnd.IntN(2) == 0
+	j.mu.Unlock()
+
+	orders := []bool{candidateFirst}
+	if j.swap {
+		orders = append(orders, !candidateFirst)
+	}
+
+	result := &PairwiseResult{Consistent: true}
+	for _, first := range orders {
+		judgment, err := j.judge(ctx, in, cand, base, first)
+		if err != nil {
+			return nil, err
+		}
+		result.Judgments = append(result.Judgments, judgment)
+	}
+
+	result.Outcome = result.Judgments[0].Outcome
+	result.Reason = result.Judgments[0].Reason
+	for _, jd := range result.Judgments[1:] {
+		if jd.Outcome != result.Outcome {
+			result.Consistent = false
+		}
+	}
+	if !result.Consistent {
+		result.Outcome = PairwiseTie
+		result.Reason = "Judgments disagreed when the order was swapped"
+	}
+	return result, nil
+}
+
+// judge makes a single judgment with the candidate as Response A if
+// candidateFirst is set.
+func (j *PairwiseJudge) judge(ctx context.Context, input, candidate, baseline string, candidateFirst bool) (PairwiseJudgment, error) {
+	a, b := baseline, candidate
+	if candidateFirst {
+		a, b = candidate, baseline
+	}
+	if input == "" {
+		input = "(none)"
+	}
+
+	prompt := strings.ReplaceAll(PairwiseTemplate, "{{input}}", input)
+	prompt = strings.ReplaceAll(prompt, "{{response_a}}", a)
+	prompt = strings.ReplaceAll(prompt, "{{response_b}}", b)
+	prompt = strings.ReplaceAll(prompt, "{{criteria}}", j.criteria)
+
+	labels := []string{"A", "B", "tie"}
+	result, err := j.llm.Classify(ctx, prompt, labels, j.includeExplanation)
+	if err != nil {
+		return PairwiseJudgment{}, err
+	}
+
+	var outcome PairwiseOutcome
+	switch result.Label {
+	case "A":
+		outcome = PairwiseLoss
+		if candidateFirst {
+			outcome = PairwiseWin
+		}
+	case "B":
+		outcome = PairwiseWin
+		if candidateFirst {
+			outcome = PairwiseLoss
+		}
+	case "tie":
+		outcome = PairwiseTie
+	default:
+		return PairwiseJudgment{}, fmt.Errorf("unexpected pairwise label %q", result.Label)
+	}
+
+	return PairwiseJudgment{
+		CandidateFirst: candidateFirst,
+		Outcome:        outcome,
+		Reason:         result.Explanation,
+	}, nil
+}
+
+// Name returns the metric name.
+func (j *PairwiseJudge) Name() string {
+	return "pairwise_preference"
+}
+
+// Evaluate compares EvalInput.Output, the candidate, with
+// EvalInput.Expected, the baseline, for EvalInput.Input.
+// Returns 1.0 if the candidate wins, 0.5 for a tie, and 0.0 if it loses.
+func (j *PairwiseJudge) Evaluate(input llmops.EvalInput) (llmops.MetricScore, error) {
+	result, err := j.Compare(context.Background(), input.Input, input.Output, input.Expected)
+	if err != nil {
+		return failedScore(j.Name(), err)
+	}
+
+	return llmops.MetricScore{
+		Name:   j.Name(),
+		Score:  result.Outcome.Score(),
+		Reason: result.Reason,
+		Metadata: map[string]any{
+			"outcome":    string(result.Outcome),
+			"consistent": result.Consistent,
+			"judgments":  result.Judgments,
+		},
+	}, nil
+}
+
+// =============================================================================
+// Aggregation
+// =============================================================================
+
+// RateEstimate is a proportion with a confidence interval.
+type RateEstimate struct {
+	Rate  float64 `json:"rate"`
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+}
+
+// PairwiseSummary aggregates pairwise results over a dataset.
+type PairwiseSummary struct {
+	Total  int `json:"total"`
+	Wins   int `json:"wins"`
+	Ties   int `json:"ties"`
+	Losses int `json:"losses"`
+
+	WinRate  RateEstimate `json:"win_rate"`
+	TieRate  RateEstimate `json:"tie_rate"`
+	LossRate RateEstimate `json:"loss_rate"`
+
+	// Score is the mean outcome score, counting ties as half a win.
+	Score float64 `json:"score"`
+
+	// Inconsistent counts results whose judgments disagreed across orders.
+	Inconsistent int `json:"inconsistent"`
+
+	Confidence float64 `json:"confidence"`
+}
+
+// SummarizePairwise computes win, tie, and loss rates of the candidate with
+// Wilson score intervals at the given confidence level, such as 0.95. Nil
+// results are skipped.
+func SummarizePairwise(results []*PairwiseResult, confidence float64) PairwiseSummary {
+	s := PairwiseSummary{Confidence: confidence}
+	for _, r := range results {
+		if r == nil {
+			continue
+		}
+		s.Total++
+		switch r.Outcome {
+		case PairwiseWin:
+			s.Wins++
+		case PairwiseTie:
+			s.Ties++
+		case PairwiseLoss:
+			s.Losses++
+		}
+		if !r.Consistent {
+			s.Inconsistent++
+		}
+	}
+
+	z := zScore(confidence)
+	s.WinRate = wilsonInterval(s.Wins, s.Total, z)
+	s.TieRate = wilsonInterval(s.Ties, s.Total, z)
+	s.LossRate = wilsonInterval(s.Losses, s.Total, z)
+	if s.Total > 0 {
+		s.Score = (float64(s.Wins) + 0.5*float64(s.Ties)) / float64(s.Total)
+	}
+	return s
+}
+
+// wilsonInterval returns the proportion successes/n with its Wilson score
+// interval for the given z-score.
+func wilsonInterval(successes, n int, z float64) RateEstimate {
+	if n == 0 {
+		return RateEstimate{}
+	}
+	p := float64(successes) / float64(n)
+	nf := float64(n)
+	z2 := z * z
+	center := (p + z2/(2*nf)) / (1 + z2/nf)
+	half := z * math.Sqrt(p*(1-p)/nf+z2/(4*nf*nf)) / (1 + z2/nf)
+	return RateEstimate{
+		Rate:  p,
+		Lower: math.Max(0, center-half),
+		Upper: math.Min(1, center+half),
+	}
+}
+
+// zScore returns the two-sided standard normal critical value for a
+// confidence level, such as 1.96 for 0.95. Levels outside (0, 1) use 0.95.
+func zScore(confidence float64) float64 {
+	if confidence <= 0 || confidence >= 1 {
+		confidence = 0.95
+	}
+	return math.Sqrt2 * math.Erfinv(confidence)
+}
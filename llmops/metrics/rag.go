@@ -0,0 +1,498 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/agentplexus/omniobserve/llmops"
+)
+
+// The RAG metrics evaluate retrieval-augmented generation using
+// EvalInput.Input as the question, EvalInput.Output as the answer,
+// EvalInput.Context as the retrieved chunks in rank order, and
+// EvalInput.Expected as the reference answer. Unlike HallucinationMetric and
+// RelevanceMetric, they return fractional scores, where higher is better,
+// with per-claim or per-chunk breakdowns in the metadata.
+
+// Claim verdicts.
+const (
+	VerdictSupported    = "supported"
+	VerdictContradicted = "contradicted"
+	VerdictUnsupported  = "unsupported"
+	VerdictRelevant     = "relevant"
+	VerdictIrrelevant   = "irrelevant"
+)
+
+// ClaimVerdict is the verdict on a single claim or statement.
+type ClaimVerdict struct {
+	Claim   string `json:"claim"`
+	Verdict string `json:"verdict"`
+	Reason  string `json:"reason,omitempty"`
+	Chunk   int    `json:"chunk,omitempty"` // 1-based index of the supporting context chunk
+}
+
+// ChunkVerdict is the verdict on a single retrieved context chunk.
+type ChunkVerdict struct {
+	Chunk    int    `json:"chunk"` // 1-based index in EvalInput.Context
+	Relevant bool   `json:"relevant"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// NewRAGMetrics returns the faithfulness, answer relevancy, context
+// precision, and context recall metrics.
+func NewRAGMetrics(llm *LLM) []llmops.Metric {
+	return []llmops.Metric{
+		NewFaithfulnessMetric(llm),
+		NewAnswerRelevancyMetric(llm),
+		NewContextPrecisionMetric(llm),
+		NewContextRecallMetric(llm),
+	}
+}
+
+// =============================================================================
+// Faithfulness
+// =============================================================================
+
+// FaithfulnessMetric is an LLM-based metric that measures how much of an
+// answer is grounded in the retrieved context. It breaks the answer into
+// claims and verifies each against the context.
+type FaithfulnessMetric struct {
+	llm *LLM
+}
+
+// NewFaithfulnessMetric creates a new faithfulness metric.
+func NewFaithfulnessMetric(llm *LLM) *FaithfulnessMetric {
+	return &FaithfulnessMetric{llm: llm}
+}
+
+// Name returns the metric name.
+func (m *FaithfulnessMetric) Name() string {
+	return "faithfulness"
+}
+
+// Evaluate measures the faithfulness of the output to the context.
+// Returns the fraction of claims in the output that the context supports,
+// or 1.0 if the output makes no claims.
+func (m *FaithfulnessMetric) Evaluate(input llmops.EvalInput) (llmops.MetricScore, error) {
+	answer := toString(input.Output)
+	if answer == "" || len(input.Context) == 0 {
+		return failedScore(m.Name(), fmt.Errorf("answer and context are required for faithfulness"))
+	}
+
+	ctx := context.Background()
+	claims, err := extractClaims(ctx, m.llm, answer)
+	if err != nil {
+		return failedScore(m.Name(), err)
+	}
+	if len(claims) == 0 {
+		return llmops.MetricScore{
+			Name:     m.Name(),
+			Score:    1.0,
+			Reason:   "Answer makes no claims",
+			Metadata: map[string]any{"claims": []ClaimVerdict{}},
+		}, nil
+	}
+
+	verdicts, err := verifyClaims(ctx, m.llm, claims, input.Context)
+	if err != nil {
+		return failedScore(m.Name(), err)
+	}
+
+	counts := countVerdicts(verdicts)
+	return llmops.MetricScore{
+		Name:   m.Name(),
+		Score:  float64(counts[VerdictSupported]) / float64(len(verdicts)),
+		Reason: fmt.Sprintf("%d of %d claims are supported by the context", counts[VerdictSupported], len(verdicts)),
+		Metadata: map[string]any{
+			"claims":       verdicts,
+			"supported":    counts[VerdictSupported],
+			"contradicted": counts[VerdictContradicted],
+			"unsupported":  counts[VerdictUnsupported],
+		},
+	}, nil
+}
+
+// =============================================================================
+// Answer Relevancy
+// =============================================================================
+
+// AnswerRelevancyMetric is an LLM-based metric that measures how much of an
+// answer addresses the question. It breaks the answer into statements and
+// judges whether each is relevant to the question.
+type AnswerRelevancyMetric struct {
+	llm *LLM
+}
+
+// NewAnswerRelevancyMetric creates a new answer relevancy metric.
+func NewAnswerRelevancyMetric(llm *LLM) *AnswerRelevancyMetric {
+	return &AnswerRelevancyMetric{llm: llm}
+}
+
+// Name returns the metric name.
+func (m *AnswerRelevancyMetric) Name() string {
+	return "answer_relevancy"
+}
+
+// Evaluate measures the relevancy of the output to the input question.
+// Returns the fraction of statements in the output that are relevant.
+func (m *AnswerRelevancyMetric) Evaluate(input llmops.EvalInput) (llmops.MetricScore, error) {
+	question := toString(input.Input)
+	answer := toString(input.Output)
+	if question == "" || answer == "" {
+		return failedScore(m.Name(), fmt.Errorf("question and answer are required for answer relevancy"))
+	}
+
+	ctx := context.Background()
+	statements, err := extractClaims(ctx, m.llm, answer)
+	if err != nil {
+		return failedScore(m.Name(), err)
+	}
+	if len(statements) == 0 {
+		return llmops.MetricScore{
+			Name:     m.Name(),
+			Score:    0.0,
+			Reason:   "Answer makes no statements",
+			Metadata: map[string]any{"statements": []ClaimVerdict{}},
+		}, nil
+	}
+
+	prompt := strings.ReplaceAll(AnswerRelevancyTemplate, "{{question}}", question)
+	prompt = strings.ReplaceAll(prompt, "{{statements}}", numberedList(statements))
+
+	verdicts, err := judgeItems(ctx, m.llm, prompt, "judge_statements", statements,
+		[]string{VerdictRelevant, VerdictIrrelevant}, false)
+	if err != nil {
+		return failedScore(m.Name(), err)
+	}
+
+	counts := countVerdicts(verdicts)
+	return llmops.MetricScore{
+		Name:   m.Name(),
+		Score:  float64(counts[VerdictRelevant]) / float64(len(verdicts)),
+		Reason: fmt.Sprintf("%d of %d statements are relevant to the question", counts[VerdictRelevant], len(verdicts)),
+		Metadata: map[string]any{
+			"statements": verdicts,
+			"relevant":   counts[VerdictRelevant],
+		},
+	}, nil
+}
+
+// =============================================================================
+// Context Precision
+// =============================================================================
+
+// ContextPrecisionMetric is an LLM-based metric that measures whether the
+// relevant context chunks are ranked first. It judges each chunk's
+// usefulness for the reference answer, or the output if there is no
+// reference.
+type ContextPrecisionMetric struct {
+	llm *LLM
+}
+
+// NewContextPrecisionMetric creates a new context precision metric.
+func NewContextPrecisionMetric(llm *LLM) *ContextPrecisionMetric {
+	return &ContextPrecisionMetric{llm: llm}
+}
+
+// Name returns the metric name.
+func (m *ContextPrecisionMetric) Name() string {
+	return "context_precision"
+}
+
+// Evaluate measures the precision of the context for the input question.
+// Returns the mean of precision@k over the ranks k of relevant chunks, which
+// is 1.0 when all relevant chunks come before irrelevant ones and 0.0 when
+// no chunk is relevant.
+func (m *ContextPrecisionMetric) Evaluate(input llmops.EvalInput) (llmops.MetricScore, error) {
+	question := toString(input.Input)
+	answer := toString(input.Expected)
+	if answer == "" {
+		answer = toString(input.Output)
+	}
+	if question == "" || answer == "" || len(input.Context) == 0 {
+		return failedScore(m.Name(), fmt.Errorf("question, answer or reference, and context are required for context precision"))
+	}
+
+	prompt := strings.ReplaceAll(ContextPrecisionTemplate, "{{question}}", question)
+	prompt = strings.ReplaceAll(prompt, "{{answer}}", answer)
+	prompt = strings.ReplaceAll(prompt, "{{context}}", numberedChunks(input.Context))
+
+	judged, err := judgeItems(context.Background(), m.llm, prompt, "judge_chunks", input.Context,
+		[]string{VerdictRelevant, VerdictIrrelevant}, false)
+	if err != nil {
+		return failedScore(m.Name(), err)
+	}
+
+	chunks := make([]ChunkVerdict, len(judged))
+	relevant := 0
+	precisionSum := 0.0
+	for i, v := range judged {
+		chunks[i] = ChunkVerdict{
+			Chunk:    i + 1,
+			Relevant: v.Verdict == VerdictRelevant,
+			Reason:   v.Reason,
+		}
+		if chunks[i].Relevant {
+			relevant++
+			precisionSum += float64(relevant) / float64(i+1)
+		}
+	}
+
+	score := 0.0
+	if relevant > 0 {
+		score = precisionSum / float64(relevant)
+	}
+
+	return llmops.MetricScore{
+		Name:   m.Name(),
+		Score:  score,
+		Reason: fmt.Sprintf("%d of %d context chunks are relevant", relevant, len(chunks)),
+		Metadata: map[string]any{
+			"chunks":   chunks,
+			"relevant": relevant,
+		},
+	}, nil
+}
+
+// =============================================================================
+// Context Recall
+// =============================================================================
+
+// ContextRecallMetric is an LLM-based metric that measures whether the
+// context contains everything needed for the reference answer. It breaks
+// the reference into claims and attributes each to a context chunk.
+type ContextRecallMetric struct {
+	llm *LLM
+}
+
+// NewContextRecallMetric creates a new context recall metric.
+func NewContextRecallMetric(llm *LLM) *ContextRecallMetric {
+	return &ContextRecallMetric{llm: llm}
+}
+
+// Name returns the metric name.
+func (m *ContextRecallMetric) Name() string {
+	return "context_recall"
+}
+
+// Evaluate measures the recall of the context for EvalInput.Expected.
+// Returns the fraction of claims in the reference that the context
+// supports. The metadata counts the claims attributed to each chunk.
+func (m *ContextRecallMetric) Evaluate(input llmops.EvalInput) (llmops.MetricScore, error) {
+	reference := toString(input.Expected)
+	if reference == "" || len(input.Context) == 0 {
+		return failedScore(m.Name(), fmt.Errorf("reference and context are required for context recall"))
+	}
+
+	ctx := context.Background()
+	claims, err := extractClaims(ctx, m.llm, reference)
+	if err != nil {
+		return failedScore(m.Name(), err)
+	}
+	if len(claims) == 0 {
+		return failedScore(m.Name(), fmt.Errorf("reference makes no claims"))
+	}
+
+	verdicts, err := verifyClaims(ctx, m.llm, claims, input.Context)
+	if err != nil {
+		return failedScore(m.Name(), err)
+	}
+
+	chunkClaims := make([]int, len(input.Context))
+	supported := 0
+	for _, v := range verdicts {
+		if v.Verdict != VerdictSupported {
+			continue
+		}
+		supported++
+		if v.Chunk >= 1 && v.Chunk <= len(chunkClaims) {
+			chunkClaims[v.Chunk-1]++
+		}
+	}
+
+	return llmops.MetricScore{
+		Name:   m.Name(),
+		Score:  float64(supported) / float64(len(verdicts)),
+		Reason: fmt.Sprintf("%d of %d reference claims are supported by the context", supported, len(verdicts)),
+		Metadata: map[string]any{
+			"claims":       verdicts,
+			"supported":    supported,
+			"chunk_claims": chunkClaims,
+		},
+	}, nil
+}
+
+// =============================================================================
+// Shared Helpers
+// =============================================================================
+
+// extractClaims breaks text into claims.
+func extractClaims(ctx context.Context, llm *LLM, text string) ([]string, error) {
+	prompt := strings.ReplaceAll(ClaimExtractionTemplate, "{{text}}", text)
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"claims": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string"},
+				"description": "The claims made by the text",
+			},
+		},
+		"required": []string{"claims"},
+	}
+
+	var result struct {
+		Claims []string `json:"claims"`
+	}
+	if err := llm.GenerateJSON(ctx, prompt, "extract_claims", schema, &result); err != nil {
+		return nil, err
+	}
+
+	claims := make([]string, 0, len(result.Claims))
+	for _, c := range result.Claims {
+		if c = strings.TrimSpace(c); c != "" {
+			claims = append(claims, c)
+		}
+	}
+	return claims, nil
+}
+
+// verifyClaims judges whether each claim is supported by the context.
+func verifyClaims(ctx context.Context, llm *LLM, claims, chunks []string) ([]ClaimVerdict, error) {
+	prompt := strings.ReplaceAll(ClaimVerificationTemplate, "{{context}}", numberedChunks(chunks))
+	prompt = strings.ReplaceAll(prompt, "{{claims}}", numberedList(claims))
+
+	return judgeItems(ctx, llm, prompt, "verify_claims", claims,
+		[]string{VerdictSupported, VerdictContradicted, VerdictUnsupported}, true)
+}
+
+// judgeItems asks the LLM for a verdict on each numbered item in the prompt.
+// Verdicts are matched to items by their 1-based index. If withChunk is set,
+// the LLM also names the supporting context chunk.
+func judgeItems(ctx context.Context, llm *LLM, prompt, name string, items, labels []string, withChunk bool) ([]ClaimVerdict, error) {
+	properties := map[string]any{
+		"index": map[string]any{
+			"type":        "integer",
+			"description": "The item number",
+		},
+		"verdict": map[string]any{
+			"type": "string",
+			"enum": labels,
+		},
+		"reason": map[string]any{
+			"type":        "string",
+			"description": "Brief explanation for the verdict",
+		},
+	}
+	if withChunk {
+		properties["chunk"] = map[string]any{
+			"type":        "integer",
+			"description": "Number of the supporting context chunk, if any",
+		}
+	}
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"verdicts": map[string]any{
+				"type":        "array",
+				"description": "One verdict per item, in order",
+				"items": map[string]any{
+					"type":       "object",
+					"properties": properties,
+					"required":   []string{"index", "verdict", "reason"},
+				},
+			},
+		},
+		"required": []string{"verdicts"},
+	}
+
+	var result struct {
+		Verdicts []struct {
+			Index   int    `json:"index"`
+			Verdict string `json:"verdict"`
+			Reason  string `json:"reason"`
+			Chunk   int    `json:"chunk"`
+		} `json:"verdicts"`
+	}
+	if err := llm.GenerateJSON(ctx, prompt, name, schema, &result); err != nil {
+		return nil, err
+	}
+
+	verdicts := make([]ClaimVerdict, len(items))
+	seen := make([]bool, len(items))
+	for _, v := range result.Verdicts {
+		i := v.Index - 1
+		if i < 0 || i >= len(items) || seen[i] {
+			continue
+		}
+		label := strings.ToLower(strings.TrimSpace(v.Verdict))
+		if !containsLabel(labels, label) {
+			return nil, fmt.Errorf("unexpected verdict %q for item %d", v.Verdict, v.Index)
+		}
+		seen[i] = true
+		verdicts[i] = ClaimVerdict{
+			Claim:   items[i],
+			Verdict: label,
+			Reason:  v.Reason,
+			Chunk:   v.Chunk,
+		}
+	}
+	for i, ok := range seen {
+		if !ok {
+			return nil, fmt.Errorf("no verdict for item %d", i+1)
+		}
+	}
+	return verdicts, nil
+}
+
+// countVerdicts counts verdicts by label.
+func countVerdicts(verdicts []ClaimVerdict) map[string]int {
+	counts := make(map[string]int)
+	for _, v := range verdicts {
+		counts[v.Verdict]++
+	}
+	return counts
+}
+
+// containsLabel reports whether labels contains label.
+func containsLabel(labels []string, label string) bool {
+	for _, l := range labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+// numberedList formats items as a 1-based numbered list.
+func numberedList(items []string) string {
+	var b strings.Builder
+	for i, item := range items {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(strconv.Itoa(i + 1))
+		b.WriteString(". ")
+		b.WriteString(item)
+	}
+	return b.String()
+}
+
+// numberedChunks formats context chunks with 1-based chunk numbers.
+func numberedChunks(chunks []string) string {
+	parts := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		parts[i] = fmt.Sprintf("[Chunk %d]\n%s", i+1, chunk)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// failedScore returns a score recording err.
+func failedScore(name string, err error) (llmops.MetricScore, error) {
+	return llmops.MetricScore{
+		Name:  name,
+		Error: err.Error(),
+	}, err
+}
@@ -0,0 +1,159 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/agentplexus/omniobserve/llmops"
+)
+
+// SemanticSimilarityMetric is an embedding-based metric that compares
+// EvalInput.Output to EvalInput.Expected by cosine similarity, so that
+// paraphrases of the expected answer score highly. Expected may be a
+// string or a []string of acceptable answers, in which case the most
+// similar one is used.
+//
+// Wrap the embedder with NewCachedEmbedder to avoid re-embedding expected
+// answers shared across evaluations.
+type SemanticSimilarityMetric struct {
+	embedder  Embedder
+	threshold float64
+	name      string
+}
+
+// SemanticSimilarityOption configures the SemanticSimilarityMetric.
+type SemanticSimilarityOption func(*SemanticSimilarityMetric)
+
+// WithSimilarityThreshold sets the similarity at or above which the output
+// is considered to match. Default is 0.8.
+func WithSimilarityThreshold(threshold float64) SemanticSimilarityOption {
+	return func(m *SemanticSimilarityMetric) {
+		m.threshold = threshold
+	}
+}
+
+// WithSimilarityName sets the metric name. Default is "semantic_similarity".
+func WithSimilarityName(name string) SemanticSimilarityOption {
+	return func(m *SemanticSimilarityMetric) {
+		m.name = name
+	}
+}
+
+// NewSemanticSimilarityMetric creates a semantic similarity metric using
+// the given embedder.
+func NewSemanticSimilarityMetric(embedder Embedder, opts ...SemanticSimilarityOption) *SemanticSimilarityMetric {
+	m := &SemanticSimilarityMetric{
+		embedder:  embedder,
+		threshold: 0.8,
+		name:      "semantic_similarity",
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Name returns the metric name.
+func (m *SemanticSimilarityMetric) Name() string {
+	return m.name
+}
+
+// Evaluate computes the cosine similarity of the output and the expected
+// answer. The score is the similarity clamped to [0, 1]; the metadata
+// records whether it meets the threshold.
+func (m *SemanticSimilarityMetric) Evaluate(input llmops.EvalInput) (llmops.MetricScore, error) {
+	output := toString(input.Output)
+	if output == "" {
+		return llmops.MetricScore{
+			Name:  m.Name(),
+			Error: "no output provided for semantic similarity",
+		}, fmt.Errorf("no output provided for semantic similarity")
+	}
+
+	expected := expectedStrings(input.Expected)
+	if len(expected) == 0 {
+		return llmops.MetricScore{
+			Name:  m.Name(),
+			Error: "no expected output provided for semantic similarity",
+		}, fmt.Errorf("no expected output provided for semantic similarity")
+	}
+
+	texts := append([]string{output}, expected...)
+	embeddings, err := m.embedder.Embed(context.Background(), texts)
+	if err == nil && len(embeddings) != len(texts) {
+		err = fmt.Errorf("embedder returned %d embeddings for %d texts", len(embeddings), len(texts))
+	}
+	if err != nil {
+		return llmops.MetricScore{
+			Name:  m.Name(),
+			Error: err.Error(),
+		}, err
+	}
+
+	best, bestIdx := -1.0, 0
+	for i, emb := range embeddings[1:] {
+		sim, err := CosineSimilarity(embeddings[0], emb)
+		if err != nil {
+			return llmops.MetricScore{
+				Name:  m.Name(),
+				Error: err.Error(),
+			}, err
+		}
+		if sim > best {
+			best, bestIdx = sim, i
+		}
+	}
+
+	score := best
+	if score < 0 {
+		score = 0
+	}
+	passed := best >= m.threshold
+
+	reason := fmt.Sprintf("Output is not similar to expected (similarity %.3f, threshold %.3f)", best, m.threshold)
+	if passed {
+		reason = fmt.Sprintf("Output is similar to expected (similarity %.3f, threshold %.3f)", best, m.threshold)
+	}
+
+	metadata := map[string]any{
+		"similarity": best,
+		"threshold":  m.threshold,
+		"passed":     passed,
+	}
+	if len(expected) > 1 {
+		metadata["best_match"] = expected[bestIdx]
+	}
+
+	return llmops.MetricScore{
+		Name:     m.Name(),
+		Score:    score,
+		Reason:   reason,
+		Metadata: metadata,
+	}, nil
+}
+
+// expectedStrings returns the non-empty expected answers in v, which may be
+// a string, a []string, a []any, or any other value converted with
+// toString.
+func expectedStrings(v any) []string {
+	var values []string
+	switch e := v.(type) {
+	case nil:
+	case []string:
+		values = e
+	case []any:
+		for _, item := range e {
+			values = append(values, toString(item))
+		}
+	default:
+		values = []string{toString(e)}
+	}
+
+	out := make([]string, 0, len(values))
+	for _, s := range values {
+		if s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -100,3 +100,132 @@ Classify the summary as:
 - "poor" if the summary misses key information, contains errors, or misrepresents the original
 
 A good summary should be concise while preserving the essential meaning of the original text.`
+
+// ClaimExtractionTemplate is the prompt template for breaking text into
+// factual claims. It is used by the faithfulness, context recall, and answer
+// relevancy metrics.
+const ClaimExtractionTemplate = `You are an expert at breaking text into factual claims.
+
+## Text
+{{text}}
+
+## Instructions
+Break the text into short, self-contained claims. Each claim should state a single fact and be understandable on its own, so replace pronouns with the entities they refer to.
+
+Only include what the text says. Do not add information, and ignore greetings and filler.
+
+Return an empty list if the text makes no claims.`
+
+// ClaimVerificationTemplate is the prompt template for verifying claims
+// against retrieved context.
+const ClaimVerificationTemplate = `You are an expert evaluator verifying claims against retrieved context.
+
+## Context
+{{context}}
+
+## Claims
+{{claims}}
+
+## Instructions
+For each claim, decide whether it is supported by the context.
+
+Classify each claim as:
+- "supported" if the context states or directly implies the claim
+- "contradicted" if the context states something that conflicts with the claim
+- "unsupported" if the context neither supports nor contradicts the claim
+
+Judge only against the context, not your own knowledge. For supported claims, give the number of the context chunk that best supports the claim.`
+
+// ContextPrecisionTemplate is the prompt template for judging whether each
+// retrieved context chunk was useful for answering a question.
+const ContextPrecisionTemplate = `You are an expert evaluator assessing retrieved context.
+
+## Question
+{{question}}
+
+## Answer
+{{answer}}
+
+## Context Chunks
+{{context}}
+
+## Instructions
+For each context chunk, decide whether it was useful in arriving at the answer to the question.
+
+Classify each chunk as:
+- "relevant" if the chunk contains information that helps produce the answer, even partially
+- "irrelevant" if the chunk does not help produce the answer, even if it is on the same topic`
+
+// AnswerRelevancyTemplate is the prompt template for judging whether the
+// statements of an answer address the question.
+const AnswerRelevancyTemplate = `You are an expert evaluator assessing answer relevancy.
+
+## Question
+{{question}}
+
+## Statements
+{{statements}}
+
+## Instructions
+The statements were taken from an answer to the question. For each statement, decide whether it is relevant to answering the question.
+
+Classify each statement as:
+- "relevant" if the statement addresses the question, including supporting details and partial answers
+- "irrelevant" if the statement does not address the question`
+
+// CriteriaTemplate is the prompt template for scoring a test case against
+// custom criteria, used by CriteriaMetric.
+const CriteriaTemplate = `You are an expert evaluator scoring an AI response against custom criteria.
+
+## Criteria
+{{criteria}}
+
+## Evaluation Steps
+{{steps}}
+
+## Rubric
+{{rubric}}
+
+## Test Case
+{{test_case}}
+
+## Instructions
+Follow the evaluation steps to assess the test case against the criteria. Then score it from {{min}} to {{max}} using the rubric, where {{max}} means the criteria are fully met.
+
+Be strict and consistent. Base the score only on the criteria, not on other qualities of the response.`
+
+// EvaluationStepsTemplate is the prompt template for generating evaluation
+// steps from criteria, used by CriteriaMetric when no steps are given.
+const EvaluationStepsTemplate = `You are an expert at designing evaluations of AI responses.
+
+## Criteria
+{{criteria}}
+
+## Instructions
+Write 3 to 5 concise evaluation steps that an evaluator should follow to judge whether a response meets the criteria. Each step should be a single, concrete instruction.`
+
+// PairwiseTemplate is the prompt template for comparing two responses to
+// the same input, used by PairwiseJudge.
+const PairwiseTemplate = `You are an expert evaluator comparing two AI responses to the same input.
+
+## Input
+{{input}}
+
+## Response A
+{{response_a}}
+
+## Response B
+{{response_b}}
+
+## Criteria
+{{criteria}}
+
+## Instructions
+Compare the two responses against the criteria and decide which is better.
+
+Classify the comparison as:
+- "A" if Response A is better
+- "B" if Response B is better
+- "tie" if the responses are equally good or equally bad
+
+Do not let the order, length, or style of the responses influence your decision unless the criteria call for it.`
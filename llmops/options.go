@@ -195,6 +195,8 @@ type FeedbackOptions struct {
 	Reason   string
 	Category string
 	Source   string
+	DataType ScoreDataType
+	Label    string
 }
 
 // WithFeedbackReason sets the reason for the score.
@@ -218,6 +220,25 @@ func WithFeedbackSource(source string) FeedbackOption {
 	}
 }
 
+// WithFeedbackDataType sets the data type of the score.
+// For boolean scores, pass 1.0 for true and 0.0 for false as the score.
+func WithFeedbackDataType(dataType ScoreDataType) FeedbackOption {
+	return func(o *FeedbackOptions) {
+		o.DataType = dataType
+	}
+}
+
+// WithFeedbackLabel sets the value of a categorical score.
+// The numeric score argument is ignored for categorical scores.
+func WithFeedbackLabel(label string) FeedbackOption {
+	return func(o *FeedbackOptions) {
+		o.Label = label
+		if o.DataType == "" {
+			o.DataType = ScoreDataTypeCategorical
+		}
+	}
+}
+
 // ListOption configures list operations.
 type ListOption func(*ListOptions)
 
@@ -447,3 +468,12 @@ func ApplyListOptions(opts ...ListOption) *ListOptions {
 	}
 	return o
 }
+
+// ApplyFeedbackOptions applies options to a FeedbackOptions struct.
+func ApplyFeedbackOptions(opts ...FeedbackOption) *FeedbackOptions {
+	o := &FeedbackOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
@@ -0,0 +1,79 @@
+package report
+
+import (
+	"html/template"
+	"io"
+)
+
+// WriteHTML writes the report as a standalone HTML page with inline styles.
+func (r *Report) WriteHTML(w io.Writer) error {
+	return htmlTemplate.Execute(w, r)
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"passRate": passRate,
+	"percent": func(count int, histogram []Bucket) float64 {
+		peak := 0
+		for _, b := range histogram {
+			peak = max(peak, b.Count)
+		}
+		if peak == 0 {
+			return 0
+		}
+		return 100 * float64(count) / float64(peak)
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2rem auto; max-width: 960px; color: #1f2328; }
+table { border-collapse: collapse; width: 100%; margin: 1rem 0; }
+th, td { border: 1px solid #d0d7de; padding: 0.4rem 0.6rem; text-align: left; vertical-align: top; }
+th { background: #f6f8fa; }
+td.num { text-align: right; font-variant-numeric: tabular-nums; }
+.status { display: inline-block; padding: 0.2rem 0.6rem; border-radius: 1rem; font-weight: 600; }
+.passed { background: #dafbe1; color: #1a7f37; }
+.failed { background: #ffebe9; color: #cf222e; }
+.bar { background: #0969da; height: 0.8rem; }
+.histogram td { border: none; padding: 0.1rem 0.4rem; }
+.muted { color: #656d76; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>
+{{if .Passed}}<span class="status passed">Passed</span>{{else}}<span class="status failed">Failed ({{.FailedCases}} of {{len .Cases}} cases)</span>{{end}}
+<span class="muted">{{len .Cases}} cases · generated {{.GeneratedAt.UTC.Format "2006-01-02 15:04 UTC"}}</span>
+</p>
+{{if .Metrics}}
+<h2>Summary</h2>
+<table>
+<tr><th>Metric</th><th>Mean</th><th>Std dev</th><th>Min</th><th>Median</th><th>Max</th><th>Pass rate</th><th>Errors</th></tr>
+{{range .Metrics}}<tr><td>{{.Name}}</td><td class="num">{{printf "%.3f" .Mean}}</td><td class="num">{{printf "%.3f" .StdDev}}</td><td class="num">{{printf "%.3f" .Min}}</td><td class="num">{{printf "%.3f" .Median}}</td><td class="num">{{printf "%.3f" .Max}}</td><td class="num">{{passRate .}}</td><td class="num">{{.Errors}}</td></tr>
+{{end}}</table>
+{{range .Metrics}}{{$histogram := .Histogram}}
+<h2>{{.Name}}</h2>
+<table class="histogram">
+{{range .Histogram}}<tr><td class="muted">{{printf "%.2f-%.2f" .Min .Max}}</td><td style="width: 70%"><div class="bar" style="width: {{printf "%.1f" (percent .Count $histogram)}}%"></div></td><td class="num">{{.Count}}</td></tr>
+{{end}}</table>
+{{if .Worst}}<h3>Worst examples</h3>
+<table>
+<tr><th>Case</th><th>Score</th><th>Reason</th><th>Output</th></tr>
+{{range .Worst}}<tr><td>{{.CaseID}}</td><td class="num">{{printf "%.3f" .Score}}</td><td>{{.Reason}}</td><td class="muted">{{.Output}}</td></tr>
+{{end}}</table>
+{{end}}{{end}}
+{{if not .Passed}}
+<h2>Failures</h2>
+<table>
+<tr><th>Case</th><th>Metric</th><th>Score</th><th>Threshold</th><th>Error</th></tr>
+{{range .Cases}}{{$case := .}}{{range .Summary.Failures}}<tr><td>{{$case.ID}}</td><td>{{.Name}}</td><td class="num">{{printf "%.3f" .Score}}</td><td class="num">{{printf "%.3f" .Threshold}} ({{.Direction}})</td><td>{{.Error}}</td></tr>
+{{end}}{{end}}</table>
+{{end}}
+{{else}}
+<p>No scores.</p>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,139 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// JUnit XML elements, in the format read by common CI systems.
+type (
+	junitTestSuites struct {
+		XMLName  xml.Name         `xml:"testsuites"`
+		Name     string           `xml:"name,attr"`
+		Tests    int              `xml:"tests,attr"`
+		Failures int              `xml:"failures,attr"`
+		Errors   int              `xml:"errors,attr"`
+		Time     string           `xml:"time,attr"`
+		Suites   []junitTestSuite `xml:"testsuite"`
+	}
+
+	junitTestSuite struct {
+		Name      string          `xml:"name,attr"`
+		Tests     int             `xml:"tests,attr"`
+		Failures  int             `xml:"failures,attr"`
+		Errors    int             `xml:"errors,attr"`
+		Time      string          `xml:"time,attr"`
+		Timestamp string          `xml:"timestamp,attr"`
+		Cases     []junitTestCase `xml:"testcase"`
+
+		seconds float64
+	}
+
+	junitTestCase struct {
+		Name      string        `xml:"name,attr"`
+		Classname string        `xml:"classname,attr"`
+		Time      string        `xml:"time,attr"`
+		Failure   *junitMessage `xml:"failure,omitempty"`
+		Error     *junitMessage `xml:"error,omitempty"`
+		SystemOut string        `xml:"system-out,omitempty"`
+	}
+
+	junitMessage struct {
+		Message string `xml:"message,attr"`
+		Type    string `xml:"type,attr"`
+		Text    string `xml:",chardata"`
+	}
+)
+
+// WriteJUnit writes the report as JUnit XML, with a test suite per metric
+// and a test case per scored case. Scores that fail their threshold are
+// failures and scores that failed to evaluate are errors; ungated scores
+// pass, with their score and reason in system-out.
+func (r *Report) WriteJUnit(w io.Writer) error {
+	suites := junitTestSuites{Name: r.Title}
+	index := make(map[string]int)
+	timestamp := r.GeneratedAt.UTC().Format("2006-01-02T15:04:05")
+
+	var total float64
+	for _, c := range r.Cases {
+		failed := make(map[string]bool)
+		for _, f := range c.Summary.Failures {
+			failed[f.Name] = true
+		}
+		// Split the case duration evenly across its scores
+		seconds := 0.0
+		if len(c.Scores) > 0 {
+			seconds = c.Duration.Seconds() / float64(len(c.Scores))
+		}
+		total += c.Duration.Seconds()
+
+		for _, s := range c.Scores {
+			i, ok := index[s.Name]
+			if !ok {
+				i = len(suites.Suites)
+				index[s.Name] = i
+				suites.Suites = append(suites.Suites, junitTestSuite{Name: s.Name, Timestamp: timestamp})
+			}
+			suite := &suites.Suites[i]
+
+			tc := junitTestCase{
+				Name:      c.ID,
+				Classname: s.Name,
+				Time:      formatSeconds(seconds),
+				SystemOut: strings.TrimSuffix(fmt.Sprintf("score %.3f: %s", s.Score, s.Reason), ": "),
+			}
+			switch {
+			case s.Error != "":
+				tc.Error = &junitMessage{Message: s.Error, Type: "error", Text: s.Error}
+				tc.SystemOut = ""
+				suite.Errors++
+			case failed[s.Name]:
+				tc.Failure = &junitMessage{
+					Message: failureMessage(c, s.Name),
+					Type:    "threshold",
+					Text:    s.Reason,
+				}
+				suite.Failures++
+			}
+			suite.Tests++
+			suite.seconds += seconds
+			suite.Cases = append(suite.Cases, tc)
+		}
+	}
+
+	for i := range suites.Suites {
+		suites.Tests += suites.Suites[i].Tests
+		suites.Failures += suites.Suites[i].Failures
+		suites.Errors += suites.Suites[i].Errors
+		suites.Suites[i].Time = formatSeconds(suites.Suites[i].seconds)
+	}
+	suites.Time = formatSeconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// failureMessage describes the failed threshold of a metric in a case.
+func failureMessage(c Case, name string) string {
+	for _, f := range c.Summary.Failures {
+		if f.Name == name {
+			return fmt.Sprintf("score %.3f does not meet threshold %.3f (%s)", f.Score, f.Threshold, f.Direction)
+		}
+	}
+	return "threshold not met"
+}
+
+// formatSeconds formats a duration in seconds for JUnit.
+func formatSeconds(seconds float64) string {
+	return fmt.Sprintf("%.3f", seconds)
+}
@@ -0,0 +1,96 @@
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteMarkdown writes the report as GitHub-flavored Markdown.
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	status := "✅ Passed"
+	if !r.Passed {
+		status = fmt.Sprintf("❌ Failed (%d of %d cases)", r.FailedCases, len(r.Cases))
+	}
+	fmt.Fprintf(bw, "# %s\n\n", escapeMarkdown(r.Title))
+	fmt.Fprintf(bw, "**%s** · %d cases · generated %s\n\n", status, len(r.Cases), r.GeneratedAt.UTC().Format("2006-01-02 15:04 UTC"))
+
+	if len(r.Metrics) == 0 {
+		bw.WriteString("No scores.\n")
+		return bw.Flush()
+	}
+
+	bw.WriteString("## Summary\n\n")
+	bw.WriteString("| Metric | Mean | Std dev | Min | Median | Max | Pass rate | Errors |\n")
+	bw.WriteString("|---|---:|---:|---:|---:|---:|---:|---:|\n")
+	for _, m := range r.Metrics {
+		fmt.Fprintf(bw, "| %s | %.3f | %.3f | %.3f | %.3f | %.3f | %s | %d |\n",
+			escapeMarkdown(m.Name), m.Mean, m.StdDev, m.Min, m.Median, m.Max, passRate(m), m.Errors)
+	}
+
+	for _, m := range r.Metrics {
+		fmt.Fprintf(bw, "\n## %s\n\n", escapeMarkdown(m.Name))
+
+		bw.WriteString("```\n")
+		peak := 0
+		for _, b := range m.Histogram {
+			peak = max(peak, b.Count)
+		}
+		for _, b := range m.Histogram {
+			bar := ""
+			if peak > 0 {
+				bar = strings.Repeat("█", (b.Count*30+peak-1)/peak)
+			}
+			fmt.Fprintf(bw, "%.2f-%.2f | %-30s %d\n", b.Min, b.Max, bar, b.Count)
+		}
+		bw.WriteString("```\n")
+
+		if len(m.Worst) > 0 {
+			bw.WriteString("\n**Worst examples**\n\n")
+			bw.WriteString("| Case | Score | Reason |\n")
+			bw.WriteString("|---|---:|---|\n")
+			for _, e := range m.Worst {
+				fmt.Fprintf(bw, "| %s | %.3f | %s |\n", escapeMarkdown(e.CaseID), e.Score, escapeMarkdown(e.Reason))
+			}
+		}
+	}
+
+	var failures []string
+	for _, c := range r.Cases {
+		for _, f := range c.Summary.Failures {
+			detail := fmt.Sprintf("score %.3f, threshold %.3f (%s)", f.Score, f.Threshold, f.Direction)
+			if f.Error != "" {
+				detail = "error: " + f.Error
+			}
+			failures = append(failures, fmt.Sprintf("| %s | %s | %s |", escapeMarkdown(c.ID), escapeMarkdown(f.Name), escapeMarkdown(detail)))
+		}
+	}
+	if len(failures) > 0 {
+		bw.WriteString("\n## Failures\n\n")
+		bw.WriteString("| Case | Metric | Detail |\n")
+		bw.WriteString("|---|---|---|\n")
+		for _, f := range failures {
+			bw.WriteString(f + "\n")
+		}
+	}
+
+	return bw.Flush()
+}
+
+// passRate formats the pass rate of a metric, or "-" if it is not gated.
+func passRate(m MetricSummary) string {
+	if m.Gated == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%% (%d/%d)", 100*m.PassRate, m.Passed, m.Gated)
+}
+
+// escapeMarkdown makes text safe for a Markdown table cell.
+func escapeMarkdown(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	s = strings.ReplaceAll(s, "\r\n", " ")
+	return strings.ReplaceAll(s, "\n", " ")
+}
@@ -0,0 +1,360 @@
+// Package report writes evaluation reports for CI.
+//
+// A Report summarizes metric scores over a dataset: per-metric score
+// distributions, pass rates, and the worst examples with their reasons. It
+// is built from llmops.ExperimentItem or llmops.EvalResult collections and
+// written as Markdown (for pull request comments and job summaries),
+// standalone HTML, or JUnit XML (so CI systems show failed thresholds as
+// failed tests).
+//
+// Pass/fail follows metrics.SummarizeResult: a score is gated if its
+// metadata records "passed", as metrics.ThresholdMetric does, and a case
+// with a score that failed to evaluate fails.
+//
+// # Usage
+//
+//	r := report.FromExperimentItems("nightly-eval", items,
+//	    report.WithWorstExamples(3),
+//	)
+//
+//	f, _ := os.Create("junit.xml")
+//	defer f.Close()
+//	if err := r.WriteJUnit(f); err != nil {
+//	    log.Fatal(err)
+//	}
+//	if !r.Passed {
+//	    os.Exit(1)
+//	}
+package report
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/agentplexus/omniobserve/llmops"
+	"github.com/agentplexus/omniobserve/llmops/metrics"
+)
+
+// Case is a single evaluated example.
+type Case struct {
+	ID       string               `json:"id"`
+	Input    any                  `json:"input,omitempty"`
+	Output   any                  `json:"output,omitempty"`
+	Expected any                  `json:"expected,omitempty"`
+	Scores   []llmops.MetricScore `json:"scores"`
+	TraceID  string               `json:"trace_id,omitempty"`
+	Duration time.Duration        `json:"duration,omitempty"`
+
+	// Summary is the pass/fail outcome of the case's scores.
+	Summary metrics.EvalSummary `json:"summary"`
+}
+
+// Bucket is a range of a score histogram.
+type Bucket struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Count int     `json:"count"`
+}
+
+// Example is a case's score for a metric.
+type Example struct {
+	CaseID  string  `json:"case_id"`
+	TraceID string  `json:"trace_id,omitempty"`
+	Score   float64 `json:"score"`
+	Reason  string  `json:"reason,omitempty"`
+	Output  string  `json:"output,omitempty"`
+}
+
+// MetricSummary summarizes the scores of one metric across cases.
+type MetricSummary struct {
+	Name      string            `json:"name"`
+	Direction metrics.Direction `json:"direction"`
+
+	// Count is the number of scores, excluding Errors.
+	Count  int `json:"count"`
+	Errors int `json:"errors"`
+
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"std_dev"`
+	Min    float64 `json:"min"`
+	Median float64 `json:"median"`
+	Max    float64 `json:"max"`
+
+	// Histogram is the score distribution over [0, 1].
+	Histogram []Bucket `json:"histogram"`
+
+	// Gated is the number of scores with a threshold, and Passed the
+	// number of those that passed.
+	Gated    int     `json:"gated"`
+	Passed   int     `json:"passed"`
+	PassRate float64 `json:"pass_rate"`
+
+	// Worst are the lowest scoring examples, or the highest for
+	// LowerIsBetter metrics.
+	Worst []Example `json:"worst,omitempty"`
+}
+
+// Report is an evaluation report.
+type Report struct {
+	Title       string    `json:"title"`
+	GeneratedAt time.Time `json:"generated_at"`
+
+	// Passed is true if every case passed its thresholds and had no
+	// errored scores.
+	Passed bool `json:"passed"`
+
+	// FailedCases is the number of cases with a failed threshold or an
+	// errored score.
+	FailedCases int `json:"failed_cases"`
+
+	Metrics []MetricSummary `json:"metrics"`
+	Cases   []Case          `json:"cases"`
+}
+
+type config struct {
+	worst      int
+	buckets    int
+	directions map[string]metrics.Direction
+}
+
+// Option configures a Report.
+type Option func(*config)
+
+// WithWorstExamples sets how many of the worst examples are listed per
+// metric. Default is 5.
+func WithWorstExamples(n int) Option {
+	return func(c *config) {
+		c.worst = n
+	}
+}
+
+// WithHistogramBuckets sets the number of histogram buckets. Default is 10.
+func WithHistogramBuckets(n int) Option {
+	return func(c *config) {
+		if n > 0 {
+			c.buckets = n
+		}
+	}
+}
+
+// WithMetrics sets the directions of the given metrics, so the worst
+// examples of LowerIsBetter metrics are their highest scores. Directions
+// recorded by metrics.ThresholdMetric are used without this option.
+func WithMetrics(ms ...llmops.Metric) Option {
+	return func(c *config) {
+		for _, m := range ms {
+			c.directions[m.Name()] = metrics.MetricDirection(m)
+		}
+	}
+}
+
+// FromExperimentItems builds a report from experiment items.
+func FromExperimentItems(title string, items []llmops.ExperimentItem, opts ...Option) *Report {
+	cases := make([]Case, len(items))
+	for i, item := range items {
+		id := item.DatasetItemID
+		if id == "" {
+			id = item.ID
+		}
+		if id == "" {
+			id = fmt.Sprintf("item-%d", i+1)
+		}
+		cases[i] = Case{
+			ID:       id,
+			Input:    item.Input,
+			Output:   item.Output,
+			Expected: item.Expected,
+			Scores:   item.Scores,
+			TraceID:  item.TraceID,
+		}
+	}
+	return New(title, cases, opts...)
+}
+
+// FromEvalResults builds a report from evaluation results. Cases are
+// identified by the "id" metadata of each result, if set, or by position.
+func FromEvalResults(title string, results []*llmops.EvalResult, opts ...Option) *Report {
+	cases := make([]Case, 0, len(results))
+	for i, result := range results {
+		if result == nil {
+			continue
+		}
+		id := fmt.Sprintf("result-%d", i+1)
+		if v, ok := result.Metadata["id"].(string); ok && v != "" {
+			id = v
+		}
+		traceID, _ := result.Metadata["trace_id"].(string)
+		cases = append(cases, Case{
+			ID:       id,
+			Scores:   result.Scores,
+			TraceID:  traceID,
+			Duration: result.Duration,
+		})
+	}
+	return New(title, cases, opts...)
+}
+
+// New builds a report from cases.
+func New(title string, cases []Case, opts ...Option) *Report {
+	cfg := &config{
+		worst:      5,
+		buckets:    10,
+		directions: make(map[string]metrics.Direction),
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	r := &Report{
+		Title:       title,
+		GeneratedAt: time.Now(),
+		Passed:      true,
+		Cases:       cases,
+	}
+
+	byMetric := make(map[string]*metricScores)
+	var names []string
+	for i := range r.Cases {
+		c := &r.Cases[i]
+		c.Summary = metrics.SummarizeResult(&llmops.EvalResult{Scores: c.Scores})
+		if !c.Summary.Passed {
+			r.Passed = false
+			r.FailedCases++
+		}
+
+		for _, s := range c.Scores {
+			ms, ok := byMetric[s.Name]
+			if !ok {
+				ms = &metricScores{direction: cfg.directions[s.Name]}
+				byMetric[s.Name] = ms
+				names = append(names, s.Name)
+			}
+			ms.add(c, s)
+		}
+	}
+
+	for _, name := range names {
+		r.Metrics = append(r.Metrics, byMetric[name].summary(name, cfg))
+	}
+	return r
+}
+
+// metricScores collects the scores of one metric.
+type metricScores struct {
+	direction metrics.Direction
+	errors    int
+	gated     int
+	passed    int
+	examples  []Example
+}
+
+func (m *metricScores) add(c *Case, s llmops.MetricScore) {
+	if md, ok := s.Metadata.(map[string]any); ok {
+		if d, ok := md["direction"].(string); ok && m.direction == "" {
+			m.direction = metrics.Direction(d)
+		}
+		if passed, ok := md["passed"].(bool); ok {
+			m.gated++
+			if passed {
+				m.passed++
+			}
+		}
+	}
+	if s.Error != "" {
+		m.errors++
+		return
+	}
+	m.examples = append(m.examples, Example{
+		CaseID:  c.ID,
+		TraceID: c.TraceID,
+		Score:   s.Score,
+		Reason:  s.Reason,
+		Output:  truncate(stringify(c.Output), 200),
+	})
+}
+
+func (m *metricScores) summary(name string, cfg *config) MetricSummary {
+	direction := m.direction
+	if direction == "" {
+		direction = metrics.HigherIsBetter
+	}
+	s := MetricSummary{
+		Name:      name,
+		Direction: direction,
+		Count:     len(m.examples),
+		Errors:    m.errors,
+		Gated:     m.gated,
+		Passed:    m.passed,
+		Histogram: make([]Bucket, cfg.buckets),
+	}
+	if m.gated > 0 {
+		s.PassRate = float64(m.passed) / float64(m.gated)
+	}
+	for i := range s.Histogram {
+		s.Histogram[i].Min = float64(i) / float64(cfg.buckets)
+		s.Histogram[i].Max = float64(i+1) / float64(cfg.buckets)
+	}
+	if len(m.examples) == 0 {
+		return s
+	}
+
+	// Worst first
+	sorted := append([]Example(nil), m.examples...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if direction == metrics.LowerIsBetter {
+			return sorted[i].Score > sorted[j].Score
+		}
+		return sorted[i].Score < sorted[j].Score
+	})
+	s.Worst = sorted[:min(cfg.worst, len(sorted))]
+
+	scores := make([]float64, len(sorted))
+	sum := 0.0
+	for i, e := range sorted {
+		scores[i] = e.Score
+		sum += e.Score
+		b := int(math.Floor(e.Score * float64(cfg.buckets)))
+		s.Histogram[max(0, min(b, cfg.buckets-1))].Count++
+	}
+	sort.Float64s(scores)
+
+	s.Mean = sum / float64(len(scores))
+	s.Min = scores[0]
+	s.Max = scores[len(scores)-1]
+	if n := len(scores); n%2 == 1 {
+		s.Median = scores[n/2]
+	} else {
+		s.Median = (scores[n/2-1] + scores[n/2]) / 2
+	}
+	variance := 0.0
+	for _, v := range scores {
+		variance += (v - s.Mean) * (v - s.Mean)
+	}
+	s.StdDev = math.Sqrt(variance / float64(len(scores)))
+	return s
+}
+
+// stringify returns a value as text for display.
+func stringify(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case []byte:
+		return string(val)
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+// truncate shortens s to at most n runes.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
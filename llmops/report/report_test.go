@@ -0,0 +1,281 @@
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/agentplexus/omniobserve/llmops"
+	"github.com/agentplexus/omniobserve/llmops/metrics"
+)
+
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+// gated returns a score with threshold metadata, as recorded by
+// metrics.ThresholdMetric.
+func gated(name string, score, threshold float64, direction metrics.Direction, reason string) llmops.MetricScore {
+	passed := score >= threshold
+	if direction == metrics.LowerIsBetter {
+		passed = score <= threshold
+	}
+	return llmops.MetricScore{
+		Name:   name,
+		Score:  score,
+		Reason: reason,
+		Metadata: map[string]any{
+			"passed":    passed,
+			"threshold": threshold,
+			"direction": string(direction),
+		},
+	}
+}
+
+// testReport returns a report with a passing case, a case failing both
+// thresholds, and a case whose hallucination judge failed.
+func testReport() *Report {
+	judgeFailed := gated("hallucination", 0, 0.5, metrics.LowerIsBetter, "")
+	judgeFailed.Error = "judge timed out"
+	judgeFailed.Metadata.(map[string]any)["passed"] = false
+
+	r := New("Nightly <eval>", []Case{
+		{
+			ID:       "case-1",
+			Output:   "Paris is the capital of France.",
+			Duration: 2 * time.Second,
+			Scores: []llmops.MetricScore{
+				gated("relevance", 0.9, 0.5, metrics.HigherIsBetter, "Answers the question"),
+				gated("hallucination", 0.0, 0.5, metrics.LowerIsBetter, "Supported by the context"),
+			},
+		},
+		{
+			ID:       "case-2",
+			Output:   "London is the capital of France.",
+			Duration: time.Second,
+			Scores: []llmops.MetricScore{
+				gated("relevance", 0.3, 0.5, metrics.HigherIsBetter, "Mentions the wrong city | partly"),
+				gated("hallucination", 1.0, 0.5, metrics.LowerIsBetter, "Contradicts the context"),
+			},
+		},
+		{
+			ID:       "case-3",
+			Duration: 500 * time.Millisecond,
+			Scores: []llmops.MetricScore{
+				gated("relevance", 0.6, 0.5, metrics.HigherIsBetter, ""),
+				judgeFailed,
+			},
+		},
+	}, WithHistogramBuckets(4))
+	r.GeneratedAt = time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+	return r
+}
+
+// erroredReport returns a report whose only problem is an ungated score
+// that failed to evaluate, as evaluators record metrics returning an error.
+func erroredReport() *Report {
+	r := FromEvalResults("Errored eval", []*llmops.EvalResult{
+		{Metadata: map[string]any{"id": "q1"}, Scores: []llmops.MetricScore{{Name: "relevance", Score: 1, Reason: "On topic"}}},
+		{Metadata: map[string]any{"id": "q2"}, Scores: []llmops.MetricScore{{Name: "relevance", Error: "judge unavailable"}}},
+	})
+	r.GeneratedAt = time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+	return r
+}
+
+func worstIDs(m MetricSummary) []string {
+	ids := make([]string, len(m.Worst))
+	for i, e := range m.Worst {
+		ids[i] = e.CaseID
+	}
+	return ids
+}
+
+func bucketCounts(m MetricSummary) []int {
+	counts := make([]int, len(m.Histogram))
+	for i, b := range m.Histogram {
+		counts[i] = b.Count
+	}
+	return counts
+}
+
+func TestNew(t *testing.T) {
+	r := testReport()
+
+	if r.Passed || r.FailedCases != 2 {
+		t.Errorf("expected 2 failed cases, got passed=%v failed=%d", r.Passed, r.FailedCases)
+	}
+	if len(r.Metrics) != 2 || r.Metrics[0].Name != "relevance" || r.Metrics[1].Name != "hallucination" {
+		t.Fatalf("expected relevance and hallucination in order of appearance, got %+v", r.Metrics)
+	}
+
+	relevance := r.Metrics[0]
+	if relevance.Direction != metrics.HigherIsBetter || relevance.Count != 3 || relevance.Errors != 0 {
+		t.Errorf("unexpected relevance summary: %+v", relevance)
+	}
+	if math.Abs(relevance.Mean-0.6) > 1e-9 || relevance.Median != 0.6 || relevance.Min != 0.3 || relevance.Max != 0.9 {
+		t.Errorf("unexpected relevance statistics: %+v", relevance)
+	}
+	if relevance.Gated != 3 || relevance.Passed != 2 || math.Abs(relevance.PassRate-2.0/3.0) > 1e-9 {
+		t.Errorf("expected 2 of 3 relevance scores to pass, got %d of %d", relevance.Passed, relevance.Gated)
+	}
+	if got, want := worstIDs(relevance), []string{"case-2", "case-3", "case-1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected lowest relevance first %v, got %v", want, got)
+	}
+	if got, want := bucketCounts(relevance), []int{0, 1, 1, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected relevance histogram %v, got %v", want, got)
+	}
+
+	hallucination := r.Metrics[1]
+	if hallucination.Direction != metrics.LowerIsBetter || hallucination.Count != 2 || hallucination.Errors != 1 {
+		t.Errorf("unexpected hallucination summary: %+v", hallucination)
+	}
+	if hallucination.Median != 0.5 {
+		t.Errorf("expected the median of an even count to average the middle scores, got %f", hallucination.Median)
+	}
+	if got, want := worstIDs(hallucination), []string{"case-2", "case-1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected highest hallucination first %v, got %v", want, got)
+	}
+	if got, want := bucketCounts(hallucination), []int{1, 0, 0, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected a score of 1.0 in the last bucket %v, got %v", want, got)
+	}
+}
+
+func TestNew_ErroredScoreFailsCase(t *testing.T) {
+	r := erroredReport()
+	if r.Passed || r.FailedCases != 1 {
+		t.Errorf("expected the errored case to fail the report, got passed=%v failed=%d", r.Passed, r.FailedCases)
+	}
+	if !r.Cases[0].Summary.Passed || r.Cases[1].Summary.Passed {
+		t.Errorf("expected only q2 to fail, got %+v and %+v", r.Cases[0].Summary, r.Cases[1].Summary)
+	}
+}
+
+func TestNew_Options(t *testing.T) {
+	cases := []Case{
+		{ID: "a", Scores: []llmops.MetricScore{{Name: "toxicity", Score: 0.1}}},
+		{ID: "b", Scores: []llmops.MetricScore{{Name: "toxicity", Score: 0.8}}},
+		{ID: "c", Scores: []llmops.MetricScore{{Name: "toxicity", Score: 0.4}}},
+	}
+
+	r := New("toxicity", cases, WithWorstExamples(2))
+	if got, want := worstIDs(r.Metrics[0]), []string{"a", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected ungated scores to default to higher-is-better %v, got %v", want, got)
+	}
+	if len(r.Metrics[0].Histogram) != 10 || !r.Passed {
+		t.Errorf("expected 10 buckets and no gated failures, got %d buckets, passed=%v", len(r.Metrics[0].Histogram), r.Passed)
+	}
+
+	r = New("toxicity", cases, WithWorstExamples(2), WithMetrics(metrics.NewToxicityMetric(nil)))
+	if got, want := worstIDs(r.Metrics[0]), []string{"b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected WithMetrics to set lower-is-better %v, got %v", want, got)
+	}
+}
+
+func TestFromExperimentItems(t *testing.T) {
+	r := FromExperimentItems("experiment", []llmops.ExperimentItem{
+		{ID: "run-1", DatasetItemID: "item-a", TraceID: "trace-1", Output: "out"},
+		{ID: "run-2"},
+		{},
+	})
+
+	var ids []string
+	for _, c := range r.Cases {
+		ids = append(ids, c.ID)
+	}
+	if want := []string{"item-a", "run-2", "item-3"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("expected case IDs %v, got %v", want, ids)
+	}
+	if c := r.Cases[0]; c.TraceID != "trace-1" || c.Output != "out" {
+		t.Errorf("expected the trace ID and output to be kept, got %+v", c)
+	}
+}
+
+func TestFromEvalResults(t *testing.T) {
+	r := FromEvalResults("results", []*llmops.EvalResult{
+		{Metadata: map[string]any{"id": "q1", "trace_id": "trace-1"}, Duration: time.Second},
+		nil,
+		{Scores: []llmops.MetricScore{{Name: "relevance", Score: 1}}},
+	})
+
+	if len(r.Cases) != 2 {
+		t.Fatalf("expected nil results to be skipped, got %d cases", len(r.Cases))
+	}
+	if c := r.Cases[0]; c.ID != "q1" || c.TraceID != "trace-1" || c.Duration != time.Second {
+		t.Errorf("expected ID, trace ID and duration from the result, got %+v", c)
+	}
+	if c := r.Cases[1]; c.ID != "result-3" {
+		t.Errorf("expected a positional ID, got %q", c.ID)
+	}
+}
+
+func TestWriteJUnit_Counts(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testReport().WriteJUnit(&buf); err != nil {
+		t.Fatalf("WriteJUnit: %v", err)
+	}
+
+	var suites junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &suites); err != nil {
+		t.Fatalf("invalid JUnit XML: %v", err)
+	}
+	if suites.Tests != 6 || suites.Failures != 2 || suites.Errors != 1 || suites.Time != "3.500" {
+		t.Errorf("expected 6 tests, 2 failures and 1 error in 3.500s, got %d, %d, %d in %s",
+			suites.Tests, suites.Failures, suites.Errors, suites.Time)
+	}
+
+	hallucination := suites.Suites[1]
+	if hallucination.Name != "hallucination" || hallucination.Failures != 1 || hallucination.Errors != 1 {
+		t.Fatalf("unexpected hallucination suite: %+v", hallucination)
+	}
+	// A judge error is reported as an error, not also as a failure
+	failed := hallucination.Cases[2]
+	if failed.Error == nil || failed.Failure != nil || failed.Error.Message != "judge timed out" {
+		t.Errorf("expected case-3 to be an error only, got %+v", failed)
+	}
+	if f := hallucination.Cases[1].Failure; f == nil || f.Type != "threshold" {
+		t.Errorf("expected case-2 to be a threshold failure, got %+v", hallucination.Cases[1])
+	}
+}
+
+func TestWriters_Golden(t *testing.T) {
+	markdown := func(r *Report, b *bytes.Buffer) error { return r.WriteMarkdown(b) }
+	html := func(r *Report, b *bytes.Buffer) error { return r.WriteHTML(b) }
+	junit := func(r *Report, b *bytes.Buffer) error { return r.WriteJUnit(b) }
+	tests := []struct {
+		file   string
+		report func() *Report
+		write  func(*Report, *bytes.Buffer) error
+	}{
+		{"report.md", testReport, markdown},
+		{"report.html", testReport, html},
+		{"junit.xml", testReport, junit},
+		{"errored.md", erroredReport, markdown},
+		{"errored.xml", erroredReport, junit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.write(tt.report(), &buf); err != nil {
+				t.Fatal(err)
+			}
+
+			path := filepath.Join("testdata", tt.file)
+			if *update {
+				if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("read golden file (run with -update to create it): %v", err)
+			}
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Errorf("output differs from %s (run with -update to accept):\n%s", path, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package llmops
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ScoreConfig describes the allowed values of a named feedback score.
+// Registered configs are used to validate feedback before it is sent.
+type ScoreConfig struct {
+	ID          string          `json:"id,omitempty"` // Provider-side config ID, if any
+	Name        string          `json:"name"`
+	DataType    ScoreDataType   `json:"data_type"`
+	MinValue    *float64        `json:"min_value,omitempty"` // Numeric only
+	MaxValue    *float64        `json:"max_value,omitempty"` // Numeric only
+	Categories  []ScoreCategory `json:"categories,omitempty"`
+	Description string          `json:"description,omitempty"`
+}
+
+// ScoreCategory is an allowed value of a categorical score.
+type ScoreCategory struct {
+	Label string  `json:"label"`
+	Value float64 `json:"value"`
+}
+
+// Category returns the category with the given label, if any.
+func (c *ScoreConfig) Category(label string) (ScoreCategory, bool) {
+	for _, cat := range c.Categories {
+		if cat.Label == label {
+			return cat, true
+		}
+	}
+	return ScoreCategory{}, false
+}
+
+// Check verifies that the config itself is well-formed.
+func (c *ScoreConfig) Check() error {
+	if c.Name == "" {
+		return fmt.Errorf("%w: score config requires a name", ErrInvalidScore)
+	}
+	switch c.DataType {
+	case ScoreDataTypeNumeric:
+		if c.MinValue != nil && c.MaxValue != nil && *c.MinValue > *c.MaxValue {
+			return fmt.Errorf("%w: score config %q has min value greater than max value", ErrInvalidScore, c.Name)
+		}
+	case ScoreDataTypeCategorical:
+		if len(c.Categories) == 0 {
+			return fmt.Errorf("%w: categorical score config %q requires categories", ErrInvalidScore, c.Name)
+		}
+	case ScoreDataTypeBoolean:
+	default:
+		return fmt.Errorf("%w: score config %q has unknown data type %q", ErrInvalidScore, c.Name, c.DataType)
+	}
+	return nil
+}
+
+// Validate checks a feedback score against the config.
+func (c *ScoreConfig) Validate(score FeedbackScore) error {
+	dataType := score.ResolvedDataType()
+	if dataType != c.DataType {
+		return fmt.Errorf("%w: %q expects %s values, got %s", ErrInvalidScore, c.Name, c.DataType, dataType)
+	}
+
+	switch dataType {
+	case ScoreDataTypeNumeric:
+		if c.MinValue != nil && score.Score < *c.MinValue {
+			return fmt.Errorf("%w: %q value %g is below minimum %g", ErrInvalidScore, c.Name, score.Score, *c.MinValue)
+		}
+		if c.MaxValue != nil && score.Score > *c.MaxValue {
+			return fmt.Errorf("%w: %q value %g is above maximum %g", ErrInvalidScore, c.Name, score.Score, *c.MaxValue)
+		}
+	case ScoreDataTypeCategorical:
+		if _, ok := c.Category(score.Label); !ok {
+			return fmt.Errorf("%w: %q does not allow category %q", ErrInvalidScore, c.Name, score.Label)
+		}
+	case ScoreDataTypeBoolean:
+		if score.Score != 0 && score.Score != 1 {
+			return fmt.Errorf("%w: %q boolean value must be 0 or 1, got %g", ErrInvalidScore, c.Name, score.Score)
+		}
+	}
+	return nil
+}
+
+// ValidateFeedbackScore checks a feedback score against its registered
+// config, if any. Scores without a registered config are only checked for
+// basic consistency of their data type.
+func ValidateFeedbackScore(score FeedbackScore) error {
+	if cfg, ok := LookupScoreConfig(score.Name); ok {
+		return cfg.Validate(score)
+	}
+
+	switch score.ResolvedDataType() {
+	case ScoreDataTypeNumeric:
+		return nil
+	case ScoreDataTypeCategorical:
+		if score.Label == "" {
+			return fmt.Errorf("%w: categorical score %q requires a label", ErrInvalidScore, score.Name)
+		}
+	case ScoreDataTypeBoolean:
+		if score.Score != 0 && score.Score != 1 {
+			return fmt.Errorf("%w: boolean score %q must be 0 or 1, got %g", ErrInvalidScore, score.Name, score.Score)
+		}
+	default:
+		return fmt.Errorf("%w: score %q has unknown data type %q", ErrInvalidScore, score.Name, score.DataType)
+	}
+	return nil
+}
+
+var (
+	scoreConfigsMu sync.RWMutex
+	scoreConfigs   = make(map[string]ScoreConfig)
+)
+
+// RegisterScoreConfig registers a score config by name, replacing any
+// existing config with the same name.
+func RegisterScoreConfig(cfg ScoreConfig) error {
+	if err := cfg.Check(); err != nil {
+		return err
+	}
+
+	scoreConfigsMu.Lock()
+	defer scoreConfigsMu.Unlock()
+	scoreConfigs[cfg.Name] = cfg
+	return nil
+}
+
+// LookupScoreConfig returns the registered config for a score name.
+func LookupScoreConfig(name string) (ScoreConfig, bool) {
+	scoreConfigsMu.RLock()
+	defer scoreConfigsMu.RUnlock()
+	cfg, ok := scoreConfigs[name]
+	return cfg, ok
+}
+
+// ScoreConfigs returns all registered score configs sorted by name.
+func ScoreConfigs() []ScoreConfig {
+	scoreConfigsMu.RLock()
+	defer scoreConfigsMu.RUnlock()
+
+	configs := make([]ScoreConfig, 0, len(scoreConfigs))
+	for _, cfg := range scoreConfigs {
+		configs = append(configs, cfg)
+	}
+	sort.Slice(configs, func(i, j int) bool {
+		return configs[i].Name < configs[j].Name
+	})
+	return configs
+}
+
+// UnregisterScoreConfig removes a score config from the registry.
+func UnregisterScoreConfig(name string) {
+	scoreConfigsMu.Lock()
+	defer scoreConfigsMu.Unlock()
+	delete(scoreConfigs, name)
+}
+
+// ScoreConfigManager manages score configs stored by the provider.
+//
+// ScoreConfigManager is optional. Check for support with a type assertion:
+//
+//	if sm, ok := provider.(llmops.ScoreConfigManager); ok {
+//		cfg, err := sm.CreateScoreConfig(ctx, llmops.ScoreConfig{...})
+//	}
+type ScoreConfigManager interface {
+	// CreateScoreConfig creates a score config in the provider.
+	CreateScoreConfig(ctx context.Context, cfg ScoreConfig) (*ScoreConfig, error)
+
+	// ListScoreConfigs lists score configs stored in the provider.
+	ListScoreConfigs(ctx context.Context, opts ...ListOption) ([]*ScoreConfig, error)
+}
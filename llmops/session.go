@@ -0,0 +1,96 @@
+package llmops
+
+import (
+	"context"
+	"time"
+)
+
+// SessionManager handles conversation threads. A thread groups the traces
+// of a multi-turn conversation that were tagged with WithThreadID.
+//
+// SessionManager is optional. Check for support with a type assertion:
+//
+//	if sm, ok := provider.(llmops.SessionManager); ok {
+//		thread, err := sm.GetThread(ctx, "conversation-123")
+//	}
+type SessionManager interface {
+	// ListThreads lists conversation threads.
+	// Threads returned by ListThreads do not include aggregated statistics;
+	// use GetThread to retrieve them.
+	ListThreads(ctx context.Context, opts ...ListOption) ([]*Thread, error)
+
+	// GetThread retrieves a thread with statistics aggregated over its traces.
+	GetThread(ctx context.Context, threadID string) (*Thread, error)
+
+	// ListThreadTraces lists the traces of a thread in chronological order.
+	ListThreadTraces(ctx context.Context, threadID string, opts ...ListOption) ([]*TraceInfo, error)
+
+	// AddThreadFeedbackScore adds a feedback score to a whole thread.
+	AddThreadFeedbackScore(ctx context.Context, threadID, name string, score float64, opts ...FeedbackOption) error
+}
+
+// Thread represents a conversation thread (session) spanning multiple traces.
+type Thread struct {
+	ID        string         `json:"id"`
+	ProjectID string         `json:"project_id,omitempty"`
+	Stats     *ThreadStats   `json:"stats,omitempty"` // Set by GetThread
+	Metadata  map[string]any `json:"metadata,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// ThreadStats holds token, cost, and latency totals for a thread.
+type ThreadStats struct {
+	TraceCount   int           `json:"trace_count"`
+	Usage        TokenUsage    `json:"usage"`
+	TotalLatency time.Duration `json:"total_latency"`
+	AvgLatency   time.Duration `json:"avg_latency"`
+	MaxLatency   time.Duration `json:"max_latency"`
+	FirstTraceAt time.Time     `json:"first_trace_at"`
+	LastTraceAt  time.Time     `json:"last_trace_at"`
+}
+
+// SummarizeThread aggregates token usage, cost, and latency over the traces
+// of a thread. Traces without usage information contribute only to counts
+// and latency.
+func SummarizeThread(traces []*TraceInfo) ThreadStats {
+	stats := ThreadStats{}
+	for _, t := range traces {
+		if t == nil {
+			continue
+		}
+		stats.TraceCount++
+
+		if stats.FirstTraceAt.IsZero() || t.StartTime.Before(stats.FirstTraceAt) {
+			stats.FirstTraceAt = t.StartTime
+		}
+		if t.StartTime.After(stats.LastTraceAt) {
+			stats.LastTraceAt = t.StartTime
+		}
+
+		latency := t.Latency
+		if latency == 0 && t.EndTime != nil {
+			latency = t.EndTime.Sub(t.StartTime)
+		}
+		stats.TotalLatency += latency
+		if latency > stats.MaxLatency {
+			stats.MaxLatency = latency
+		}
+
+		if t.Usage != nil {
+			stats.Usage.PromptTokens += t.Usage.PromptTokens
+			stats.Usage.CompletionTokens += t.Usage.CompletionTokens
+			stats.Usage.TotalTokens += t.Usage.TotalTokens
+			stats.Usage.PromptCost += t.Usage.PromptCost
+			stats.Usage.CompletionCost += t.Usage.CompletionCost
+			stats.Usage.TotalCost += t.Usage.TotalCost
+			if stats.Usage.Currency == "" {
+				stats.Usage.Currency = t.Usage.Currency
+			}
+		}
+	}
+
+	if stats.TraceCount > 0 {
+		stats.AvgLatency = stats.TotalLatency / time.Duration(stats.TraceCount)
+	}
+	return stats
+}
@@ -122,12 +122,28 @@ type TokenUsage struct {
 }
 
 // FeedbackScore represents a score given to a trace or span.
+// Categorical scores carry their value in Label; boolean scores use
+// Score 1.0 for true and 0.0 for false.
 type FeedbackScore struct {
-	Name     string  `json:"name"`
-	Score    float64 `json:"score"`
-	Reason   string  `json:"reason,omitempty"`
-	Category string  `json:"category,omitempty"`
-	Source   string  `json:"source,omitempty"` // e.g., "user", "llm", "heuristic"
+	Name     string        `json:"name"`
+	Score    float64       `json:"score"`
+	DataType ScoreDataType `json:"data_type,omitempty"`
+	Label    string        `json:"label,omitempty"`
+	Reason   string        `json:"reason,omitempty"`
+	Category string        `json:"category,omitempty"`
+	Source   string        `json:"source,omitempty"` // e.g., "user", "llm", "heuristic"
+}
+
+// ResolvedDataType returns the score's data type. If none is set, it is
+// categorical when Label is set and numeric otherwise.
+func (f FeedbackScore) ResolvedDataType() ScoreDataType {
+	if f.DataType != "" {
+		return f.DataType
+	}
+	if f.Label != "" {
+		return ScoreDataTypeCategorical
+	}
+	return ScoreDataTypeNumeric
 }
 
 // TraceInfo provides read-only information about a trace.
@@ -135,12 +151,15 @@ type TraceInfo struct {
 	ID        string          `json:"id"`
 	Name      string          `json:"name"`
 	ProjectID string          `json:"project_id,omitempty"`
+	ThreadID  string          `json:"thread_id,omitempty"`
 	StartTime time.Time       `json:"start_time"`
 	EndTime   *time.Time      `json:"end_time,omitempty"`
+	Latency   time.Duration   `json:"latency,omitempty"`
 	Input     any             `json:"input,omitempty"`
 	Output    any             `json:"output,omitempty"`
 	Metadata  map[string]any  `json:"metadata,omitempty"`
 	Tags      []string        `json:"tags,omitempty"`
+	Usage     *TokenUsage     `json:"usage,omitempty"` // Aggregated over the trace's LLM spans
 	Feedback  []FeedbackScore `json:"feedback,omitempty"`
 }
 
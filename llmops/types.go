@@ -1,6 +1,10 @@
 package llmops
 
-import "time"
+import (
+	"math"
+	"sort"
+	"time"
+)
 
 // EvalInput represents input for evaluation.
 type EvalInput struct {
@@ -41,14 +45,31 @@ type Metric interface {
 }
 
 // FeedbackScoreOpts configures feedback score creation.
+// Categorical scores carry their value in Label; boolean scores use
+// Score 1.0 for true and 0.0 for false.
 type FeedbackScoreOpts struct {
-	TraceID  string  `json:"trace_id,omitempty"`
-	SpanID   string  `json:"span_id,omitempty"`
-	Name     string  `json:"name"`
-	Score    float64 `json:"score"`
-	Reason   string  `json:"reason,omitempty"`
-	Category string  `json:"category,omitempty"`
-	Source   string  `json:"source,omitempty"`
+	TraceID  string        `json:"trace_id,omitempty"`
+	SpanID   string        `json:"span_id,omitempty"`
+	Name     string        `json:"name"`
+	Score    float64       `json:"score"`
+	DataType ScoreDataType `json:"data_type,omitempty"` // Defaults to numeric, or categorical if Label is set
+	Label    string        `json:"label,omitempty"`     // Categorical value
+	Reason   string        `json:"reason,omitempty"`
+	Category string        `json:"category,omitempty"`
+	Source   string        `json:"source,omitempty"`
+}
+
+// FeedbackScore returns the feedback score described by the options.
+func (o FeedbackScoreOpts) FeedbackScore() FeedbackScore {
+	return FeedbackScore{
+		Name:     o.Name,
+		Score:    o.Score,
+		DataType: o.DataType,
+		Label:    o.Label,
+		Reason:   o.Reason,
+		Category: o.Category,
+		Source:   o.Source,
+	}
 }
 
 // Prompt represents a prompt template.
@@ -171,6 +192,7 @@ type Annotation struct {
 	Name        string         `json:"name"`               // Annotation name/type
 	Score       float64        `json:"score,omitempty"`    // Numeric score (0-1)
 	Label       string         `json:"label,omitempty"`    // Categorical label
+	DataType    ScoreDataType  `json:"data_type,omitempty"`
 	Explanation string         `json:"explanation,omitempty"`
 	Source      AnnotatorKind  `json:"source,omitempty"` // Who created: human, llm, code
 	Metadata    map[string]any `json:"metadata,omitempty"`
@@ -187,6 +209,18 @@ const (
 	AnnotatorKindCode  AnnotatorKind = "code"
 )
 
+// ScoreDataType indicates how a score or annotation value is interpreted.
+type ScoreDataType string
+
+const (
+	// ScoreDataTypeNumeric is a numeric value carried in Score.
+	ScoreDataTypeNumeric ScoreDataType = "numeric"
+	// ScoreDataTypeCategorical is a label carried in Label.
+	ScoreDataTypeCategorical ScoreDataType = "categorical"
+	// ScoreDataTypeBoolean is true (1.0) or false (0.0) carried in Score.
+	ScoreDataTypeBoolean ScoreDataType = "boolean"
+)
+
 // StreamChunk represents a chunk from streaming LLM output.
 type StreamChunk struct {
 	Content      string         `json:"content,omitempty"`
@@ -194,6 +228,7 @@ type StreamChunk struct {
 	Index        int            `json:"index,omitempty"`
 	FinishReason string         `json:"finish_reason,omitempty"`
 	Metadata     map[string]any `json:"metadata,omitempty"`
+	ReceivedAt   time.Time      `json:"received_at,omitempty"` // Set by AddChunk if zero
 }
 
 // StreamAccumulator accumulates streaming chunks.
@@ -204,7 +239,9 @@ type StreamAccumulator struct {
 	ChunkCount   int           `json:"chunk_count"`
 	StartTime    time.Time     `json:"start_time"`
 	FirstChunkAt *time.Time    `json:"first_chunk_at,omitempty"`
+	EndTime      *time.Time    `json:"end_time,omitempty"`
 	FinishReason string        `json:"finish_reason,omitempty"`
+	Usage        *TokenUsage   `json:"usage,omitempty"` // Reported by the provider, if any
 }
 
 // NewStreamAccumulator creates a new stream accumulator.
@@ -217,9 +254,12 @@ func NewStreamAccumulator() *StreamAccumulator {
 
 // AddChunk adds a chunk to the accumulator.
 func (a *StreamAccumulator) AddChunk(chunk StreamChunk) {
+	if chunk.ReceivedAt.IsZero() {
+		chunk.ReceivedAt = time.Now()
+	}
 	if a.FirstChunkAt == nil {
-		now := time.Now()
-		a.FirstChunkAt = &now
+		first := chunk.ReceivedAt
+		a.FirstChunkAt = &first
 	}
 	a.Chunks = append(a.Chunks, chunk)
 	a.TotalContent += chunk.Content
@@ -238,10 +278,58 @@ func (a *StreamAccumulator) TimeToFirstChunk() time.Duration {
 	return a.FirstChunkAt.Sub(a.StartTime)
 }
 
-// TotalDuration returns the total streaming duration.
+// SetUsage records the token usage reported by the provider.
+func (a *StreamAccumulator) SetUsage(usage TokenUsage) {
+	a.Usage = &usage
+}
+
+// End marks the stream as complete.
+func (a *StreamAccumulator) End() {
+	if a.EndTime == nil {
+		now := time.Now()
+		a.EndTime = &now
+	}
+}
+
+// TotalDuration returns the total streaming duration, or the time since
+// start if the stream has not ended.
 func (a *StreamAccumulator) TotalDuration() time.Duration {
+	if a.EndTime != nil {
+		return a.EndTime.Sub(a.StartTime)
+	}
 	if len(a.Chunks) == 0 {
 		return 0
 	}
 	return time.Since(a.StartTime)
 }
+
+// InterChunkLatencies returns the delays between consecutive chunks.
+func (a *StreamAccumulator) InterChunkLatencies() []time.Duration {
+	if len(a.Chunks) < 2 {
+		return nil
+	}
+	latencies := make([]time.Duration, 0, len(a.Chunks)-1)
+	for i := 1; i < len(a.Chunks); i++ {
+		latencies = append(latencies, a.Chunks[i].ReceivedAt.Sub(a.Chunks[i-1].ReceivedAt))
+	}
+	return latencies
+}
+
+// InterChunkLatency returns the p-th percentile (0-100) of the delays
+// between consecutive chunks, using the nearest-rank method.
+func (a *StreamAccumulator) InterChunkLatency(p float64) time.Duration {
+	latencies := a.InterChunkLatencies()
+	if len(latencies) == 0 {
+		return 0
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	rank := int(math.Ceil(p / 100 * float64(len(latencies))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(latencies) {
+		rank = len(latencies)
+	}
+	return latencies[rank-1]
+}
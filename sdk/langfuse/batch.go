@@ -0,0 +1,240 @@
+package langfuse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"unicode/utf8"
+)
+
+// Default payload size limits, matching the Langfuse ingestion API.
+const (
+	DefaultMaxEventBytes = 1_000_000
+	DefaultMaxBatchBytes = 2_500_000
+)
+
+// TruncatedMarker is appended to payloads shortened to fit the event size limit.
+const TruncatedMarker = "...[truncated by langfuse-go-sdk]"
+
+// batchOverhead is the size of the batch request envelope, excluding events.
+const batchOverhead = len(`{"batch":[]}`)
+
+// PayloadOffloader stores an oversized event payload elsewhere and returns
+// a reference to it, such as a URL. field is "input", "output" or "metadata".
+type PayloadOffloader func(ctx context.Context, eventID, field string, payload []byte) (string, error)
+
+// EventError describes an event that Langfuse did not accept.
+type EventError struct {
+	EventID   string
+	EventType string
+	Status    int    // HTTP status for the event, or 0 for transport errors
+	Message   string // Error message from Langfuse
+	Spooled   bool   // Whether the event was saved to the spool directory
+}
+
+func (e EventError) Error() string {
+	return fmt.Sprintf("langfuse: event %s (%s) failed with status %d: %s", e.EventID, e.EventType, e.Status, e.Message)
+}
+
+// Retryable reports whether the event may be accepted if sent again.
+func (e EventError) Retryable() bool {
+	return e.Status == http.StatusTooManyRequests ||
+		e.Status == http.StatusRequestTimeout ||
+		e.Status >= 500
+}
+
+// BatchError is returned when Langfuse accepts a batch with HTTP 207 but
+// rejects some of its events.
+type BatchError struct {
+	Errors []EventError
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("langfuse: %d events rejected in batch", len(e.Errors))
+}
+
+// multiStatusResponse is the body of an HTTP 207 ingestion response.
+type multiStatusResponse struct {
+	Errors []struct {
+		ID      string `json:"id"`
+		Status  int    `json:"status"`
+		Message string `json:"message"`
+		Error   any    `json:"error"`
+	} `json:"errors"`
+}
+
+// parseMultiStatus converts the per-event errors of a 207 response into a
+// *BatchError. It returns nil if every event succeeded.
+func parseMultiStatus(body []byte, events []Event) error {
+	var resp multiStatusResponse
+	if err := json.Unmarshal(body, &resp); err != nil || len(resp.Errors) == 0 {
+		return nil
+	}
+
+	types := make(map[string]string, len(events))
+	for _, e := range events {
+		types[e.ID] = e.Type
+	}
+
+	batchErr := &BatchError{}
+	for _, e := range resp.Errors {
+		msg := e.Message
+		if msg == "" && e.Error != nil {
+			msg = fmt.Sprint(e.Error)
+		}
+		batchErr.Errors = append(batchErr.Errors, EventError{
+			EventID:   e.ID,
+			EventType: types[e.ID],
+			Status:    e.Status,
+			Message:   msg,
+		})
+	}
+	return batchErr
+}
+
+// reportEventError passes a failed event to the error handler, if any.
+func (c *Client) reportEventError(e EventError) {
+	if c.onEventError != nil {
+		c.onEventError(e)
+	}
+}
+
+// splitBatch splits events into batches whose serialized size fits the
+// batch size limit, shrinking oversized events first.
+func (c *Client) splitBatch(ctx context.Context, events []Event) [][]Event {
+	var (
+		batches [][]Event
+		current []Event
+		size    = batchOverhead
+	)
+	for _, e := range events {
+		e, n := c.fitEvent(ctx, e)
+		if len(current) > 0 && size+n+1 > c.maxBatchBytes {
+			batches = append(batches, current)
+			current, size = nil, batchOverhead
+		}
+		current = append(current, e)
+		size += n + 1 // Separating comma
+	}
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+	return batches
+}
+
+// fitEvent shrinks an event's input, output and metadata, largest first,
+// until the serialized event fits the event size limit. Each shrunk payload
+// is offloaded if an offloader is configured, or truncated with
+// TruncatedMarker otherwise. It returns the event and its serialized size.
+func (c *Client) fitEvent(ctx context.Context, e Event) (Event, int) {
+	data, err := json.Marshal(e)
+	if err != nil || len(data) <= c.maxEventBytes {
+		return e, len(data)
+	}
+
+	e.Body = copyBody(e.Body)
+	fields := payloadFields(e.Body)
+	for len(data) > c.maxEventBytes && len(fields) > 0 {
+		// Shrink the largest remaining payload
+		largest := 0
+		for i := range fields {
+			if len(fields[i].data) > len(fields[largest].data) {
+				largest = i
+			}
+		}
+		field := fields[largest]
+		fields = append(fields[:largest], fields[largest+1:]...)
+
+		keep := len(field.data) - (len(data) - c.maxEventBytes) - len(TruncatedMarker) - 64
+		field.set(c.shrinkPayload(ctx, e.ID, field.name, field.data, keep))
+
+		if data, err = json.Marshal(e); err != nil {
+			break
+		}
+	}
+	return e, len(data)
+}
+
+// shrinkPayload returns a replacement for an oversized payload.
+func (c *Client) shrinkPayload(ctx context.Context, eventID, field string, payload []byte, keep int) string {
+	if c.offloader != nil {
+		ref, err := c.offloader(ctx, eventID, field, payload)
+		if err == nil {
+			return fmt.Sprintf("[offloaded %d bytes: %s]", len(payload), ref)
+		}
+	}
+
+	// Truncate the JSON text, backing up to a rune boundary
+	if keep < 0 {
+		keep = 0
+	}
+	for keep > 0 && !utf8.RuneStart(payload[keep]) {
+		keep--
+	}
+	return string(payload[:keep]) + TruncatedMarker
+}
+
+// copyBody returns a copy of an event body that can be modified without
+// affecting the original.
+func copyBody(body any) any {
+	switch b := body.(type) {
+	case TraceBody:
+		return &b
+	case SpanBody:
+		return &b
+	case GenerationBody:
+		return &b
+	case map[string]any:
+		return maps.Clone(b)
+	}
+	return body
+}
+
+// payloadField is a shrinkable field of an event body.
+type payloadField struct {
+	name string
+	data []byte // Serialized value
+	set  func(string)
+}
+
+// payloadFields returns the non-empty input, output and metadata fields of
+// an event body. Bodies replayed from the spool are decoded as maps.
+func payloadFields(body any) []payloadField {
+	var fields []payloadField
+	add := func(name string, value any, set func(string)) {
+		if value == nil {
+			return
+		}
+		data, err := json.Marshal(value)
+		if err != nil || string(data) == "null" {
+			return
+		}
+		fields = append(fields, payloadField{name: name, data: data, set: set})
+	}
+
+	switch b := body.(type) {
+	case *TraceBody:
+		add("input", b.Input, func(s string) { b.Input = s })
+		add("output", b.Output, func(s string) { b.Output = s })
+		add("metadata", b.Metadata, func(s string) { b.Metadata = map[string]any{"truncated": s} })
+	case *SpanBody:
+		add("input", b.Input, func(s string) { b.Input = s })
+		add("output", b.Output, func(s string) { b.Output = s })
+		add("metadata", b.Metadata, func(s string) { b.Metadata = map[string]any{"truncated": s} })
+	case *GenerationBody:
+		add("input", b.Input, func(s string) { b.Input = s })
+		add("output", b.Output, func(s string) { b.Output = s })
+		add("metadata", b.Metadata, func(s string) { b.Metadata = map[string]any{"truncated": s} })
+	case map[string]any:
+		for _, name := range []string{"input", "output", "metadata"} {
+			if name == "metadata" {
+				add(name, b[name], func(s string) { b[name] = map[string]any{"truncated": s} })
+			} else {
+				add(name, b[name], func(s string) { b[name] = s })
+			}
+		}
+	}
+	return fields
+}
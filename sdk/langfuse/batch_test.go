@@ -0,0 +1,335 @@
+package langfuse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// =============================================================================
+// Batch Splitting Tests
+// =============================================================================
+
+func TestSplitBatch_BySize(t *testing.T) {
+	events := testEvents(10)
+	for i := range events {
+		body := events[i].Body.(SpanBody)
+		body.Input = strings.Repeat("x", 200)
+		events[i].Body = body
+	}
+	one, _ := json.Marshal(events[0])
+
+	c := &Client{maxEventBytes: DefaultMaxEventBytes, maxBatchBytes: 3*len(one) + 50}
+	batches := c.splitBatch(context.Background(), events)
+
+	if len(batches) != 4 {
+		t.Fatalf("expected 4 batches of up to 3 events, got %d", len(batches))
+	}
+	var ids []string
+	for i, batch := range batches {
+		data, _ := json.Marshal(BatchIngestionRequest{Batch: batch})
+		if len(data) > c.maxBatchBytes {
+			t.Errorf("batch %d is %d bytes, over the %d byte limit", i, len(data), c.maxBatchBytes)
+		}
+		for _, e := range batch {
+			ids = append(ids, e.ID)
+		}
+	}
+	want := []string{"e1", "e2", "e3", "e4", "e5", "e6", "e7", "e8", "e9", "e10"}
+	if !equalIDs(ids, want) {
+		t.Errorf("expected events in order, got %v", ids)
+	}
+}
+
+func TestSplitBatch_OversizedEventAlone(t *testing.T) {
+	c := &Client{maxEventBytes: DefaultMaxEventBytes, maxBatchBytes: 10}
+	batches := c.splitBatch(context.Background(), testEvents(3))
+	if len(batches) != 3 {
+		t.Errorf("expected each event in its own batch, got %d batches", len(batches))
+	}
+}
+
+func TestFitEvent_Truncates(t *testing.T) {
+	c := &Client{maxEventBytes: 1000}
+	e := testEvents(1)[0]
+	body := e.Body.(SpanBody)
+	body.Input = strings.Repeat("é", 2000)
+	body.Output = "short"
+	e.Body = body
+
+	fitted, n := c.fitEvent(context.Background(), e)
+	if n > 1000 {
+		t.Errorf("expected event to fit in 1000 bytes, got %d", n)
+	}
+	got := fitted.Body.(*SpanBody)
+	input, _ := got.Input.(string)
+	if !strings.HasSuffix(input, TruncatedMarker) {
+		t.Errorf("expected truncated input to end with marker, got %q", input)
+	}
+	if !utf8.ValidString(input) {
+		t.Error("expected truncation at a rune boundary")
+	}
+	if got.Output != "short" {
+		t.Errorf("expected small output to be kept, got %v", got.Output)
+	}
+	if _, ok := e.Body.(SpanBody).Input.(string); !ok || len(e.Body.(SpanBody).Input.(string)) != 4000 {
+		t.Error("expected the original event to be unchanged")
+	}
+}
+
+func TestFitEvent_SmallEventUnchanged(t *testing.T) {
+	c := &Client{maxEventBytes: DefaultMaxEventBytes}
+	e := testEvents(1)[0]
+	fitted, n := c.fitEvent(context.Background(), e)
+	data, _ := json.Marshal(e)
+	if n != len(data) {
+		t.Errorf("expected size %d, got %d", len(data), n)
+	}
+	if _, ok := fitted.Body.(SpanBody); !ok {
+		t.Errorf("expected body to be left as is, got %T", fitted.Body)
+	}
+}
+
+func TestFitEvent_Offloads(t *testing.T) {
+	var offloaded []string
+	c := &Client{
+		maxEventBytes: 500,
+		offloader: func(_ context.Context, eventID, field string, payload []byte) (string, error) {
+			offloaded = append(offloaded, eventID+"/"+field)
+			return "s3://bucket/" + eventID, nil
+		},
+	}
+	e := testEvents(1)[0]
+	body := e.Body.(SpanBody)
+	body.Output = strings.Repeat("y", 2000)
+	e.Body = body
+
+	fitted, n := c.fitEvent(context.Background(), e)
+	if n > 500 {
+		t.Errorf("expected event to fit in 500 bytes, got %d", n)
+	}
+	if len(offloaded) != 1 || offloaded[0] != "e1/output" {
+		t.Errorf("expected output of e1 to be offloaded, got %v", offloaded)
+	}
+	if out, _ := fitted.Body.(*SpanBody).Output.(string); !strings.Contains(out, "s3://bucket/e1") {
+		t.Errorf("expected reference to offloaded payload, got %q", out)
+	}
+}
+
+func TestFitEvent_SpooledMapBody(t *testing.T) {
+	c := &Client{maxEventBytes: 1000}
+	e := Event{ID: "e1", Type: EventTypeSpanCreate, Body: map[string]any{
+		"id":       "e1",
+		"metadata": map[string]any{"blob": strings.Repeat("z", 3000)},
+	}}
+
+	fitted, n := c.fitEvent(context.Background(), e)
+	if n > 1000 {
+		t.Errorf("expected event to fit in 1000 bytes, got %d", n)
+	}
+	md, _ := fitted.Body.(map[string]any)["metadata"].(map[string]any)
+	if s, _ := md["truncated"].(string); !strings.HasSuffix(s, TruncatedMarker) {
+		t.Errorf("expected truncated metadata, got %v", md)
+	}
+}
+
+// =============================================================================
+// Partial Failure Tests
+// =============================================================================
+
+func TestParseMultiStatus(t *testing.T) {
+	events := testEvents(2)
+	body := []byte(`{"successes":[{"id":"e1","status":201}],"errors":[{"id":"e2","status":400,"message":"invalid"}]}`)
+
+	err := parseMultiStatus(body, events)
+	batchErr, ok := err.(*BatchError)
+	if !ok || len(batchErr.Errors) != 1 {
+		t.Fatalf("expected BatchError with 1 error, got %v", err)
+	}
+	got := batchErr.Errors[0]
+	if got.EventID != "e2" || got.EventType != EventTypeSpanCreate || got.Status != 400 || got.Message != "invalid" {
+		t.Errorf("unexpected event error: %+v", got)
+	}
+	if got.Retryable() {
+		t.Error("expected 400 not to be retryable")
+	}
+
+	if err := parseMultiStatus([]byte(`{"successes":[],"errors":[]}`), events); err != nil {
+		t.Errorf("expected nil with no errors, got %v", err)
+	}
+}
+
+func TestSendWithRetry_PartialFailure(t *testing.T) {
+	server := newIngestionServer(t, func(w http.ResponseWriter, n int, batch []Event) {
+		if n > 1 {
+			return
+		}
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprint(w, `{"errors":[{"id":"e2","status":500,"message":"busy"},{"id":"e3","status":400,"message":"invalid"}]}`)
+	})
+
+	var mu sync.Mutex
+	var reported []EventError
+	c := newTestClient(t, server, WithEventErrorHandler(func(e EventError) {
+		mu.Lock()
+		reported = append(reported, e)
+		mu.Unlock()
+	}))
+
+	failed, err := c.sendWithRetry(context.Background(), testEvents(4))
+	if err != nil || len(failed) != 0 {
+		t.Fatalf("expected success after retry, got %v (%d failed)", err, len(failed))
+	}
+
+	ids := server.eventIDs()
+	if len(ids) != 2 || !equalIDs(ids[1], []string{"e2"}) {
+		t.Errorf("expected only e2 to be retried, got %v", ids)
+	}
+	stats := c.Stats()
+	if stats.Sent != 3 || stats.Failed != 1 {
+		t.Errorf("expected 3 sent and 1 failed, got %+v", stats)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reported) != 1 || reported[0].EventID != "e3" {
+		t.Errorf("expected e3 to be reported, got %+v", reported)
+	}
+}
+
+// =============================================================================
+// Spool Replay Tests
+// =============================================================================
+
+func TestReplaySpool_KeepsOnlyUndeliveredEvents(t *testing.T) {
+	dir := t.TempDir()
+	events := testEvents(4)
+	file := filepath.Join(dir, "batch-1"+spoolFileExt)
+	if err := writeSpoolFile(file, events); err != nil {
+		t.Fatal(err)
+	}
+
+	// Accept the first sub-batch and fail the rest
+	var down atomic.Bool
+	server := newIngestionServer(t, func(w http.ResponseWriter, n int, _ []Event) {
+		if n > 1 && down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+	down.Store(true)
+	one, _ := json.Marshal(events[0])
+
+	c := newTestClient(t, server,
+		WithSpoolDir(dir),
+		WithMaxRetries(0),
+		WithMaxBatchBytes(2*len(one)+50),
+	)
+	c.replaying.Wait()
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("expected spool file to be kept: %v", err)
+	}
+	var remaining []Event
+	if err := json.Unmarshal(data, &remaining); err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, e := range remaining {
+		ids = append(ids, e.ID)
+	}
+	if !equalIDs(ids, []string{"e3", "e4"}) {
+		t.Errorf("expected only e3 and e4 to remain spooled, got %v", ids)
+	}
+
+	down.Store(false)
+	if err := c.replaySpool(context.Background()); err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	ids = nil
+	for i, batch := range server.eventIDs() {
+		if i == 1 {
+			continue // The failed request
+		}
+		for _, id := range batch {
+			ids = append(ids, id)
+		}
+	}
+	if !equalIDs(ids, []string{"e1", "e2", "e3", "e4"}) {
+		t.Errorf("expected each event delivered once, got %v", ids)
+	}
+	if got := c.SpooledBatches(); got != 0 {
+		t.Errorf("expected spool to be empty, got %d", got)
+	}
+}
+
+func TestClose_StopsReplay(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "batch-1"+spoolFileExt)
+	if err := writeSpoolFile(file, testEvents(2)); err != nil {
+		t.Fatal(err)
+	}
+
+	server := newIngestionServer(t, func(w http.ResponseWriter, _ int, _ []Event) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	c := newTestClient(t, server,
+		WithSpoolDir(dir),
+		WithMaxRetries(10),
+		WithRetryBackoff(time.Hour, time.Hour),
+	)
+	// Wait for the replay to be backing off after its first attempt
+	for server.requestCount() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	closed := make(chan error, 1)
+	go func() { closed <- c.Close() }()
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Errorf("Close: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected Close to stop the replay instead of waiting for its retries")
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("expected the spool file to be kept: %v", err)
+	}
+	var remaining []Event
+	if err := json.Unmarshal(data, &remaining); err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != 2 {
+		t.Errorf("expected both events to stay spooled, got %d", len(remaining))
+	}
+}
+
+func TestReplaySpool_RemovesCorruptFiles(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "batch-1"+spoolFileExt)
+	if err := os.WriteFile(file, []byte("not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	server := newIngestionServer(t, nil)
+	c := newTestClient(t, server, WithSpoolDir(dir))
+	c.replaying.Wait()
+
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Errorf("expected corrupt spool file to be removed, got %v", err)
+	}
+	if got := server.requestCount(); got != 0 {
+		t.Errorf("expected no requests, got %d", got)
+	}
+}
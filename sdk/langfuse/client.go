@@ -21,13 +21,19 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/agentplexus/omniobserve/observops"
 )
 
 // Version is the SDK version.
@@ -50,12 +56,43 @@ type Client struct {
 	timeout    time.Duration
 
 	// Batching
-	mu          sync.Mutex
-	batch       []Event
-	batchSize   int
-	flushPeriod time.Duration
-	stopCh      chan struct{}
-	doneCh      chan struct{}
+	mu             sync.Mutex
+	notFull        *sync.Cond // Signalled when events leave the queue
+	queue          []Event
+	queueSize      int
+	overflow       OverflowPolicy
+	batchSize      int
+	flushPeriod    time.Duration
+	flushing       bool // Send partial batches until the queue is empty
+	closed         bool
+	numWorkers     int
+	workers        sync.WaitGroup
+	wakeCh         chan struct{}
+	sending        int           // Events currently being sent
+	sendingBatches int           // Batches currently being sent
+	idleCh         chan struct{} // Closed when sendingBatches drops to zero
+	stopCh         chan struct{}
+	doneCh         chan struct{}
+	stats          queueStats
+	meter          observops.Meter
+
+	// Delivery
+	maxEventBytes int
+	maxBatchBytes int
+	offloader     PayloadOffloader
+	onEventError  func(EventError)
+	maxRetries    int
+	retryWait     time.Duration
+	retryMaxWait  time.Duration
+	spoolDir      string
+	replaying     sync.WaitGroup
+	stopReplay    context.CancelFunc // Cancels the spool replay on Close
+	failed        *DeliveryError     // Background failures not yet reported by Flush
+
+	// OTLP export
+	otlpMode     bool
+	spanExporter sdktrace.SpanExporter
+	otlp         *otlpExporter
 
 	// State
 	disabled bool
@@ -68,11 +105,19 @@ func NewClient(opts ...Option) (*Client, error) {
 		endpoint:    DefaultEndpoint,
 		httpClient:  &http.Client{Timeout: 30 * time.Second},
 		timeout:     30 * time.Second,
-		batch:       make([]Event, 0, 100),
 		batchSize:   100,
 		flushPeriod: 5 * time.Second,
+		queueSize:   DefaultQueueSize,
+		numWorkers:  DefaultWorkers,
+		idleCh:      make(chan struct{}),
 		stopCh:      make(chan struct{}),
 		doneCh:      make(chan struct{}),
+
+		maxEventBytes: DefaultMaxEventBytes,
+		maxBatchBytes: DefaultMaxBatchBytes,
+		maxRetries:    DefaultMaxRetries,
+		retryWait:     DefaultRetryWait,
+		retryMaxWait:  DefaultRetryMaxWait,
 	}
 
 	for _, opt := range opts {
@@ -86,8 +131,51 @@ func NewClient(opts ...Option) (*Client, error) {
 		return nil, ErrMissingSecretKey
 	}
 
-	// Start background flusher
+	if c.queueSize < c.batchSize {
+		c.queueSize = c.batchSize
+	}
+	if c.numWorkers < 1 {
+		c.numWorkers = 1
+	}
+	c.notFull = sync.NewCond(&c.mu)
+	c.wakeCh = make(chan struct{}, 1)
+
+	if c.meter != nil {
+		if err := c.stats.initMeter(c.meter); err != nil {
+			return nil, err
+		}
+	}
+
+	if (c.otlpMode || c.spanExporter != nil) && !c.disabled {
+		otlp, err := newOTLPExporter(c)
+		if err != nil {
+			return nil, err
+		}
+		c.otlp = otlp
+	}
+
+	if c.spoolDir != "" && !c.disabled {
+		if err := os.MkdirAll(c.spoolDir, 0o700); err != nil {
+			return nil, fmt.Errorf("create spool dir: %w", err)
+		}
+
+		// Replay events left behind by a previous client. Close stops the
+		// replay, leaving unsent events in the spool.
+		ctx, cancel := context.WithCancel(context.Background())
+		c.stopReplay = cancel
+		c.replaying.Add(1)
+		go func() {
+			defer c.replaying.Done()
+			_ = c.replaySpool(ctx)
+		}()
+	}
+
+	// Start sender workers and background flusher
 	if !c.disabled {
+		c.workers.Add(c.numWorkers)
+		for i := 0; i < c.numWorkers; i++ {
+			go c.worker()
+		}
 		go c.backgroundFlusher()
 	}
 
@@ -95,31 +183,151 @@ func NewClient(opts ...Option) (*Client, error) {
 }
 
 // Close flushes pending events and closes the client.
+// Events enqueued after Close are dropped. It waits for in-flight batches
+// and returns a *DeliveryError if any events could not be delivered.
+// A spool replay still in progress is stopped, and the events it has not
+// sent stay in the spool for the next client.
 func (c *Client) Close() error {
 	if c.disabled {
 		return nil
 	}
 
+	c.mu.Lock()
+	c.closed = true
+	c.notFull.Broadcast()
+	c.mu.Unlock()
+
 	close(c.stopCh)
+	if c.stopReplay != nil {
+		c.stopReplay()
+	}
 	<-c.doneCh
+	c.workers.Wait()
+	c.replaying.Wait()
 
 	// Final flush
-	return c.Flush(context.Background())
+	err := c.Flush(context.Background())
+	if c.otlp != nil {
+		err = errors.Join(err, c.otlp.shutdown(context.Background()))
+	}
+	return err
 }
 
-// Flush sends all pending events to Langfuse.
+// Flush sends all queued events to Langfuse and waits for in-flight
+// batches to complete. It returns a *DeliveryError describing the events
+// that could not be delivered, including those from background sends
+// since the last Flush.
 func (c *Client) Flush(ctx context.Context) error {
 	c.mu.Lock()
-	if len(c.batch) == 0 {
-		c.mu.Unlock()
+	c.flushing = true
+	c.mu.Unlock()
+
+	c.drain(ctx)
+	if err := c.waitIdle(ctx); err != nil {
+		return err
+	}
+	if c.otlp != nil {
+		if err := c.otlp.flush(ctx); err != nil {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failed == nil {
 		return nil
 	}
+	err := c.failed
+	c.failed = nil
+	return err
+}
 
-	events := c.batch
-	c.batch = make([]Event, 0, c.batchSize)
-	c.mu.Unlock()
+// Pending returns the number of events that are queued or being sent.
+func (c *Client) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.queue) + c.sending
+}
+
+// deliver splits events into size-limited batches and sends each with
+// retries. Events that still fail are written to the spool directory, if
+// configured, and reported to the event error handler.
+func (c *Client) deliver(ctx context.Context, events []Event) *DeliveryError {
+	var failure *DeliveryError
+	for _, batch := range c.splitBatch(ctx, events) {
+		failed, err := c.sendWithRetry(ctx, batch)
+		if err == nil {
+			continue
+		}
 
-	return c.sendBatch(ctx, events)
+		f := &DeliveryError{Events: len(failed), Err: err}
+		if c.spoolDir != "" && isRetryable(err) {
+			if spoolErr := c.spool(failed); spoolErr != nil {
+				f.Err = errors.Join(err, spoolErr)
+			} else {
+				f.Spooled = len(failed)
+			}
+		}
+		c.reportFailedEvents(failed, err, f.Spooled > 0)
+		failure = mergeDeliveryErrors(failure, f)
+	}
+	return failure
+}
+
+// reportFailedEvents reports events that could not be delivered.
+func (c *Client) reportFailedEvents(events []Event, err error, spooled bool) {
+	if c.onEventError == nil {
+		return
+	}
+
+	status := 0
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		status = apiErr.StatusCode
+	}
+	eventErrs := make(map[string]EventError)
+	var batchErr *BatchError
+	if errors.As(err, &batchErr) {
+		for _, e := range batchErr.Errors {
+			eventErrs[e.EventID] = e
+		}
+	}
+
+	for _, e := range events {
+		eventErr, ok := eventErrs[e.ID]
+		if !ok {
+			eventErr = EventError{EventID: e.ID, EventType: e.Type, Status: status, Message: err.Error()}
+		}
+		eventErr.Spooled = spooled
+		c.onEventError(eventErr)
+	}
+}
+
+// recordFailure adds a delivery failure to be reported by the next Flush.
+func (c *Client) recordFailure(failure *DeliveryError) {
+	if failure == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failed = mergeDeliveryErrors(c.failed, failure)
+}
+
+// mergeDeliveryErrors combines two delivery failures, either of which may
+// be nil, joining their errors.
+func mergeDeliveryErrors(a, b *DeliveryError) *DeliveryError {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	return &DeliveryError{
+		Events:  a.Events + b.Events,
+		Spooled: a.Spooled + b.Spooled,
+		Err:     errors.Join(a.Err, b.Err),
+	}
 }
 
 // backgroundFlusher periodically flushes events.
@@ -132,30 +340,16 @@ func (c *Client) backgroundFlusher() {
 	for {
 		select {
 		case <-ticker.C:
-			_ = c.Flush(context.Background())
+			c.mu.Lock()
+			c.flushing = true
+			c.mu.Unlock()
+			c.wake()
 		case <-c.stopCh:
 			return
 		}
 	}
 }
 
-// enqueue adds an event to the batch.
-func (c *Client) enqueue(event Event) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	c.batch = append(c.batch, event)
-
-	// Flush if batch is full
-	if len(c.batch) >= c.batchSize {
-		events := c.batch
-		c.batch = make([]Event, 0, c.batchSize)
-		go func() {
-			_ = c.sendBatch(context.Background(), events)
-		}()
-	}
-}
-
 // sendBatch sends a batch of events to the ingestion API.
 func (c *Client) sendBatch(ctx context.Context, events []Event) error {
 	if len(events) == 0 {
@@ -191,7 +385,17 @@ func (c *Client) sendBatch(ctx context.Context, events []Event) error {
 		return &APIError{
 			StatusCode: resp.StatusCode,
 			Message:    string(respBody),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+
+	// Langfuse reports per-event errors with 207 Multi-Status
+	if resp.StatusCode == http.StatusMultiStatus {
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("read response: %w", err)
 		}
+		return parseMultiStatus(respBody, events)
 	}
 
 	return nil
@@ -210,7 +414,7 @@ func (c *Client) StartTrace(ctx context.Context, name string, opts ...TraceOptio
 
 	trace := &Trace{
 		client:    c,
-		id:        uuid.New().String(),
+		id:        c.newTraceID(),
 		name:      name,
 		startTime: time.Now(),
 		metadata:  cfg.metadata,
@@ -278,6 +482,16 @@ func (c *Client) doGet(ctx context.Context, path string, result any) error {
 
 // doPost performs a POST request.
 func (c *Client) doPost(ctx context.Context, path string, body, result any) error {
+	return c.doJSON(ctx, "POST", path, body, result)
+}
+
+// doPatch performs a PATCH request.
+func (c *Client) doPatch(ctx context.Context, path string, body, result any) error {
+	return c.doJSON(ctx, "PATCH", path, body, result)
+}
+
+// doJSON performs a request with a JSON body.
+func (c *Client) doJSON(ctx context.Context, method, path string, body, result any) error {
 	var bodyReader io.Reader
 	if body != nil {
 		data, err := json.Marshal(body)
@@ -287,7 +501,7 @@ func (c *Client) doPost(ctx context.Context, path string, body, result any) erro
 		bodyReader = bytes.NewReader(data)
 	}
 
-	resp, err := c.doRequest(ctx, "POST", path, bodyReader)
+	resp, err := c.doRequest(ctx, method, path, bodyReader)
 	if err != nil {
 		return err
 	}
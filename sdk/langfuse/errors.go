@@ -3,6 +3,7 @@ package langfuse
 import (
 	"errors"
 	"fmt"
+	"time"
 )
 
 // Sentinel errors.
@@ -22,12 +23,30 @@ type APIError struct {
 	StatusCode int
 	Message    string
 	Details    map[string]any
+	RetryAfter time.Duration // From the Retry-After header, if any
 }
 
 func (e *APIError) Error() string {
 	return fmt.Sprintf("langfuse API error (status %d): %s", e.StatusCode, e.Message)
 }
 
+// DeliveryError reports events that could not be delivered to Langfuse.
+// Spooled events were written to the spool directory and will be replayed
+// by the next client; the rest were dropped.
+type DeliveryError struct {
+	Events  int   // Number of undelivered events
+	Spooled int   // Number of those events saved to the spool directory
+	Err     error // Delivery errors, joined when several batches failed
+}
+
+func (e *DeliveryError) Error() string {
+	return fmt.Sprintf("langfuse: %d events not delivered (%d spooled): %v", e.Events, e.Spooled, e.Err)
+}
+
+func (e *DeliveryError) Unwrap() error {
+	return e.Err
+}
+
 // IsNotFound returns true if the error is a not found error.
 func IsNotFound(err error) bool {
 	if err == nil {
@@ -125,11 +125,15 @@ func (g *Generation) End(ctx context.Context, opts ...GenerationOption) error {
 	return nil
 }
 
-// Update updates the generation.
+// Update updates the generation. In OTLP mode, updates after End are dropped,
+// since the generation has already been exported.
 func (g *Generation) Update(ctx context.Context, opts ...GenerationOption) error {
 	if g.disabled {
 		return nil
 	}
+	if g.ended && g.client.otlp != nil {
+		return nil
+	}
 
 	cfg := &generationConfig{}
 	for _, opt := range opts {
@@ -225,7 +229,9 @@ func (g *Generation) Score(ctx context.Context, name string, value float64, opts
 			Value:         value,
 			Comment:       cfg.comment,
 			Source:        cfg.source,
-			DataType:      "NUMERIC",
+			StringValue:   cfg.stringValue,
+			DataType:      cfg.resolvedDataType(),
+			ConfigID:      cfg.configID,
 		},
 	})
 
@@ -0,0 +1,194 @@
+package langfuse
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Media fields, identifying which part of a trace or observation the media
+// belongs to.
+const (
+	MediaFieldInput    = "input"
+	MediaFieldOutput   = "output"
+	MediaFieldMetadata = "metadata"
+)
+
+// MediaUpload describes media to upload to Langfuse.
+type MediaUpload struct {
+	TraceID       string
+	ObservationID string // Optional; empty for trace-level media
+	Field         string // input, output, or metadata
+	ContentType   string // MIME type, e.g. image/png
+	Data          []byte
+}
+
+// MediaReference identifies uploaded media.
+type MediaReference struct {
+	ID          string
+	ContentType string
+	Size        int
+	Token       string // Reference token to embed in input, output, or metadata
+}
+
+// Media describes media stored in Langfuse.
+type Media struct {
+	ID            string    `json:"mediaId"`
+	ContentType   string    `json:"contentType"`
+	ContentLength int       `json:"contentLength"`
+	UploadedAt    time.Time `json:"uploadedAt"`
+	URL           string    `json:"url"`
+	URLExpiry     string    `json:"urlExpiry"`
+}
+
+// MediaToken returns the Langfuse reference token for media. Langfuse
+// replaces tokens found in inputs, outputs, and metadata with the media
+// when rendering a trace.
+func MediaToken(contentType, mediaID string) string {
+	return fmt.Sprintf("@@@langfuseMedia:type=%s|id=%s|source=bytes@@@", contentType, mediaID)
+}
+
+// UploadMedia uploads media and returns a reference to it. Langfuse
+// deduplicates media by content hash, so the data is only transferred if
+// it has not been uploaded before.
+func (c *Client) UploadMedia(ctx context.Context, media MediaUpload) (*MediaReference, error) {
+	if media.TraceID == "" {
+		return nil, fmt.Errorf("langfuse: media upload requires a trace ID")
+	}
+	if media.ContentType == "" {
+		return nil, fmt.Errorf("langfuse: media upload requires a content type")
+	}
+	field := media.Field
+	if field == "" {
+		field = MediaFieldInput
+	}
+
+	sum := sha256.Sum256(media.Data)
+	hash := base64.StdEncoding.EncodeToString(sum[:])
+
+	req := map[string]any{
+		"traceId":       media.TraceID,
+		"contentType":   media.ContentType,
+		"contentLength": len(media.Data),
+		"sha256Hash":    hash,
+		"field":         field,
+	}
+	if media.ObservationID != "" {
+		req["observationId"] = media.ObservationID
+	}
+
+	var upload struct {
+		UploadURL *string `json:"uploadUrl"`
+		MediaID   string  `json:"mediaId"`
+	}
+	if err := c.doPost(ctx, "/api/public/media", req, &upload); err != nil {
+		return nil, err
+	}
+
+	// A nil upload URL means the media was already uploaded
+	if upload.UploadURL != nil {
+		if err := c.putMedia(ctx, upload.MediaID, *upload.UploadURL, media, hash); err != nil {
+			return nil, err
+		}
+	}
+
+	return &MediaReference{
+		ID:          upload.MediaID,
+		ContentType: media.ContentType,
+		Size:        len(media.Data),
+		Token:       MediaToken(media.ContentType, upload.MediaID),
+	}, nil
+}
+
+// putMedia uploads media to a presigned URL and reports the result to Langfuse.
+func (c *Client) putMedia(ctx context.Context, mediaID, uploadURL string, media MediaUpload, hash string) error {
+	start := time.Now()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, uploadURL, bytes.NewReader(media.Data))
+	if err != nil {
+		return fmt.Errorf("create upload request: %w", err)
+	}
+	req.Header.Set("Content-Type", media.ContentType)
+	req.Header.Set("x-amz-checksum-sha256", hash)
+
+	status := map[string]any{}
+	resp, uploadErr := c.httpClient.Do(req)
+	if uploadErr == nil {
+		defer resp.Body.Close()
+		status["uploadHttpStatus"] = resp.StatusCode
+		if resp.StatusCode >= 400 {
+			body, _ := io.ReadAll(resp.Body)
+			uploadErr = &APIError{StatusCode: resp.StatusCode, Message: string(body)}
+			status["uploadHttpError"] = string(body)
+		}
+	} else {
+		status["uploadHttpError"] = uploadErr.Error()
+	}
+	status["uploadedAt"] = time.Now().UTC().Format(time.RFC3339Nano)
+	status["uploadTimeMs"] = time.Since(start).Milliseconds()
+
+	if err := c.doPatch(ctx, "/api/public/media/"+url.PathEscape(mediaID), status, nil); err != nil && uploadErr == nil {
+		return err
+	}
+	if uploadErr != nil {
+		return fmt.Errorf("upload media: %w", uploadErr)
+	}
+	return nil
+}
+
+// GetMedia retrieves media metadata and a temporary download URL.
+func (c *Client) GetMedia(ctx context.Context, mediaID string) (*Media, error) {
+	var result Media
+	err := c.doGet(ctx, "/api/public/media/"+url.PathEscape(mediaID), &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// UploadMedia uploads media belonging to the trace.
+func (t *Trace) UploadMedia(ctx context.Context, field, contentType string, data []byte) (*MediaReference, error) {
+	if t.disabled {
+		return &MediaReference{ContentType: contentType, Size: len(data)}, nil
+	}
+	return t.client.UploadMedia(ctx, MediaUpload{
+		TraceID:     t.id,
+		Field:       field,
+		ContentType: contentType,
+		Data:        data,
+	})
+}
+
+// UploadMedia uploads media belonging to the span.
+func (s *Span) UploadMedia(ctx context.Context, field, contentType string, data []byte) (*MediaReference, error) {
+	if s.disabled {
+		return &MediaReference{ContentType: contentType, Size: len(data)}, nil
+	}
+	return s.client.UploadMedia(ctx, MediaUpload{
+		TraceID:       s.traceID,
+		ObservationID: s.id,
+		Field:         field,
+		ContentType:   contentType,
+		Data:          data,
+	})
+}
+
+// UploadMedia uploads media belonging to the generation.
+func (g *Generation) UploadMedia(ctx context.Context, field, contentType string, data []byte) (*MediaReference, error) {
+	if g.disabled {
+		return &MediaReference{ContentType: contentType, Size: len(data)}, nil
+	}
+	return g.client.UploadMedia(ctx, MediaUpload{
+		TraceID:       g.traceID,
+		ObservationID: g.id,
+		Field:         field,
+		ContentType:   contentType,
+		Data:          data,
+	})
+}
@@ -3,6 +3,10 @@ package langfuse
 import (
 	"net/http"
 	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/agentplexus/omniobserve/observops"
 )
 
 // Option configures the client.
@@ -58,6 +62,113 @@ func WithFlushPeriod(period time.Duration) Option {
 	}
 }
 
+// WithQueueSize sets the maximum number of events waiting to be sent.
+func WithQueueSize(size int) Option {
+	return func(c *Client) {
+		c.queueSize = size
+	}
+}
+
+// WithOverflowPolicy sets what happens when the queue is full.
+// The default is OverflowDropNewest.
+func WithOverflowPolicy(policy OverflowPolicy) Option {
+	return func(c *Client) {
+		c.overflow = policy
+	}
+}
+
+// WithWorkers sets the number of concurrent sender workers.
+func WithWorkers(n int) Option {
+	return func(c *Client) {
+		c.numWorkers = n
+	}
+}
+
+// WithMeter reports ingestion counters to an observops meter.
+func WithMeter(meter observops.Meter) Option {
+	return func(c *Client) {
+		c.meter = meter
+	}
+}
+
+// WithMaxEventBytes sets the maximum serialized size of a single event.
+// Larger events have their input, output, and metadata offloaded or
+// truncated with TruncatedMarker.
+func WithMaxEventBytes(n int) Option {
+	return func(c *Client) {
+		c.maxEventBytes = n
+	}
+}
+
+// WithMaxBatchBytes sets the maximum serialized size of an ingestion request.
+// Larger batches are split into several requests.
+func WithMaxBatchBytes(n int) Option {
+	return func(c *Client) {
+		c.maxBatchBytes = n
+	}
+}
+
+// WithPayloadOffloader sets a function that stores oversized payloads
+// elsewhere. The payload is replaced with a reference to the stored copy.
+// If the offloader fails, the payload is truncated instead.
+func WithPayloadOffloader(offloader PayloadOffloader) Option {
+	return func(c *Client) {
+		c.offloader = offloader
+	}
+}
+
+// WithEventErrorHandler sets a function called for each event that could
+// not be delivered, either because Langfuse rejected it or because retries
+// were exhausted. It is called from sender goroutines and must be safe for
+// concurrent use.
+func WithEventErrorHandler(handler func(EventError)) Option {
+	return func(c *Client) {
+		c.onEventError = handler
+	}
+}
+
+// WithMaxRetries sets how many times a failed batch is retried.
+// Zero disables retries.
+func WithMaxRetries(n int) Option {
+	return func(c *Client) {
+		c.maxRetries = n
+	}
+}
+
+// WithRetryBackoff sets the initial and maximum wait between retries.
+func WithRetryBackoff(wait, maxWait time.Duration) Option {
+	return func(c *Client) {
+		c.retryWait = wait
+		c.retryMaxWait = maxWait
+	}
+}
+
+// WithSpoolDir enables on-disk spooling. Batches that cannot be delivered
+// are written to dir and replayed when the next client is created.
+func WithSpoolDir(dir string) Option {
+	return func(c *Client) {
+		c.spoolDir = dir
+	}
+}
+
+// WithOTLP exports traces, spans, and generations as OpenTelemetry spans
+// to the Langfuse OTLP endpoint instead of the batch ingestion API.
+// Scores are still sent through the ingestion API.
+func WithOTLP() Option {
+	return func(c *Client) {
+		c.otlpMode = true
+	}
+}
+
+// WithSpanExporter exports traces, spans, and generations as OpenTelemetry
+// spans through the given exporter, such as an OTLP exporter pointed at a
+// collector. It implies WithOTLP.
+func WithSpanExporter(exporter sdktrace.SpanExporter) Option {
+	return func(c *Client) {
+		c.spanExporter = exporter
+	}
+}
+
 // WithDisabled disables tracing.
 func WithDisabled(disabled bool) Option {
 	return func(c *Client) {
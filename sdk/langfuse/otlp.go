@@ -0,0 +1,563 @@
+package langfuse
+
+import (
+	"container/list"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// OTLPTracesPath is the path of the Langfuse OTLP/HTTP traces endpoint.
+const OTLPTracesPath = "/api/public/otel/v1/traces"
+
+// Langfuse OpenTelemetry span attributes.
+const (
+	AttrTraceName     = "langfuse.trace.name"
+	AttrTraceInput    = "langfuse.trace.input"
+	AttrTraceOutput   = "langfuse.trace.output"
+	AttrTraceTags     = "langfuse.trace.tags"
+	AttrTracePublic   = "langfuse.trace.public"
+	AttrTraceMetadata = "langfuse.trace.metadata" // Prefix for per-key metadata
+	AttrUserID        = "user.id"
+	AttrSessionID     = "session.id"
+	AttrVersion       = "langfuse.version"
+
+	AttrObservationType                = "langfuse.observation.type"
+	AttrObservationInput               = "langfuse.observation.input"
+	AttrObservationOutput              = "langfuse.observation.output"
+	AttrObservationMetadata            = "langfuse.observation.metadata" // Prefix for per-key metadata
+	AttrObservationLevel               = "langfuse.observation.level"
+	AttrObservationStatusMessage       = "langfuse.observation.status_message"
+	AttrObservationModel               = "langfuse.observation.model.name"
+	AttrObservationModelParameters     = "langfuse.observation.model.parameters"
+	AttrObservationUsageDetails        = "langfuse.observation.usage_details"
+	AttrObservationCompletionStartTime = "langfuse.observation.completion_start_time"
+	AttrObservationPromptName          = "langfuse.observation.prompt.name"
+	AttrObservationPromptVersion       = "langfuse.observation.prompt.version"
+)
+
+// Observation types reported in AttrObservationType.
+const (
+	ObservationTypeSpan       = "span"
+	ObservationTypeGeneration = "generation"
+)
+
+// otlpExporter converts trace, span and generation events into OTel spans.
+// Observations are buffered until they end, then recorded through a tracer
+// provider with a batch span processor. Traces are exported as a root span
+// when they end. At most maxPending traces and observations are buffered;
+// beyond that, the oldest is exported as ending now, as on Close.
+type otlpExporter struct {
+	provider   *sdktrace.TracerProvider
+	tracer     trace.Tracer
+	maxPending int
+
+	mu           sync.Mutex
+	traces       map[string]*TraceBody
+	observations map[string]*otlpObservation
+	pending      *list.List // pendingKeys, oldest first
+	elements     map[pendingKey]*list.Element
+}
+
+// pendingKey identifies a buffered trace or observation.
+type pendingKey struct {
+	trace bool
+	id    string
+}
+
+// otlpObservation holds the merged state of a span or generation.
+type otlpObservation struct {
+	obsType         string
+	traceID         string
+	parentID        string
+	name            string
+	startTime       time.Time
+	endTime         *time.Time
+	completionStart *time.Time
+	input           any
+	output          any
+	metadata        map[string]any
+	level           string
+	statusMessage   string
+	version         string
+	model           string
+	modelParameters map[string]any
+	usage           *Usage
+	promptName      string
+	promptVersion   int
+}
+
+// newOTLPExporter creates the OTLP exporter for the client, using the
+// configured span exporter or OTLP/HTTP to the Langfuse endpoint.
+func newOTLPExporter(c *Client) (*otlpExporter, error) {
+	exporter := c.spanExporter
+	if exporter == nil {
+		auth := base64.StdEncoding.EncodeToString([]byte(c.publicKey + ":" + c.secretKey))
+		var err error
+		exporter, err = otlptracehttp.New(context.Background(),
+			otlptracehttp.WithEndpointURL(strings.TrimRight(c.endpoint, "/")+OTLPTracesPath),
+			otlptracehttp.WithHeaders(map[string]string{"Authorization": "Basic " + auth}),
+			otlptracehttp.WithHTTPClient(c.httpClient),
+			otlptracehttp.WithTimeout(c.timeout),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("create OTLP exporter: %w", err)
+		}
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter,
+			sdktrace.WithBatchTimeout(c.flushPeriod),
+			sdktrace.WithMaxExportBatchSize(c.batchSize),
+			sdktrace.WithMaxQueueSize(c.queueSize),
+		),
+		sdktrace.WithResource(resource.Default()),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+		sdktrace.WithIDGenerator(otlpIDGenerator{}),
+	)
+
+	return &otlpExporter{
+		provider:     provider,
+		tracer:       provider.Tracer("langfuse-go-sdk", trace.WithInstrumentationVersion(Version)),
+		maxPending:   c.queueSize,
+		traces:       make(map[string]*TraceBody),
+		observations: make(map[string]*otlpObservation),
+		pending:      list.New(),
+		elements:     make(map[pendingKey]*list.Element),
+	}, nil
+}
+
+// handle records an event. It returns false for events that are not
+// exported as spans, such as scores, which must use the ingestion API.
+func (x *otlpExporter) handle(event Event) bool {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	switch body := event.Body.(type) {
+	case TraceBody:
+		// Trace updates carry the full trace state, except Public
+		prev, ok := x.traces[body.ID]
+		if ok && prev.Public {
+			body.Public = true
+		}
+		x.traces[body.ID] = &body
+		if !ok {
+			x.track(pendingKey{trace: true, id: body.ID})
+		}
+	case SpanBody:
+		obs := x.observation(body.ID, ObservationTypeSpan)
+		obs.mergeSpan(body)
+		x.endObservation(body.ID, obs)
+	case GenerationBody:
+		obs := x.observation(body.ID, ObservationTypeGeneration)
+		obs.mergeGeneration(body)
+		x.endObservation(body.ID, obs)
+	default:
+		return false
+	}
+	return true
+}
+
+// endTrace exports the root span of a trace.
+func (x *otlpExporter) endTrace(traceID string, endTime time.Time) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if body, ok := x.traces[traceID]; ok {
+		delete(x.traces, traceID)
+		x.untrack(pendingKey{trace: true, id: traceID})
+		x.exportTrace(body, endTime)
+	}
+}
+
+// flush exports buffered spans.
+func (x *otlpExporter) flush(ctx context.Context) error {
+	return x.provider.ForceFlush(ctx)
+}
+
+// shutdown exports unfinished traces and observations as ending now, then
+// shuts down the tracer provider.
+func (x *otlpExporter) shutdown(ctx context.Context) error {
+	now := time.Now()
+
+	x.mu.Lock()
+	for id, obs := range x.observations {
+		obs.endTime = &now
+		x.exportObservation(id, obs)
+	}
+	for _, body := range x.traces {
+		x.exportTrace(body, now)
+	}
+	x.observations = make(map[string]*otlpObservation)
+	x.traces = make(map[string]*TraceBody)
+	x.pending.Init()
+	clear(x.elements)
+	x.mu.Unlock()
+
+	return x.provider.Shutdown(ctx)
+}
+
+func (x *otlpExporter) observation(id, obsType string) *otlpObservation {
+	obs, ok := x.observations[id]
+	if !ok {
+		obs = &otlpObservation{obsType: obsType}
+		x.observations[id] = obs
+		x.track(pendingKey{id: id})
+	}
+	return obs
+}
+
+// endObservation exports the observation if it has ended.
+func (x *otlpExporter) endObservation(id string, obs *otlpObservation) {
+	if obs.endTime == nil {
+		return
+	}
+	delete(x.observations, id)
+	x.untrack(pendingKey{id: id})
+	x.exportObservation(id, obs)
+}
+
+// track records a newly buffered trace or observation, exporting the
+// oldest ones as ending now if more than maxPending are buffered.
+func (x *otlpExporter) track(key pendingKey) {
+	x.elements[key] = x.pending.PushBack(key)
+
+	for x.maxPending > 0 && x.pending.Len() > x.maxPending {
+		oldest := x.pending.Remove(x.pending.Front()).(pendingKey)
+		delete(x.elements, oldest)
+
+		now := time.Now()
+		if oldest.trace {
+			x.exportTrace(x.traces[oldest.id], now)
+			delete(x.traces, oldest.id)
+		} else {
+			obs := x.observations[oldest.id]
+			obs.endTime = &now
+			x.exportObservation(oldest.id, obs)
+			delete(x.observations, oldest.id)
+		}
+	}
+}
+
+// untrack removes a trace or observation that is no longer buffered.
+func (x *otlpExporter) untrack(key pendingKey) {
+	if e, ok := x.elements[key]; ok {
+		x.pending.Remove(e)
+		delete(x.elements, key)
+	}
+}
+
+func (x *otlpExporter) exportTrace(body *TraceBody, endTime time.Time) {
+	attrs := []attribute.KeyValue{
+		attribute.String(AttrTraceName, body.Name),
+	}
+	if body.UserID != "" {
+		attrs = append(attrs, attribute.String(AttrUserID, body.UserID))
+	}
+	if body.SessionID != "" {
+		attrs = append(attrs, attribute.String(AttrSessionID, body.SessionID))
+	}
+	if len(body.Tags) > 0 {
+		attrs = append(attrs, attribute.StringSlice(AttrTraceTags, body.Tags))
+	}
+	if body.Public {
+		attrs = append(attrs, attribute.Bool(AttrTracePublic, true))
+	}
+	attrs = appendJSON(attrs, AttrTraceInput, body.Input)
+	attrs = appendJSON(attrs, AttrTraceOutput, body.Output)
+	attrs = appendMetadata(attrs, AttrTraceMetadata, body.Metadata)
+
+	x.export(otlpSpan{
+		name:      body.Name,
+		traceID:   traceIDFromString(body.ID),
+		spanID:    rootSpanID(body.ID),
+		startTime: body.Timestamp,
+		endTime:   endTime,
+		attrs:     attrs,
+	})
+}
+
+func (x *otlpExporter) exportObservation(id string, obs *otlpObservation) {
+	attrs := []attribute.KeyValue{
+		attribute.String(AttrObservationType, obs.obsType),
+	}
+	if obs.level != "" {
+		attrs = append(attrs, attribute.String(AttrObservationLevel, obs.level))
+	}
+	if obs.statusMessage != "" {
+		attrs = append(attrs, attribute.String(AttrObservationStatusMessage, obs.statusMessage))
+	}
+	if obs.version != "" {
+		attrs = append(attrs, attribute.String(AttrVersion, obs.version))
+	}
+	attrs = appendJSON(attrs, AttrObservationInput, obs.input)
+	attrs = appendJSON(attrs, AttrObservationOutput, obs.output)
+	attrs = appendMetadata(attrs, AttrObservationMetadata, obs.metadata)
+
+	if obs.model != "" {
+		attrs = append(attrs,
+			attribute.String(AttrObservationModel, obs.model),
+			attribute.String("gen_ai.request.model", obs.model),
+		)
+	}
+	if len(obs.modelParameters) > 0 {
+		attrs = appendJSON(attrs, AttrObservationModelParameters, obs.modelParameters)
+	}
+	if obs.completionStart != nil {
+		attrs = append(attrs, attribute.String(AttrObservationCompletionStartTime, obs.completionStart.Format(time.RFC3339Nano)))
+	}
+	if obs.promptName != "" {
+		attrs = append(attrs, attribute.String(AttrObservationPromptName, obs.promptName))
+		if obs.promptVersion > 0 {
+			attrs = append(attrs, attribute.Int(AttrObservationPromptVersion, obs.promptVersion))
+		}
+	}
+	if obs.usage != nil {
+		input := max(obs.usage.PromptTokens, obs.usage.Input)
+		output := max(obs.usage.CompletionTokens, obs.usage.Output)
+		total := max(obs.usage.TotalTokens, obs.usage.Total, input+output)
+		attrs = appendJSON(attrs, AttrObservationUsageDetails, map[string]int{
+			"input":  input,
+			"output": output,
+			"total":  total,
+		})
+		attrs = append(attrs,
+			attribute.Int("gen_ai.usage.input_tokens", input),
+			attribute.Int("gen_ai.usage.output_tokens", output),
+		)
+	}
+
+	parentID := rootSpanID(obs.traceID)
+	if obs.parentID != "" {
+		parentID = spanIDFromString(obs.parentID)
+	}
+
+	x.export(otlpSpan{
+		name:          obs.name,
+		traceID:       traceIDFromString(obs.traceID),
+		spanID:        spanIDFromString(id),
+		parentID:      parentID,
+		startTime:     obs.startTime,
+		endTime:       *obs.endTime,
+		attrs:         attrs,
+		failed:        obs.level == "ERROR",
+		statusMessage: obs.statusMessage,
+	})
+}
+
+// otlpSpan is a finished span to record.
+type otlpSpan struct {
+	name               string
+	traceID            trace.TraceID
+	spanID, parentID   trace.SpanID // parentID is zero for root spans
+	startTime, endTime time.Time
+	attrs              []attribute.KeyValue
+	failed             bool
+	statusMessage      string
+}
+
+// export records a finished span with the given IDs and timestamps.
+func (x *otlpExporter) export(s otlpSpan) {
+	ctx := context.WithValue(context.Background(), spanIDsKey{}, spanIDs{traceID: s.traceID, spanID: s.spanID})
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithTimestamp(s.startTime),
+		trace.WithAttributes(s.attrs...),
+	}
+	if s.parentID.IsValid() {
+		ctx = trace.ContextWithRemoteSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    s.traceID,
+			SpanID:     s.parentID,
+			TraceFlags: trace.FlagsSampled,
+			Remote:     true,
+		}))
+	} else {
+		opts = append(opts, trace.WithNewRoot())
+	}
+
+	_, span := x.tracer.Start(ctx, s.name, opts...)
+	if s.failed {
+		span.SetStatus(codes.Error, s.statusMessage)
+	}
+	span.End(trace.WithTimestamp(s.endTime))
+}
+
+// spanIDs carries the IDs of the span being recorded to otlpIDGenerator.
+type spanIDs struct {
+	traceID trace.TraceID
+	spanID  trace.SpanID
+}
+
+type spanIDsKey struct{}
+
+// otlpIDGenerator gives spans the IDs derived from their Langfuse trace
+// and observation IDs, which export passes in the context.
+type otlpIDGenerator struct{}
+
+func (otlpIDGenerator) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	ids, _ := ctx.Value(spanIDsKey{}).(spanIDs)
+	if !ids.traceID.IsValid() {
+		_, _ = rand.Read(ids.traceID[:])
+	}
+	if !ids.spanID.IsValid() {
+		_, _ = rand.Read(ids.spanID[:])
+	}
+	return ids.traceID, ids.spanID
+}
+
+func (g otlpIDGenerator) NewSpanID(ctx context.Context, _ trace.TraceID) trace.SpanID {
+	_, spanID := g.NewIDs(ctx)
+	return spanID
+}
+
+func (o *otlpObservation) mergeSpan(b SpanBody) {
+	o.merge(b.TraceID, b.ParentObservationID, b.Name, b.StartTime, b.EndTime, b.Input, b.Output, b.Metadata, b.Level, b.StatusMessage, b.Version)
+}
+
+func (o *otlpObservation) mergeGeneration(b GenerationBody) {
+	o.merge(b.TraceID, b.ParentObservationID, b.Name, b.StartTime, b.EndTime, b.Input, b.Output, b.Metadata, b.Level, b.StatusMessage, "")
+	if b.CompletionStartTime != nil {
+		o.completionStart = b.CompletionStartTime
+	}
+	if b.Model != "" {
+		o.model = b.Model
+	}
+	if b.ModelParameters != nil {
+		o.modelParameters = b.ModelParameters
+	}
+	if b.Usage != nil {
+		o.usage = b.Usage
+	}
+	if b.PromptName != "" {
+		o.promptName = b.PromptName
+		o.promptVersion = b.PromptVersion
+	}
+}
+
+// merge overwrites fields with the non-zero values of an update.
+func (o *otlpObservation) merge(traceID, parentID, name string, start time.Time, end *time.Time, input, output any, metadata map[string]any, level, statusMessage, version string) {
+	if traceID != "" {
+		o.traceID = traceID
+	}
+	if parentID != "" {
+		o.parentID = parentID
+	}
+	if name != "" {
+		o.name = name
+	}
+	if !start.IsZero() {
+		o.startTime = start
+	}
+	if end != nil {
+		o.endTime = end
+	}
+	if input != nil {
+		o.input = input
+	}
+	if output != nil {
+		o.output = output
+	}
+	if metadata != nil {
+		o.metadata = metadata
+	}
+	if level != "" {
+		o.level = level
+	}
+	if statusMessage != "" {
+		o.statusMessage = statusMessage
+	}
+	if version != "" {
+		o.version = version
+	}
+}
+
+// appendJSON appends a value as a string attribute, JSON-encoding non-strings.
+func appendJSON(attrs []attribute.KeyValue, key string, value any) []attribute.KeyValue {
+	if value == nil {
+		return attrs
+	}
+	if s, ok := value.(string); ok {
+		return append(attrs, attribute.String(key, s))
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return attrs
+	}
+	return append(attrs, attribute.String(key, string(data)))
+}
+
+// appendMetadata appends each metadata entry as prefix.key.
+func appendMetadata(attrs []attribute.KeyValue, prefix string, metadata map[string]any) []attribute.KeyValue {
+	for k, v := range metadata {
+		attrs = appendJSON(attrs, prefix+"."+k, v)
+	}
+	return attrs
+}
+
+// newTraceID returns a new trace ID. In OTLP mode, IDs are hex-encoded
+// OTel trace IDs so that they match the IDs Langfuse assigns to the spans.
+func (c *Client) newTraceID() string {
+	if c.otlp == nil {
+		return uuid.New().String()
+	}
+	var id trace.TraceID
+	_, _ = rand.Read(id[:])
+	return id.String()
+}
+
+// newObservationID returns a new span or generation ID. In OTLP mode, IDs
+// are hex-encoded OTel span IDs.
+func (c *Client) newObservationID() string {
+	if c.otlp == nil {
+		return uuid.New().String()
+	}
+	var id trace.SpanID
+	_, _ = rand.Read(id[:])
+	return id.String()
+}
+
+// traceIDFromString converts a trace ID into an OTel trace ID. Hex IDs and
+// UUIDs are used as is; other IDs are hashed.
+func traceIDFromString(s string) trace.TraceID {
+	var id trace.TraceID
+	if b, err := hex.DecodeString(strings.ReplaceAll(s, "-", "")); err == nil && len(b) == len(id) {
+		copy(id[:], b)
+		return id
+	}
+	sum := sha256.Sum256([]byte(s))
+	copy(id[:], sum[:])
+	return id
+}
+
+// spanIDFromString converts an observation ID into an OTel span ID. Hex IDs
+// are used as is; other IDs are hashed.
+func spanIDFromString(s string) trace.SpanID {
+	var id trace.SpanID
+	if b, err := hex.DecodeString(s); err == nil && len(b) == len(id) {
+		copy(id[:], b)
+		return id
+	}
+	sum := sha256.Sum256([]byte(s))
+	copy(id[:], sum[:])
+	return id
+}
+
+// rootSpanID returns the span ID of the root span representing a trace.
+func rootSpanID(traceID string) trace.SpanID {
+	return spanIDFromString("trace:" + traceID)
+}
@@ -0,0 +1,229 @@
+package langfuse
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	collectorpb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// otlpServer is a fake Langfuse OTLP traces endpoint.
+type otlpServer struct {
+	*httptest.Server
+
+	mu    sync.Mutex
+	auth  []string
+	spans []*tracepb.Span
+}
+
+func newOTLPServer(t *testing.T) *otlpServer {
+	t.Helper()
+	s := &otlpServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != OTLPTracesPath {
+			http.NotFound(w, r)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var req collectorpb.ExportTraceServiceRequest
+		if err := proto.Unmarshal(body, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		s.mu.Lock()
+		s.auth = append(s.auth, r.Header.Get("Authorization"))
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				s.spans = append(s.spans, ss.Spans...)
+			}
+		}
+		s.mu.Unlock()
+
+		resp, _ := proto.Marshal(&collectorpb.ExportTraceServiceResponse{})
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		_, _ = w.Write(resp)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// spansByName returns the received spans by name.
+func (s *otlpServer) spansByName() map[string][]*tracepb.Span {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	byName := make(map[string][]*tracepb.Span)
+	for _, span := range s.spans {
+		byName[span.Name] = append(byName[span.Name], span)
+	}
+	return byName
+}
+
+func (s *otlpServer) spanCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.spans)
+}
+
+func newOTLPClient(t *testing.T, server *otlpServer, opts ...Option) *Client {
+	t.Helper()
+	opts = append([]Option{
+		WithPublicKey("pk-test"),
+		WithSecretKey("sk-test"),
+		WithEndpoint(server.URL),
+		WithFlushPeriod(time.Hour),
+		WithOTLP(),
+	}, opts...)
+	c, err := NewClient(opts...)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return c
+}
+
+func spanAttr(span *tracepb.Span, key string) string {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value.GetStringValue()
+		}
+	}
+	return ""
+}
+
+// buffered returns the number of traces and observations waiting to end.
+func (x *otlpExporter) buffered() int {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if len(x.traces)+len(x.observations) != x.pending.Len() {
+		panic("otlpExporter: pending list out of sync")
+	}
+	return x.pending.Len()
+}
+
+func TestOTLP_ExportsTraceAndObservations(t *testing.T) {
+	server := newOTLPServer(t)
+	c := newOTLPClient(t, server)
+	ctx := context.Background()
+
+	ctx, trace, _ := c.StartTrace(ctx, "chat", WithUserID("user-1"))
+	_, span, _ := trace.Span(ctx, "retrieve", WithLevel("ERROR"))
+	_ = span.End(ctx)
+	_, gen, _ := trace.Generation(ctx, "llm", WithModel("gpt-4o"))
+	_ = gen.End(ctx)
+	_ = trace.End(ctx)
+
+	if n := c.otlp.buffered(); n != 0 {
+		t.Errorf("expected nothing buffered after End, got %d", n)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	byName := server.spansByName()
+	if len(byName["chat"]) != 1 || len(byName["retrieve"]) != 1 || len(byName["llm"]) != 1 {
+		t.Fatalf("expected one span each for chat, retrieve and llm, got %v", byName)
+	}
+	root, retrieve, llm := byName["chat"][0], byName["retrieve"][0], byName["llm"][0]
+
+	if got := hex.EncodeToString(root.TraceId); got != trace.ID() {
+		t.Errorf("expected trace ID %s, got %s", trace.ID(), got)
+	}
+	if len(root.ParentSpanId) != 0 {
+		t.Error("expected the trace span to be a root span")
+	}
+	if root.StartTimeUnixNano != uint64(trace.StartTime().UnixNano()) || root.EndTimeUnixNano != uint64(trace.EndTime().UnixNano()) {
+		t.Error("expected the trace span to keep the trace's timestamps")
+	}
+	if got := spanAttr(root, AttrUserID); got != "user-1" {
+		t.Errorf("expected user ID attribute, got %q", got)
+	}
+
+	if got := hex.EncodeToString(retrieve.SpanId); got != span.ID() {
+		t.Errorf("expected span ID %s, got %s", span.ID(), got)
+	}
+	if string(retrieve.ParentSpanId) != string(root.SpanId) {
+		t.Error("expected the span's parent to be the trace span")
+	}
+	if retrieve.Status.GetCode() != tracepb.Status_STATUS_CODE_ERROR {
+		t.Errorf("expected error status, got %v", retrieve.Status)
+	}
+	if retrieve.StartTimeUnixNano != uint64(span.StartTime().UnixNano()) {
+		t.Error("expected the span to keep its start time")
+	}
+
+	if got := spanAttr(llm, AttrObservationType); got != ObservationTypeGeneration {
+		t.Errorf("expected generation type, got %q", got)
+	}
+	if got := spanAttr(llm, AttrObservationModel); got != "gpt-4o" {
+		t.Errorf("expected model attribute, got %q", got)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if len(server.auth) == 0 || server.auth[0] != "Basic cGstdGVzdDpzay10ZXN0" {
+		t.Errorf("expected basic auth with the API keys, got %v", server.auth)
+	}
+}
+
+func TestOTLP_UpdateAfterEndDropped(t *testing.T) {
+	server := newOTLPServer(t)
+	c := newOTLPClient(t, server)
+	ctx := context.Background()
+
+	ctx, trace, _ := c.StartTrace(ctx, "chat")
+	_, span, _ := trace.Span(ctx, "step")
+	_ = span.End(ctx)
+	_ = span.Update(ctx, WithSpanOutput("late"))
+	_ = trace.End(ctx)
+	_ = trace.Update(ctx, WithOutput("late"))
+
+	if n := c.otlp.buffered(); n != 0 {
+		t.Errorf("expected updates after End not to be buffered, got %d", n)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	byName := server.spansByName()
+	if len(byName["chat"]) != 1 || len(byName["step"]) != 1 {
+		t.Errorf("expected no duplicate spans, got %d chat and %d step", len(byName["chat"]), len(byName["step"]))
+	}
+}
+
+func TestOTLP_EvictsOldestUnendedEntries(t *testing.T) {
+	server := newOTLPServer(t)
+	c := newOTLPClient(t, server, WithBatchSize(2), WithQueueSize(2))
+	ctx := context.Background()
+
+	ctx, trace, _ := c.StartTrace(ctx, "abandoned")
+	_, _, _ = trace.Span(ctx, "a")
+	_, _, _ = trace.Span(ctx, "b")
+
+	if n := c.otlp.buffered(); n != 2 {
+		t.Errorf("expected 2 buffered entries, got %d", n)
+	}
+	if err := c.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if got := server.spansByName()["abandoned"]; len(got) != 1 {
+		t.Errorf("expected the oldest entry to be exported, got %d spans", len(got))
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if got := server.spanCount(); got != 3 {
+		t.Errorf("expected 3 spans after Close, got %d", got)
+	}
+}
@@ -0,0 +1,254 @@
+package langfuse
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/agentplexus/omniobserve/observops"
+)
+
+// Default queue settings.
+const (
+	DefaultQueueSize = 10000
+	DefaultWorkers   = 2
+)
+
+// OverflowPolicy determines what happens when an event is enqueued while
+// the queue is full.
+type OverflowPolicy int
+
+const (
+	// OverflowDropNewest discards the event being enqueued.
+	OverflowDropNewest OverflowPolicy = iota
+	// OverflowDropOldest discards the oldest queued event to make room.
+	OverflowDropOldest
+	// OverflowBlock blocks the caller until there is room in the queue.
+	OverflowBlock
+)
+
+// String returns the policy name.
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowDropOldest:
+		return "drop_oldest"
+	case OverflowBlock:
+		return "block"
+	default:
+		return "drop_newest"
+	}
+}
+
+// Stats holds ingestion counters since the client was created.
+// Events evicted by OverflowDropOldest count as both enqueued and dropped.
+type Stats struct {
+	Enqueued int64 // Events accepted into the queue
+	Sent     int64 // Events delivered to Langfuse
+	Dropped  int64 // Events discarded because the queue was full or closed
+	Failed   int64 // Events whose delivery failed, including spooled events
+	Spooled  int64 // Failed events saved to the spool directory
+	Queued   int64 // Events currently waiting in the queue
+	InFlight int64 // Events currently being sent
+}
+
+// Stats returns the client's ingestion counters.
+func (c *Client) Stats() Stats {
+	c.mu.Lock()
+	queued, sending := len(c.queue), c.sending
+	c.mu.Unlock()
+
+	return Stats{
+		Enqueued: c.stats.enqueued.Load(),
+		Sent:     c.stats.sent.Load(),
+		Dropped:  c.stats.dropped.Load(),
+		Failed:   c.stats.failed.Load(),
+		Spooled:  c.stats.spooled.Load(),
+		Queued:   int64(queued),
+		InFlight: int64(sending),
+	}
+}
+
+// queueStats tracks ingestion counters and mirrors them to an
+// observops.Meter when one is configured.
+type queueStats struct {
+	enqueued atomic.Int64
+	sent     atomic.Int64
+	dropped  atomic.Int64
+	failed   atomic.Int64
+	spooled  atomic.Int64
+
+	enqueuedCounter observops.Counter
+	sentCounter     observops.Counter
+	droppedCounter  observops.Counter
+	failedCounter   observops.Counter
+}
+
+// initMeter creates the metric instruments for the counters.
+func (s *queueStats) initMeter(meter observops.Meter) error {
+	counters := []struct {
+		dst  *observops.Counter
+		name string
+		desc string
+	}{
+		{&s.enqueuedCounter, "langfuse.events.enqueued", "Events accepted into the Langfuse ingestion queue"},
+		{&s.sentCounter, "langfuse.events.sent", "Events delivered to Langfuse"},
+		{&s.droppedCounter, "langfuse.events.dropped", "Events dropped because the ingestion queue was full"},
+		{&s.failedCounter, "langfuse.events.failed", "Events whose delivery to Langfuse failed"},
+	}
+	for _, c := range counters {
+		counter, err := meter.Counter(c.name,
+			observops.WithDescription(c.desc),
+			observops.WithUnit("{event}"),
+		)
+		if err != nil {
+			return fmt.Errorf("create %s counter: %w", c.name, err)
+		}
+		*c.dst = counter
+	}
+	return nil
+}
+
+func (s *queueStats) add(v *atomic.Int64, counter observops.Counter, n int) {
+	v.Add(int64(n))
+	if counter != nil {
+		counter.Add(context.Background(), float64(n))
+	}
+}
+
+// enqueue adds an event to the queue, applying the overflow policy when
+// the queue is full, and wakes a sender once a full batch is available.
+// In OTLP mode, trace and observation events are exported as spans instead.
+func (c *Client) enqueue(event Event) {
+	if c.otlp != nil && c.otlp.handle(event) {
+		return
+	}
+
+	c.mu.Lock()
+	for c.overflow == OverflowBlock && len(c.queue) >= c.queueSize && !c.closed {
+		c.notFull.Wait()
+	}
+	if c.closed {
+		c.mu.Unlock()
+		c.stats.add(&c.stats.dropped, c.stats.droppedCounter, 1)
+		return
+	}
+
+	if len(c.queue) >= c.queueSize {
+		if c.overflow != OverflowDropOldest {
+			c.mu.Unlock()
+			c.stats.add(&c.stats.dropped, c.stats.droppedCounter, 1)
+			return
+		}
+		c.queue[0] = Event{}
+		c.queue = c.queue[1:]
+		c.stats.add(&c.stats.dropped, c.stats.droppedCounter, 1)
+	}
+
+	c.queue = append(c.queue, event)
+	full := len(c.queue) >= c.batchSize
+	c.mu.Unlock()
+
+	c.stats.add(&c.stats.enqueued, c.stats.enqueuedCounter, 1)
+	if full {
+		c.wake()
+	}
+}
+
+// wake signals a sender worker without blocking.
+func (c *Client) wake() {
+	select {
+	case c.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+// worker sends batches until the client is closed.
+func (c *Client) worker() {
+	defer c.workers.Done()
+
+	for {
+		select {
+		case <-c.wakeCh:
+			c.drain(context.Background())
+		case <-c.stopCh:
+			return
+		}
+	}
+}
+
+// drain sends batches until no batch is ready.
+func (c *Client) drain(ctx context.Context) {
+	for {
+		batch := c.takeBatch()
+		if batch == nil {
+			return
+		}
+		c.sendQueued(ctx, batch)
+	}
+}
+
+// takeBatch removes the next batch from the queue. Partial batches are
+// only taken while a flush is in progress.
+func (c *Client) takeBatch() []Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := len(c.queue)
+	if n == 0 {
+		c.flushing = false
+		return nil
+	}
+	if n < c.batchSize && !c.flushing {
+		return nil
+	}
+	if n > c.batchSize {
+		n = c.batchSize
+	}
+
+	batch := make([]Event, n)
+	copy(batch, c.queue)
+	clear(c.queue[:n])
+	c.queue = c.queue[n:]
+
+	c.sending += n
+	c.sendingBatches++
+	c.notFull.Broadcast()
+	return batch
+}
+
+// sendQueued delivers a batch taken from the queue and updates the counters.
+func (c *Client) sendQueued(ctx context.Context, batch []Event) {
+	if failure := c.deliver(ctx, batch); failure != nil {
+		c.stats.add(&c.stats.failed, c.stats.failedCounter, failure.Events)
+		c.stats.spooled.Add(int64(failure.Spooled))
+		c.recordFailure(failure)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sending -= len(batch)
+	c.sendingBatches--
+	if c.sendingBatches == 0 {
+		close(c.idleCh)
+		c.idleCh = make(chan struct{})
+	}
+}
+
+// waitIdle waits until no batches are being sent.
+func (c *Client) waitIdle(ctx context.Context) error {
+	for {
+		c.mu.Lock()
+		if c.sendingBatches == 0 {
+			c.mu.Unlock()
+			return nil
+		}
+		idle := c.idleCh
+		c.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-idle:
+		}
+	}
+}
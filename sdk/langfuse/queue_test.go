@@ -0,0 +1,252 @@
+package langfuse
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingServer is an ingestion server whose first request blocks until
+// released, holding the client's only worker busy.
+type blockingServer struct {
+	*ingestionServer
+	started chan struct{}
+	release func()
+}
+
+func newBlockingServer(t *testing.T) *blockingServer {
+	t.Helper()
+	started := make(chan struct{})
+	released := make(chan struct{})
+	var once sync.Once
+	s := &blockingServer{
+		started: started,
+		release: func() { once.Do(func() { close(released) }) },
+	}
+	s.ingestionServer = newIngestionServer(t, func(_ http.ResponseWriter, n int, _ []Event) {
+		if n == 1 {
+			close(started)
+			<-released
+		}
+	})
+	return s
+}
+
+// newBlockedClient creates a client with one worker, a batch size of 2 and
+// a queue of 2, and waits until its worker is blocked sending e1 and e2.
+func newBlockedClient(t *testing.T, policy OverflowPolicy) (*Client, *blockingServer, []Event) {
+	t.Helper()
+	server := newBlockingServer(t)
+	c := newTestClient(t, server.ingestionServer,
+		WithWorkers(1),
+		WithBatchSize(2),
+		WithQueueSize(2),
+		WithOverflowPolicy(policy),
+	)
+	// Cleanups run last first, so the server is released before Close.
+	t.Cleanup(server.release)
+
+	events := testEvents(5)
+	c.enqueue(events[0])
+	c.enqueue(events[1])
+	select {
+	case <-server.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker did not send the first batch")
+	}
+	return c, server, events
+}
+
+func TestQueue_DropNewest(t *testing.T) {
+	c, server, events := newBlockedClient(t, OverflowDropNewest)
+
+	for _, e := range events[2:] {
+		c.enqueue(e)
+	}
+	stats := c.Stats()
+	if stats.Dropped != 1 || stats.Queued != 2 || stats.InFlight != 2 {
+		t.Errorf("expected 1 dropped, 2 queued, 2 in flight, got %+v", stats)
+	}
+
+	server.release()
+	if err := c.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if got := server.allEventIDs(); !equalIDs(got, []string{"e1", "e2", "e3", "e4"}) {
+		t.Errorf("expected e1-e4 with e5 dropped, got %v", got)
+	}
+}
+
+func TestQueue_DropOldest(t *testing.T) {
+	c, server, events := newBlockedClient(t, OverflowDropOldest)
+
+	for _, e := range events[2:] {
+		c.enqueue(e)
+	}
+	stats := c.Stats()
+	if stats.Dropped != 1 || stats.Enqueued != 5 || stats.Queued != 2 {
+		t.Errorf("expected 1 dropped, 5 enqueued, 2 queued, got %+v", stats)
+	}
+
+	server.release()
+	if err := c.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if got := server.allEventIDs(); !equalIDs(got, []string{"e1", "e2", "e4", "e5"}) {
+		t.Errorf("expected e1, e2, e4, e5 with e3 evicted, got %v", got)
+	}
+}
+
+func TestQueue_Block(t *testing.T) {
+	c, server, events := newBlockedClient(t, OverflowBlock)
+
+	c.enqueue(events[2])
+	c.enqueue(events[3])
+	done := make(chan struct{})
+	go func() {
+		c.enqueue(events[4])
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("enqueue returned while the queue was full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Sending e3 and e4 makes room and wakes the blocked caller
+	server.release()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("enqueue still blocked after the queue drained")
+	}
+
+	if err := c.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if got := server.allEventIDs(); !equalIDs(got, []string{"e1", "e2", "e3", "e4", "e5"}) {
+		t.Errorf("expected all events delivered, got %v", got)
+	}
+	if got := c.Stats().Dropped; got != 0 {
+		t.Errorf("expected no dropped events, got %d", got)
+	}
+}
+
+func TestQueue_BlockReleasedByClose(t *testing.T) {
+	c, server, events := newBlockedClient(t, OverflowBlock)
+
+	c.enqueue(events[2])
+	c.enqueue(events[3])
+	done := make(chan struct{})
+	go func() {
+		c.enqueue(events[4])
+		close(done)
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	closed := make(chan error, 1)
+	go func() { closed <- c.Close() }()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not wake the blocked caller")
+	}
+
+	server.release()
+	if err := <-closed; err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if got := c.Stats().Dropped; got != 1 {
+		t.Errorf("expected the blocked event to be dropped, got %d dropped", got)
+	}
+}
+
+func TestFlush_WaitsForInFlightBatches(t *testing.T) {
+	c, server, events := newBlockedClient(t, OverflowDropNewest)
+	c.enqueue(events[2])
+
+	flushed := make(chan error, 1)
+	go func() { flushed <- c.Flush(context.Background()) }()
+
+	select {
+	case <-flushed:
+		t.Fatal("Flush returned while a batch was in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	server.release()
+	if err := <-flushed; err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if got := c.Pending(); got != 0 {
+		t.Errorf("expected no pending events, got %d", got)
+	}
+	if got := server.allEventIDs(); !equalIDs(got, []string{"e1", "e2", "e3"}) {
+		t.Errorf("expected e1-e3, got %v", got)
+	}
+}
+
+func TestFlush_ContextCanceled(t *testing.T) {
+	c, _, _ := newBlockedClient(t, OverflowDropNewest)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := c.Flush(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestQueue_ConcurrentEnqueue(t *testing.T) {
+	server := newIngestionServer(t, nil)
+	c := newTestClient(t, server, WithWorkers(4), WithBatchSize(10), WithQueueSize(1000))
+
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 50 {
+				e := testEvents(1)[0]
+				e.ID = fmt.Sprintf("g%d-%d", g, i)
+				c.enqueue(e)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := c.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	stats := c.Stats()
+	if stats.Enqueued != 400 || stats.Sent != 400 || stats.Dropped != 0 {
+		t.Errorf("expected 400 enqueued and sent, got %+v", stats)
+	}
+	seen := make(map[string]bool)
+	for _, id := range server.allEventIDs() {
+		if seen[id] {
+			t.Errorf("event %s delivered twice", id)
+		}
+		seen[id] = true
+	}
+	if len(seen) != 400 {
+		t.Errorf("expected 400 distinct events, got %d", len(seen))
+	}
+}
+
+func TestEnqueue_AfterClose(t *testing.T) {
+	server := newIngestionServer(t, nil)
+	c := newTestClient(t, server)
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	c.enqueue(testEvents(1)[0])
+	if got := c.Stats().Dropped; got != 1 {
+		t.Errorf("expected event enqueued after Close to be dropped, got %d", got)
+	}
+}
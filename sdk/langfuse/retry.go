@@ -0,0 +1,132 @@
+package langfuse
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Default retry settings.
+const (
+	DefaultMaxRetries   = 5
+	DefaultRetryWait    = 500 * time.Millisecond
+	DefaultRetryMaxWait = 30 * time.Second
+)
+
+// sendWithRetry sends a batch, retrying network errors, 5xx, 408 and 429
+// responses with exponential backoff and jitter. Retry-After is honoured
+// when the server sends it. When Langfuse rejects only some events, only
+// those with a retryable status are sent again; the rest are reported to
+// the event error handler. On failure, it returns the undelivered events.
+func (c *Client) sendWithRetry(ctx context.Context, events []Event) ([]Event, error) {
+	for attempt := 0; ; attempt++ {
+		err := c.sendBatch(ctx, events)
+
+		var batchErr *BatchError
+		if errors.As(err, &batchErr) {
+			failed := c.retryableEvents(events, batchErr)
+			c.stats.add(&c.stats.sent, c.stats.sentCounter, len(events)-len(batchErr.Errors))
+			if len(failed) == 0 {
+				return nil, nil
+			}
+			events = failed
+		} else if err == nil {
+			c.stats.add(&c.stats.sent, c.stats.sentCounter, len(events))
+			return nil, nil
+		}
+
+		if !isRetryable(err) || attempt >= c.maxRetries {
+			return events, err
+		}
+
+		timer := time.NewTimer(c.backoff(attempt, err))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return events, err
+		case <-timer.C:
+		}
+	}
+}
+
+// retryableEvents returns the events rejected with a retryable status.
+// Events rejected permanently are counted as failed and reported.
+func (c *Client) retryableEvents(events []Event, batchErr *BatchError) []Event {
+	byID := make(map[string]Event, len(events))
+	for _, e := range events {
+		byID[e.ID] = e
+	}
+
+	var retry []Event
+	for _, eventErr := range batchErr.Errors {
+		if eventErr.Retryable() {
+			if e, ok := byID[eventErr.EventID]; ok {
+				retry = append(retry, e)
+				continue
+			}
+		}
+		c.stats.add(&c.stats.failed, c.stats.failedCounter, 1)
+		c.reportEventError(eventErr)
+	}
+	return retry
+}
+
+// backoff returns the wait before the given retry attempt.
+// The delay doubles with each attempt up to the maximum wait, with the
+// upper half randomized to avoid synchronized retries.
+func (c *Client) backoff(attempt int, err error) time.Duration {
+	wait := c.retryWait << attempt
+	if wait <= 0 || wait > c.retryMaxWait {
+		wait = c.retryMaxWait
+	}
+	if half := int64(wait / 2); half > 0 {
+		wait = time.Duration(half + rand.Int64N(half+1))
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > wait {
+		wait = apiErr.RetryAfter
+	}
+	return wait
+}
+
+// isRetryable reports whether a failed request may succeed if retried.
+func isRetryable(err error) bool {
+	var batchErr *BatchError
+	if errors.As(err, &batchErr) {
+		return true
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests ||
+			apiErr.StatusCode == http.StatusRequestTimeout ||
+			apiErr.StatusCode >= 500
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
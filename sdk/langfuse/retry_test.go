@@ -0,0 +1,350 @@
+package langfuse
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// Test Helpers
+// =============================================================================
+
+// ingestionServer is a fake Langfuse ingestion API. Each request is passed
+// to handle with its sequence number, starting at 1; a handler that writes
+// nothing accepts the batch.
+type ingestionServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests [][]Event
+	handle   func(w http.ResponseWriter, n int, batch []Event)
+}
+
+func newIngestionServer(t *testing.T, handle func(w http.ResponseWriter, n int, batch []Event)) *ingestionServer {
+	t.Helper()
+	s := &ingestionServer{handle: handle}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/public/ingestion" {
+			http.NotFound(w, r)
+			return
+		}
+		var req BatchIngestionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		s.mu.Lock()
+		s.requests = append(s.requests, req.Batch)
+		n := len(s.requests)
+		s.mu.Unlock()
+
+		if s.handle != nil {
+			s.handle(w, n, req.Batch)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// requestCount returns the number of ingestion requests received.
+func (s *ingestionServer) requestCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.requests)
+}
+
+// eventIDs returns the IDs of the events in each request.
+func (s *ingestionServer) eventIDs() [][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make([][]string, len(s.requests))
+	for i, batch := range s.requests {
+		for _, e := range batch {
+			ids[i] = append(ids[i], e.ID)
+		}
+	}
+	return ids
+}
+
+// allEventIDs returns the IDs of all events received, in order.
+func (s *ingestionServer) allEventIDs() []string {
+	var all []string
+	for _, ids := range s.eventIDs() {
+		all = append(all, ids...)
+	}
+	return all
+}
+
+// newTestClient creates a client sending to the server, with short retry
+// waits and no periodic flush.
+func newTestClient(t *testing.T, server *ingestionServer, opts ...Option) *Client {
+	t.Helper()
+	opts = append([]Option{
+		WithPublicKey("pk-test"),
+		WithSecretKey("sk-test"),
+		WithEndpoint(server.URL),
+		WithFlushPeriod(time.Hour),
+		WithRetryBackoff(time.Millisecond, 5*time.Millisecond),
+	}, opts...)
+	c, err := NewClient(opts...)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() {
+		c.mu.Lock()
+		closed := c.closed
+		c.mu.Unlock()
+		if !closed {
+			_ = c.Close()
+		}
+	})
+	return c
+}
+
+// testEvents returns n span events with IDs e1, e2, ...
+func testEvents(n int) []Event {
+	events := make([]Event, n)
+	for i := range events {
+		id := fmt.Sprintf("e%d", i+1)
+		events[i] = Event{
+			ID:        id,
+			Type:      EventTypeSpanCreate,
+			Timestamp: time.Unix(0, 0).UTC(),
+			Body:      SpanBody{ID: id, TraceID: "trace-1", Name: "span"},
+		}
+	}
+	return events
+}
+
+func equalIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// =============================================================================
+// Retry Tests
+// =============================================================================
+
+func TestSendWithRetry_RetriesServerErrors(t *testing.T) {
+	server := newIngestionServer(t, func(w http.ResponseWriter, n int, _ []Event) {
+		if n <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+	c := newTestClient(t, server)
+
+	failed, err := c.sendWithRetry(context.Background(), testEvents(3))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(failed) != 0 {
+		t.Errorf("expected no failed events, got %d", len(failed))
+	}
+	if got := server.requestCount(); got != 3 {
+		t.Errorf("expected 3 requests, got %d", got)
+	}
+	if got := c.Stats().Sent; got != 3 {
+		t.Errorf("expected 3 sent, got %d", got)
+	}
+}
+
+func TestSendWithRetry_GivesUp(t *testing.T) {
+	server := newIngestionServer(t, func(w http.ResponseWriter, _ int, _ []Event) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	c := newTestClient(t, server, WithMaxRetries(2))
+
+	failed, err := c.sendWithRetry(context.Background(), testEvents(2))
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected 500 APIError, got %v", err)
+	}
+	if len(failed) != 2 {
+		t.Errorf("expected 2 failed events, got %d", len(failed))
+	}
+	if got := server.requestCount(); got != 3 {
+		t.Errorf("expected 3 requests (1 + 2 retries), got %d", got)
+	}
+}
+
+func TestSendWithRetry_NonRetryable(t *testing.T) {
+	server := newIngestionServer(t, func(w http.ResponseWriter, _ int, _ []Event) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	c := newTestClient(t, server)
+
+	_, err := c.sendWithRetry(context.Background(), testEvents(1))
+	if err == nil || isRetryable(err) {
+		t.Fatalf("expected non-retryable error, got %v", err)
+	}
+	if got := server.requestCount(); got != 1 {
+		t.Errorf("expected 1 request, got %d", got)
+	}
+}
+
+func TestSendWithRetry_RetryAfter(t *testing.T) {
+	server := newIngestionServer(t, func(w http.ResponseWriter, _ int, _ []Event) {
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	c := newTestClient(t, server, WithMaxRetries(0))
+
+	_, err := c.sendWithRetry(context.Background(), testEvents(1))
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected APIError, got %v", err)
+	}
+	if apiErr.RetryAfter != 7*time.Second {
+		t.Errorf("expected Retry-After 7s, got %v", apiErr.RetryAfter)
+	}
+	if !isRetryable(err) {
+		t.Error("expected 429 to be retryable")
+	}
+	if wait := c.backoff(0, err); wait != 7*time.Second {
+		t.Errorf("expected backoff to honour Retry-After, got %v", wait)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	c := &Client{retryWait: 100 * time.Millisecond, retryMaxWait: time.Second}
+
+	tests := []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{0, 50 * time.Millisecond, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond, 400 * time.Millisecond},
+		{10, 500 * time.Millisecond, time.Second},
+		{100, 500 * time.Millisecond, time.Second},
+	}
+	for _, tt := range tests {
+		for range 20 {
+			wait := c.backoff(tt.attempt, errors.New("network"))
+			if wait < tt.min || wait > tt.max {
+				t.Errorf("attempt %d: wait %v outside [%v, %v]", tt.attempt, wait, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if got := parseRetryAfter("3"); got != 3*time.Second {
+		t.Errorf("expected 3s, got %v", got)
+	}
+	if got := parseRetryAfter(""); got != 0 {
+		t.Errorf("expected 0 for empty header, got %v", got)
+	}
+	if got := parseRetryAfter("soon"); got != 0 {
+		t.Errorf("expected 0 for invalid header, got %v", got)
+	}
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got := parseRetryAfter(date); got <= 50*time.Second || got > time.Minute {
+		t.Errorf("expected about 1m for HTTP date, got %v", got)
+	}
+}
+
+// =============================================================================
+// Spool Tests
+// =============================================================================
+
+func TestDeliver_SpoolsAndReplays(t *testing.T) {
+	dir := t.TempDir()
+
+	down := newIngestionServer(t, func(w http.ResponseWriter, _ int, _ []Event) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	c := newTestClient(t, down, WithMaxRetries(0), WithSpoolDir(dir))
+
+	failure := c.deliver(context.Background(), testEvents(3))
+	if failure == nil || failure.Events != 3 || failure.Spooled != 3 {
+		t.Fatalf("expected 3 spooled events, got %+v", failure)
+	}
+	if got := c.SpooledBatches(); got != 1 {
+		t.Fatalf("expected 1 spooled batch, got %d", got)
+	}
+
+	up := newIngestionServer(t, nil)
+	replay := newTestClient(t, up, WithSpoolDir(dir))
+	replay.replaying.Wait()
+
+	if got := up.allEventIDs(); !equalIDs(got, []string{"e1", "e2", "e3"}) {
+		t.Errorf("expected replayed events e1-e3, got %v", got)
+	}
+	if got := replay.SpooledBatches(); got != 0 {
+		t.Errorf("expected spool to be empty after replay, got %d", got)
+	}
+}
+
+func TestDeliver_KeepsEachBatchError(t *testing.T) {
+	server := newIngestionServer(t, func(w http.ResponseWriter, n int, _ []Event) {
+		if n == 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+	})
+	one, _ := json.Marshal(testEvents(1)[0])
+	c := newTestClient(t, server, WithMaxBatchBytes(len(one)+50))
+
+	failure := c.deliver(context.Background(), testEvents(2))
+	if failure == nil || failure.Events != 2 {
+		t.Fatalf("expected 2 undelivered events, got %+v", failure)
+	}
+	joined, ok := failure.Err.(interface{ Unwrap() []error })
+	if !ok {
+		t.Fatalf("expected the errors of both batches to be joined, got %v", failure.Err)
+	}
+	var statuses []int
+	for _, err := range joined.Unwrap() {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			statuses = append(statuses, apiErr.StatusCode)
+		}
+	}
+	if len(statuses) != 2 || statuses[0] != http.StatusUnauthorized || statuses[1] != http.StatusForbidden {
+		t.Errorf("expected the errors of both batches, got %v", failure.Err)
+	}
+}
+
+func TestDeliver_DoesNotSpoolPermanentFailures(t *testing.T) {
+	dir := t.TempDir()
+	server := newIngestionServer(t, func(w http.ResponseWriter, _ int, _ []Event) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	var mu sync.Mutex
+	var reported []EventError
+	c := newTestClient(t, server, WithSpoolDir(dir), WithEventErrorHandler(func(e EventError) {
+		mu.Lock()
+		reported = append(reported, e)
+		mu.Unlock()
+	}))
+
+	failure := c.deliver(context.Background(), testEvents(2))
+	if failure == nil || failure.Spooled != 0 {
+		t.Fatalf("expected unspooled failure, got %+v", failure)
+	}
+	if got := c.SpooledBatches(); got != 0 {
+		t.Errorf("expected no spooled batches, got %d", got)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reported) != 2 || reported[0].Status != http.StatusUnauthorized {
+		t.Errorf("expected 2 reported 401 errors, got %+v", reported)
+	}
+}
@@ -0,0 +1,186 @@
+package langfuse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MarshalJSON encodes the score value according to its data type.
+// Langfuse expects categorical values as a string in "value", and numeric
+// and boolean values as a number, including zero.
+func (b ScoreBody) MarshalJSON() ([]byte, error) {
+	type alias ScoreBody
+	var value any = b.Value
+	if b.DataType == ScoreDataTypeCategorical {
+		value = b.StringValue
+	}
+	return json.Marshal(struct {
+		alias
+		Value any `json:"value"`
+	}{
+		alias: alias(b),
+		Value: value,
+	})
+}
+
+// CreateScore creates a score synchronously and returns its ID.
+// Unlike Trace.Score, the score is not batched, so API errors are returned
+// to the caller.
+func (c *Client) CreateScore(ctx context.Context, score ScoreBody) (string, error) {
+	var result struct {
+		ID string `json:"id"`
+	}
+	err := c.doPost(ctx, "/api/public/scores", score, &result)
+	if err != nil {
+		return "", err
+	}
+	return result.ID, nil
+}
+
+// ScoreFilter filters scores returned by ListScores.
+type ScoreFilter struct {
+	TraceID       string
+	ObservationID string
+	SessionID     string
+	Name          string
+	Source        string // API, ANNOTATION, EVAL
+	DataType      string // NUMERIC, CATEGORICAL, BOOLEAN
+}
+
+// ListScores lists scores matching the filter with pagination.
+func (c *Client) ListScores(ctx context.Context, filter ScoreFilter, limit, page int) (*PaginatedResponse[Score], error) {
+	query := url.Values{}
+	query.Set("limit", fmt.Sprintf("%d", limit))
+	query.Set("page", fmt.Sprintf("%d", page))
+	if filter.TraceID != "" {
+		query.Set("traceId", filter.TraceID)
+	}
+	if filter.ObservationID != "" {
+		query.Set("observationId", filter.ObservationID)
+	}
+	if filter.SessionID != "" {
+		query.Set("sessionId", filter.SessionID)
+	}
+	if filter.Name != "" {
+		query.Set("name", filter.Name)
+	}
+	if filter.Source != "" {
+		query.Set("source", filter.Source)
+	}
+	if filter.DataType != "" {
+		query.Set("dataType", filter.DataType)
+	}
+
+	var result PaginatedResponse[Score]
+	err := c.doGet(ctx, "/api/public/v2/scores?"+query.Encode(), &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Score adds a score to a trace, or to an observation when observationID is set.
+// Use this when the trace or observation is not available in context.
+func (c *Client) Score(ctx context.Context, traceID, observationID, name string, value float64, opts ...ScoreOption) error {
+	if c.disabled {
+		return nil
+	}
+
+	c.enqueue(Event{
+		ID:        uuid.New().String(),
+		Type:      EventTypeScoreCreate,
+		Timestamp: time.Now(),
+		Body:      NewScoreBody(traceID, observationID, name, value, opts...),
+	})
+
+	return nil
+}
+
+// NewScoreBody builds the body of a score on a trace, or on an observation
+// when observationID is set, with a new ID. Use it with CreateScore to
+// create a score from the same options as Client.Score.
+func NewScoreBody(traceID, observationID, name string, value float64, opts ...ScoreOption) ScoreBody {
+	cfg := &scoreConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return ScoreBody{
+		ID:            uuid.New().String(),
+		TraceID:       traceID,
+		ObservationID: observationID,
+		Name:          name,
+		Value:         value,
+		Comment:       cfg.comment,
+		Source:        cfg.source,
+		StringValue:   cfg.stringValue,
+		DataType:      cfg.resolvedDataType(),
+		ConfigID:      cfg.configID,
+	}
+}
+
+// ScoreConfig defines the allowed values of a named score.
+type ScoreConfig struct {
+	ID          string          `json:"id,omitempty"`
+	Name        string          `json:"name"`
+	DataType    string          `json:"dataType"` // NUMERIC, CATEGORICAL, BOOLEAN
+	MinValue    *float64        `json:"minValue,omitempty"`
+	MaxValue    *float64        `json:"maxValue,omitempty"`
+	Categories  []ScoreCategory `json:"categories,omitempty"`
+	Description string          `json:"description,omitempty"`
+	IsArchived  bool            `json:"isArchived,omitempty"`
+	CreatedAt   time.Time       `json:"createdAt,omitempty"`
+	UpdatedAt   time.Time       `json:"updatedAt,omitempty"`
+}
+
+// ScoreCategory is an allowed value of a categorical score config.
+type ScoreCategory struct {
+	Label string  `json:"label"`
+	Value float64 `json:"value"`
+}
+
+// CreateScoreConfig creates a score config.
+func (c *Client) CreateScoreConfig(ctx context.Context, config ScoreConfig) (*ScoreConfig, error) {
+	req := map[string]any{
+		"name":     config.Name,
+		"dataType": config.DataType,
+	}
+	if config.MinValue != nil {
+		req["minValue"] = *config.MinValue
+	}
+	if config.MaxValue != nil {
+		req["maxValue"] = *config.MaxValue
+	}
+	if len(config.Categories) > 0 {
+		req["categories"] = config.Categories
+	}
+	if config.Description != "" {
+		req["description"] = config.Description
+	}
+
+	var result ScoreConfig
+	err := c.doPost(ctx, "/api/public/score-configs", req, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListScoreConfigs lists score configs with pagination.
+func (c *Client) ListScoreConfigs(ctx context.Context, limit, page int) ([]ScoreConfig, error) {
+	path := fmt.Sprintf("/api/public/score-configs?limit=%d&page=%d", limit, page)
+
+	var result struct {
+		Data []ScoreConfig `json:"data"`
+	}
+	err := c.doGet(ctx, path, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
@@ -0,0 +1,112 @@
+package langfuse
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session represents a Langfuse session, which groups the traces of a
+// multi-turn conversation sharing the same session ID.
+type Session struct {
+	ID        string      `json:"id"`
+	ProjectID string      `json:"projectId,omitempty"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Traces    []TraceInfo `json:"traces,omitempty"` // Only set by GetSession
+}
+
+// ListSessions lists sessions with pagination.
+func (c *Client) ListSessions(ctx context.Context, limit, page int) ([]Session, error) {
+	path := fmt.Sprintf("/api/public/sessions?limit=%d&page=%d", limit, page)
+
+	var result struct {
+		Data []Session `json:"data"`
+	}
+	err := c.doGet(ctx, path, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+// GetSession retrieves a session and its traces.
+// The traces do not include observations or scores; use GetTrace for details.
+func (c *Client) GetSession(ctx context.Context, sessionID string) (*Session, error) {
+	var result Session
+	err := c.doGet(ctx, "/api/public/sessions/"+url.PathEscape(sessionID), &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListSessionTraces lists the traces of a session in chronological order.
+// The traces do not include observations or scores; use GetTrace for details.
+func (c *Client) ListSessionTraces(ctx context.Context, sessionID string, limit, page int) ([]TraceInfo, error) {
+	path := fmt.Sprintf("/api/public/traces?sessionId=%s&orderBy=%s&limit=%d&page=%d",
+		url.QueryEscape(sessionID), url.QueryEscape("timestamp.asc"), limit, page)
+
+	// The list endpoint returns observation and score IDs rather than objects,
+	// so decode them separately from TraceInfo.
+	var result struct {
+		Data []struct {
+			TraceInfo
+			Observations []string `json:"observations,omitempty"`
+			Scores       []string `json:"scores,omitempty"`
+		} `json:"data"`
+	}
+	err := c.doGet(ctx, path, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	traces := make([]TraceInfo, len(result.Data))
+	for i, item := range result.Data {
+		traces[i] = item.TraceInfo
+	}
+	return traces, nil
+}
+
+// GetTrace retrieves a trace with its observations and scores.
+func (c *Client) GetTrace(ctx context.Context, traceID string) (*TraceInfo, error) {
+	var result TraceInfo
+	err := c.doGet(ctx, "/api/public/traces/"+url.PathEscape(traceID), &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ScoreSession adds a score to a whole session.
+func (c *Client) ScoreSession(ctx context.Context, sessionID, name string, value float64, opts ...ScoreOption) error {
+	if c.disabled {
+		return nil
+	}
+
+	cfg := &scoreConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	c.enqueue(Event{
+		ID:        uuid.New().String(),
+		Type:      EventTypeScoreCreate,
+		Timestamp: time.Now(),
+		Body: ScoreBody{
+			ID:          uuid.New().String(),
+			SessionID:   sessionID,
+			Name:        name,
+			Value:       value,
+			Comment:     cfg.comment,
+			Source:      cfg.source,
+			StringValue: cfg.stringValue,
+			DataType:    cfg.resolvedDataType(),
+			ConfigID:    cfg.configID,
+		},
+	})
+
+	return nil
+}
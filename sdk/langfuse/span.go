@@ -102,11 +102,15 @@ func (s *Span) End(ctx context.Context, opts ...SpanOption) error {
 	return nil
 }
 
-// Update updates the span.
+// Update updates the span. In OTLP mode, updates after End are dropped,
+// since the span has already been exported.
 func (s *Span) Update(ctx context.Context, opts ...SpanOption) error {
 	if s.disabled {
 		return nil
 	}
+	if s.ended && s.client.otlp != nil {
+		return nil
+	}
 
 	cfg := &spanConfig{}
 	for _, opt := range opts {
@@ -165,7 +169,7 @@ func (s *Span) Span(ctx context.Context, name string, opts ...SpanOption) (conte
 
 	child := &Span{
 		client:       s.client,
-		id:           uuid.New().String(),
+		id:           s.client.newObservationID(),
 		traceID:      s.traceID,
 		parentSpanID: s.id,
 		name:         name,
@@ -209,7 +213,7 @@ func (s *Span) Generation(ctx context.Context, name string, opts ...GenerationOp
 
 	gen := &Generation{
 		client:          s.client,
-		id:              uuid.New().String(),
+		id:              s.client.newObservationID(),
 		traceID:         s.traceID,
 		parentSpanID:    s.id,
 		name:            name,
@@ -269,7 +273,9 @@ func (s *Span) Score(ctx context.Context, name string, value float64, opts ...Sc
 			Value:         value,
 			Comment:       cfg.comment,
 			Source:        cfg.source,
-			DataType:      "NUMERIC",
+			StringValue:   cfg.stringValue,
+			DataType:      cfg.resolvedDataType(),
+			ConfigID:      cfg.configID,
 		},
 	})
 
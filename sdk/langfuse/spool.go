@@ -0,0 +1,128 @@
+package langfuse
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// spoolFileExt is the extension of spooled batch files.
+const spoolFileExt = ".json"
+
+// spool writes undelivered events to the spool directory so they can be
+// replayed by a later client.
+func (c *Client) spool(events []Event) error {
+	name := fmt.Sprintf("batch-%d-%s", time.Now().UnixNano(), uuid.New().String())
+	return writeSpoolFile(filepath.Join(c.spoolDir, name+spoolFileExt), events)
+}
+
+// writeSpoolFile writes events to a spool file atomically, replacing any
+// existing file.
+func writeSpoolFile(path string, events []Event) error {
+	data, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf("marshal spool: %w", err)
+	}
+
+	tmp := strings.TrimSuffix(path, spoolFileExt) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("write spool: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("write spool: %w", err)
+	}
+	return nil
+}
+
+// spoolFiles returns the spooled batch files, oldest first.
+func (c *Client) spoolFiles() ([]string, error) {
+	entries, err := os.ReadDir(c.spoolDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), spoolFileExt) {
+			continue
+		}
+		files = append(files, filepath.Join(c.spoolDir, e.Name()))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// SpooledBatches returns the number of batches waiting in the spool directory.
+func (c *Client) SpooledBatches() int {
+	if c.spoolDir == "" {
+		return 0
+	}
+	files, err := c.spoolFiles()
+	if err != nil {
+		return 0
+	}
+	return len(files)
+}
+
+// replaySpool sends the batches left in the spool directory by a previous
+// client. It stops at the first file that fails with a retryable error,
+// leaving it and any later files for the next replay. The file is rewritten
+// with only its undelivered events first, so events already accepted are
+// not sent again. Events rejected with a non-retryable error are discarded.
+// When ctx is cancelled, the replay stops the same way, keeping the events
+// it has not sent.
+func (c *Client) replaySpool(ctx context.Context) error {
+	files, err := c.spoolFiles()
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		var events []Event
+		if err := json.Unmarshal(data, &events); err != nil {
+			// Corrupt files can never be delivered.
+			_ = os.Remove(file)
+			continue
+		}
+
+		batches := c.splitBatch(ctx, events)
+		for i, batch := range batches {
+			failed, err := batch, ctx.Err()
+			if err == nil {
+				failed, err = c.sendWithRetry(ctx, batch)
+				if err == nil || (!isRetryable(err) && ctx.Err() == nil) {
+					continue
+				}
+			}
+
+			remaining := append([]Event(nil), failed...)
+			for _, b := range batches[i+1:] {
+				remaining = append(remaining, b...)
+			}
+			if writeErr := writeSpoolFile(file, remaining); writeErr != nil {
+				return errors.Join(err, writeErr)
+			}
+			return err
+		}
+		_ = os.Remove(file)
+	}
+	return nil
+}
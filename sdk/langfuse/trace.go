@@ -89,14 +89,22 @@ func (t *Trace) End(ctx context.Context, opts ...TraceOption) error {
 		},
 	})
 
+	if t.client.otlp != nil {
+		t.client.otlp.endTrace(t.id, endTime)
+	}
+
 	return nil
 }
 
-// Update updates the trace.
+// Update updates the trace. In OTLP mode, updates after End are dropped,
+// since the trace has already been exported.
 func (t *Trace) Update(ctx context.Context, opts ...TraceOption) error {
 	if t.disabled {
 		return nil
 	}
+	if t.ended && t.client.otlp != nil {
+		return nil
+	}
 
 	cfg := &traceConfig{}
 	for _, opt := range opts {
@@ -151,7 +159,7 @@ func (t *Trace) Span(ctx context.Context, name string, opts ...SpanOption) (cont
 
 	span := &Span{
 		client:    t.client,
-		id:        uuid.New().String(),
+		id:        t.client.newObservationID(),
 		traceID:   t.id,
 		name:      name,
 		startTime: time.Now(),
@@ -193,7 +201,7 @@ func (t *Trace) Generation(ctx context.Context, name string, opts ...GenerationO
 
 	gen := &Generation{
 		client:          t.client,
-		id:              uuid.New().String(),
+		id:              t.client.newObservationID(),
 		traceID:         t.id,
 		name:            name,
 		startTime:       time.Now(),
@@ -244,13 +252,15 @@ func (t *Trace) Score(ctx context.Context, name string, value float64, opts ...S
 		Type:      EventTypeScoreCreate,
 		Timestamp: time.Now(),
 		Body: ScoreBody{
-			ID:       uuid.New().String(),
-			TraceID:  t.id,
-			Name:     name,
-			Value:    value,
-			Comment:  cfg.comment,
-			Source:   cfg.source,
-			DataType: "NUMERIC",
+			ID:          uuid.New().String(),
+			TraceID:     t.id,
+			Name:        name,
+			Value:       value,
+			Comment:     cfg.comment,
+			Source:      cfg.source,
+			StringValue: cfg.stringValue,
+			DataType:    cfg.resolvedDataType(),
+			ConfigID:    cfg.configID,
 		},
 	})
 
@@ -259,9 +269,19 @@ func (t *Trace) Score(ctx context.Context, name string, value float64, opts ...S
 
 // scoreConfig holds score configuration.
 type scoreConfig struct {
-	comment  string
-	source   string
-	dataType string
+	comment     string
+	source      string
+	dataType    string
+	stringValue string
+	configID    string
+}
+
+// resolvedDataType returns the configured data type, defaulting to NUMERIC.
+func (c *scoreConfig) resolvedDataType() string {
+	if c.dataType != "" {
+		return c.dataType
+	}
+	return ScoreDataTypeNumeric
 }
 
 // ScoreOption configures score creation.
@@ -281,9 +301,23 @@ func WithScoreSource(source string) ScoreOption {
 	}
 }
 
-// WithScoreDataType sets the score data type.
+// WithScoreDataType sets the score data type (NUMERIC, CATEGORICAL, BOOLEAN).
 func WithScoreDataType(dataType string) ScoreOption {
 	return func(c *scoreConfig) {
 		c.dataType = dataType
 	}
 }
+
+// WithScoreStringValue sets the value of a categorical score.
+func WithScoreStringValue(value string) ScoreOption {
+	return func(c *scoreConfig) {
+		c.stringValue = value
+	}
+}
+
+// WithScoreConfigID links the score to a score config.
+func WithScoreConfigID(configID string) ScoreOption {
+	return func(c *scoreConfig) {
+		c.configID = configID
+	}
+}
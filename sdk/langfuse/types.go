@@ -13,6 +13,20 @@ const (
 	EventTypeEventCreate      = "event-create"
 )
 
+// Score data types.
+const (
+	ScoreDataTypeNumeric     = "NUMERIC"
+	ScoreDataTypeCategorical = "CATEGORICAL"
+	ScoreDataTypeBoolean     = "BOOLEAN"
+)
+
+// Score sources.
+const (
+	ScoreSourceAPI        = "API"
+	ScoreSourceAnnotation = "ANNOTATION"
+	ScoreSourceEval       = "EVAL"
+)
+
 // BatchIngestionRequest is the request body for batch ingestion.
 type BatchIngestionRequest struct {
 	Batch    []Event        `json:"batch"`
@@ -95,7 +109,8 @@ type Usage struct {
 // ScoreBody represents the body of a score event.
 type ScoreBody struct {
 	ID            string  `json:"id"`
-	TraceID       string  `json:"traceId"`
+	TraceID       string  `json:"traceId,omitempty"`
+	SessionID     string  `json:"sessionId,omitempty"` // Set for session-level scores
 	ObservationID string  `json:"observationId,omitempty"`
 	Name          string  `json:"name"`
 	Value         float64 `json:"value,omitempty"`
@@ -103,6 +118,7 @@ type ScoreBody struct {
 	DataType      string  `json:"dataType,omitempty"` // NUMERIC, CATEGORICAL, BOOLEAN
 	Comment       string  `json:"comment,omitempty"`
 	Source        string  `json:"source,omitempty"` // API, ANNOTATION, EVAL
+	ConfigID      string  `json:"configId,omitempty"`
 }
 
 // Observation represents an observation from the API.
@@ -135,6 +151,8 @@ type TraceInfo struct {
 	Input        any            `json:"input,omitempty"`
 	Output       any            `json:"output,omitempty"`
 	Public       bool           `json:"public,omitempty"`
+	Latency      float64        `json:"latency,omitempty"`   // Seconds
+	TotalCost    float64        `json:"totalCost,omitempty"` // USD
 	Observations []Observation  `json:"observations,omitempty"`
 	Scores       []Score        `json:"scores,omitempty"`
 }
@@ -142,7 +160,8 @@ type TraceInfo struct {
 // Score represents a score from the API.
 type Score struct {
 	ID            string    `json:"id"`
-	TraceID       string    `json:"traceId"`
+	TraceID       string    `json:"traceId,omitempty"`
+	SessionID     string    `json:"sessionId,omitempty"`
 	ObservationID string    `json:"observationId,omitempty"`
 	Name          string    `json:"name"`
 	Value         float64   `json:"value,omitempty"`
@@ -150,7 +169,10 @@ type Score struct {
 	DataType      string    `json:"dataType,omitempty"`
 	Comment       string    `json:"comment,omitempty"`
 	Source        string    `json:"source,omitempty"`
+	ConfigID      string    `json:"configId,omitempty"`
 	Timestamp     time.Time `json:"timestamp,omitempty"`
+	CreatedAt     time.Time `json:"createdAt,omitempty"`
+	UpdatedAt     time.Time `json:"updatedAt,omitempty"`
 }
 
 // Dataset represents a dataset.